@@ -1,12 +1,17 @@
 package hashing
 
 import (
+	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"hash"
 	"hash/fnv"
 	"log"
+	"reflect"
+	"runtime"
 	"sort"
+	"strings"
 	"sync"
 	"slices"
 )
@@ -16,6 +21,10 @@ var (
 	ErrNodeExists = errors.New("node already exists")
 	ErrNodeNotFound = errors.New("node not found")
 	ErrInHashingKey = errors.New("error in hashing the key")
+	ErrInvalidCount = errors.New("requested node count must be at least 1")
+	ErrInvalidConfig = errors.New("invalid ring configuration")
+	ErrNilPredicate = errors.New("predicate must not be nil")
+	ErrNoHealthyNodes = errors.New("no healthy nodes available")
 )
 
 type ICacheNode interface {
@@ -24,7 +33,11 @@ type ICacheNode interface {
 
 type hashRingConfig struct {
 	HashFunction func() hash.Hash64
+	HashName string
 	EnableLogs bool
+	HashTags bool
+	KeyNormalizer func(string) string
+	SeedNodes []ICacheNode
 }
 
 type HashRingConfigFn func(*hashRingConfig)
@@ -32,6 +45,61 @@ type HashRingConfigFn func(*hashRingConfig)
 func SetHashFunction(f func() hash.Hash64) HashRingConfigFn {
 	return func (config *hashRingConfig) {
 		config.HashFunction = f
+		config.HashName = ""
+	}
+}
+
+// SetHashFunctionNamed is SetHashFunction with a display name reported by
+// HashFunctionName and String in place of the function's symbol.
+func SetHashFunctionNamed(name string, f func() hash.Hash64) HashRingConfigFn {
+	return func (config *hashRingConfig) {
+		config.HashFunction = f
+		config.HashName = name
+	}
+}
+
+// EnableHashTags makes lookups hash only the part of a key between the first
+// '{' and the next '}', as Redis Cluster does, so keys sharing a tag such as
+// order:{cust42}:items and order:{cust42}:total land on the same node. Keys
+// without a tag, or with an empty one, are hashed whole.
+func EnableHashTags(enabled bool) HashRingConfigFn {
+	return func (config *hashRingConfig) {
+		config.HashTags = enabled
+	}
+}
+
+// SetKeyNormalizer rewrites every lookup key with normalize before it is
+// hashed, e.g. DefaultKeyNormalizer so "User:1001 " and "user:1001" share a
+// node. Node identifiers are never normalized. nil disables normalization.
+func SetKeyNormalizer(normalize func(string) string) HashRingConfigFn {
+	return func (config *hashRingConfig) {
+		config.KeyNormalizer = normalize
+	}
+}
+
+// DefaultKeyNormalizer trims surrounding white space and lowercases ASCII
+// letters.
+func DefaultKeyNormalizer(key string) string {
+	key = strings.TrimSpace(key)
+	for i := 0; i < len(key); i++ {
+		if c := key[i]; 'A' <= c && c <= 'Z' {
+			lowered := []byte(key)
+			for j := i; j < len(lowered); j++ {
+				if c := lowered[j]; 'A' <= c && c <= 'Z' {
+					lowered[j] = c + ('a' - 'A')
+				}
+			}
+			return string(lowered)
+		}
+	}
+	return key
+}
+
+// WithNodes adds nodes to the ring as it is constructed. InitHashRingE fails
+// if any of them can't be added; InitHashRing skips those.
+func WithNodes(nodes ...ICacheNode) HashRingConfigFn {
+	return func (config *hashRingConfig) {
+		config.SeedNodes = append(config.SeedNodes, nodes...)
 	}
 }
 
@@ -44,11 +112,32 @@ func EnableVerboseLogs(enabled bool) HashRingConfigFn {
 type HashRing struct {
 	mu sync.RWMutex
 	config hashRingConfig
-	nodes sync.Map
+	nodes sync.Map // hash -> node
+	hostMap sync.Map // nodeId -> node
 	sortedKeysOfNodes []uint64
 }
 
 func InitHashRing(opts ...HashRingConfigFn) *HashRing {
+	ring := newHashRing(opts...)
+	ring.seed()
+	return ring
+}
+
+// InitHashRingE is InitHashRing but rejects nonsensical options instead of
+// building a ring that fails later. Every problem found is reported. A seed
+// node from WithNodes that can't be added fails construction as well.
+func InitHashRingE(opts ...HashRingConfigFn) (*HashRing, error) {
+	ring := newHashRing(opts...)
+	if err := ring.config.validate(); err != nil {
+		return nil, err
+	}
+	if err := ring.seed(); err != nil {
+		return nil, err
+	}
+	return ring, nil
+}
+
+func newHashRing(opts ...HashRingConfigFn) *HashRing {
 	config := &hashRingConfig{
 		HashFunction: fnv.New64a,
 		EnableLogs: false,
@@ -64,15 +153,43 @@ func InitHashRing(opts ...HashRingConfigFn) *HashRing {
 	}
 }
 
+// seed adds the nodes given with WithNodes.
+func (h *HashRing) seed() error {
+	nodes := h.config.SeedNodes
+	h.config.SeedNodes = nil
+
+	var errs []error
+	for _, node := range nodes {
+		if err := h.AddServer(node); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (cfg *hashRingConfig) validate() error {
+	var errs []error
+	if cfg.HashFunction == nil {
+		errs = append(errs, fmt.Errorf("%w: hash function must not be nil", ErrInvalidConfig))
+	}
+	return errors.Join(errs...)
+}
+
 func(h *HashRing) AddServer(node ICacheNode) error {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	hashValue, err := h.generateHash(node.GetIdentifier())
+	nodeId := node.GetIdentifier()
+	if _, exists := h.hostMap.Load(nodeId); exists {
+		return fmt.Errorf("%w : %s", ErrNodeExists, nodeId)
+	}
+
+	hashValue, err := h.generateHash(nodeId)
 	if err != nil {
-		return fmt.Errorf("%w : %s",ErrNodeExists, node.GetIdentifier())
+		return fmt.Errorf("%w : %s", ErrInHashingKey, nodeId)
 	}
 
+	h.hostMap.Store(nodeId, node)
 	h.nodes.Store(hashValue,node)
 	h.sortedKeysOfNodes = append(h.sortedKeysOfNodes, hashValue)
 
@@ -87,58 +204,304 @@ func(h *HashRing) AddServer(node ICacheNode) error {
 }
 
 func (h *HashRing) GetServer(key string) (ICacheNode, error) {
-	h.mu.Lock()
-	defer h.mu.Unlock()
+	h.mu.RLock()
+	defer h.mu.RUnlock()
 
-	hashValue, err := h.generateHash(key)
+	hashValue, err := h.hashKey(key)
 	if err != nil {
 		return nil, fmt.Errorf("%w : %s",ErrInHashingKey, key)
 	}
 
+	node, nodeHash, err := h.serverFor(hashValue)
+	if err != nil {
+		if errors.Is(err, ErrNodeNotFound) {
+			return nil, fmt.Errorf("%w: no node found for key %s", ErrNodeNotFound, key)
+		}
+		return nil, err
+	}
+
+	if h.config.EnableLogs{
+		log.Printf("[HashRing] Key '%s' (hash: %d) mapped to node (hash:%d)",key,hashValue,nodeHash)
+	}
+	return node, nil
+}
+
+// GetServerBytes is GetServer for keys held as bytes, hashing them without
+// converting to a string first.
+func (h *HashRing) GetServerBytes(key []byte) (ICacheNode, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	hashValue, err := h.hashKeyBytes(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w : %s", ErrInHashingKey, key)
+	}
+
+	node, _, err := h.serverFor(hashValue)
+	if err != nil {
+		if errors.Is(err, ErrNodeNotFound) {
+			return nil, fmt.Errorf("%w: no node found for key %s", ErrNodeNotFound, key)
+		}
+		return nil, err
+	}
+	return node, nil
+}
+
+// GetServerByHash is GetServer for callers that already hashed the key, e.g.
+// a routing token received over the wire. hash is used as the ring position
+// as is, so GetServerByHash(hash(k)) always agrees with GetServer(k).
+func (h *HashRing) GetServerByHash(hash uint64) (ICacheNode, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	node, _, err := h.serverFor(hash)
+	return node, err
+}
+
+// serverFor returns the node owning hashValue and its token. Callers must
+// hold h.mu.
+func (h *HashRing) serverFor(hashValue uint64) (ICacheNode, uint64, error) {
 	//performs a binary search on sortedKeyOfNodes
 	index,err := h.search(hashValue)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	nodeHash := h.sortedKeysOfNodes[index]
 	if node, ok := h.nodes.Load(nodeHash); ok {
-		if h.config.EnableLogs{
-			log.Printf("[HashRing] Key '%s' (hash: %d) mapped to node (hash:%d)",key,hashValue,nodeHash)
+		return node.(ICacheNode), nodeHash, nil
+	}
+
+	return nil, 0, fmt.Errorf("%w: no node owns hash %d", ErrNodeNotFound, hashValue)
+}
+
+// GetNServers returns up to n distinct nodes for key, walking clockwise from
+// the key's position. The first entry is the node GetServer would return;
+// fewer than n nodes are returned when the ring is smaller than n.
+func (h *HashRing) GetNServers(key string, n int) ([]ICacheNode, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("%w : %d", ErrInvalidCount, n)
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	hashValue, err := h.hashKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w : %s", ErrInHashingKey, key)
+	}
+
+	index, err := h.search(hashValue)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{})
+	servers := make([]ICacheNode, 0, n)
+	for i := 0; i < len(h.sortedKeysOfNodes) && len(servers) < n; i++ {
+		nodeHash := h.sortedKeysOfNodes[(index+i)%len(h.sortedKeysOfNodes)]
+		node, ok := h.nodes.Load(nodeHash)
+		if !ok {
+			continue
 		}
-		return node.(ICacheNode), nil
+		id := node.(ICacheNode).GetIdentifier()
+		if _, dup := seen[id]; dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		servers = append(servers, node.(ICacheNode))
+	}
+
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("%w: no node found for key %s", ErrNodeNotFound, key)
 	}
 
-	return nil, fmt.Errorf("%w: no node found for key %s", ErrNodeNotFound, key)
+	return servers, nil
 }
 
 func (h *HashRing) RemoveServer(node ICacheNode) error {
+	return h.RemoveServerByID(node.GetIdentifier())
+}
+
+// RemoveServerByID removes the node registered under nodeId, for callers
+// that no longer hold the node itself.
+func (h *HashRing) RemoveServerByID(nodeId string) error {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	hashValue, err := h.generateHash(node.GetIdentifier())
+	if _, exists := h.hostMap.Load(nodeId); !exists {
+		return fmt.Errorf("%w: %s", ErrNodeNotFound, nodeId)
+	}
+
+	hashValue, err := h.generateHash(nodeId)
 	if err != nil {
-		return fmt.Errorf("%w: %s", ErrInHashingKey, node.GetIdentifier())
+		return fmt.Errorf("%w: %s", ErrInHashingKey, nodeId)
 	}
 
-	if _, found := h.nodes.LoadAndDelete(hashValue); !found {
-		return fmt.Errorf("%w: %s", ErrNodeNotFound, node.GetIdentifier())
+	// exact match only: search() returns the successor and wraps around,
+	// which would drop a neighbouring node's token
+	index, found := slices.BinarySearch(h.sortedKeysOfNodes, hashValue)
+	if !found {
+		return fmt.Errorf("%w: no token for %s", ErrNodeNotFound, nodeId)
+	}
+
+	h.nodes.Delete(hashValue)
+	h.sortedKeysOfNodes = slices.Delete(h.sortedKeysOfNodes, index, index+1)
+	h.hostMap.Delete(nodeId)
+
+	if h.config.EnableLogs {
+		log.Printf("[HashRing] Removed node: %s (hash: %d)",nodeId, hashValue)
+	}
+
+	return nil
+}
+
+// GetServerWithFallback returns the first node clockwise from key for which
+// healthy returns true, trying every node once before failing with
+// ErrNoHealthyNodes. healthy is called without holding the ring's lock, so a
+// slow check never blocks AddServer or RemoveServer.
+func (h *HashRing) GetServerWithFallback(key string, healthy func(ICacheNode) bool) (ICacheNode, error) {
+	if healthy == nil {
+		return nil, ErrNilPredicate
+	}
+
+	candidates, err := h.candidates(key)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range candidates {
+		if healthy(node) {
+			return node, nil
+		}
+	}
+	return nil, fmt.Errorf("%w for key %s", ErrNoHealthyNodes, key)
+}
+
+// candidates snapshots every node in ring order starting at key's owner.
+func (h *HashRing) candidates(key string) ([]ICacheNode, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	hashValue, err := h.hashKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w : %s", ErrInHashingKey, key)
 	}
 
 	index, err := h.search(hashValue)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	h.sortedKeysOfNodes = append(h.sortedKeysOfNodes[:index], h.sortedKeysOfNodes[index+1:]...)
+	nodes := make([]ICacheNode, 0, len(h.sortedKeysOfNodes))
+	for i := range h.sortedKeysOfNodes {
+		nodeHash := h.sortedKeysOfNodes[(index+i)%len(h.sortedKeysOfNodes)]
+		if node, ok := h.nodes.Load(nodeHash); ok {
+			nodes = append(nodes, node.(ICacheNode))
+		}
+	}
+	return nodes, nil
+}
 
-	if h.config.EnableLogs {
-		log.Printf("[HashRing] Removed node: %s (hash: %d)",node.GetIdentifier(), hashValue)
+// Distribution resolves every key and counts how many land on each node,
+// keyed by node identifier. Lookups run on a copy of the layout taken under
+// a single read lock, so a large sample doesn't hold up writers.
+func (h *HashRing) Distribution(keys []string) (map[string]int, error) {
+	h.mu.RLock()
+	tokens := slices.Clone(h.sortedKeysOfNodes)
+	owners := make([]string, len(tokens))
+	for i, nodeHash := range tokens {
+		if node, ok := h.nodes.Load(nodeHash); ok {
+			owners[i] = node.(ICacheNode).GetIdentifier()
+		}
 	}
+	h.mu.RUnlock()
 
+	if len(tokens) == 0 {
+		return nil, ErrNoConnectedNodes
+	}
+
+	counts := make(map[string]int)
+	for _, key := range keys {
+		hashValue, err := h.hashKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("%w : %s", ErrInHashingKey, key)
+		}
+		index := sort.Search(len(tokens), func(i int) bool {
+			return tokens[i] >= hashValue
+		})
+		if index == len(tokens) {
+			index = 0
+		}
+		if owners[index] == "" {
+			return nil, fmt.Errorf("%w: no node owns hash %d", ErrNodeNotFound, hashValue)
+		}
+		counts[owners[index]]++
+	}
+	return counts, nil
+}
+
+// UpdateServer replaces the stored object for node's identifier, e.g. after
+// a reconnect hands out a new object for the same host. Its token stays
+// where it is, so no keys move.
+func (h *HashRing) UpdateServer(node ICacheNode) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	nodeId := node.GetIdentifier()
+	if _, exists := h.hostMap.Load(nodeId); !exists {
+		return fmt.Errorf("%w: %s", ErrNodeNotFound, nodeId)
+	}
+
+	hashValue, err := h.generateHash(nodeId)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInHashingKey, nodeId)
+	}
+	h.nodes.Store(hashValue, node)
+	h.hostMap.Store(nodeId, node)
 	return nil
 }
 
+// Members returns a snapshot of the ring's nodes sorted by identifier.
+func (h *HashRing) Members() []ICacheNode {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var members []ICacheNode
+	h.hostMap.Range(func(_, val any) bool {
+		members = append(members, val.(ICacheNode))
+		return true
+	})
+	slices.SortFunc(members, func(a, b ICacheNode) int {
+		return strings.Compare(a.GetIdentifier(), b.GetIdentifier())
+	})
+	return members
+}
+
+// MemberIDs is Members reduced to the sorted identifiers.
+func (h *HashRing) MemberIDs() []string {
+	members := h.Members()
+	ids := make([]string, len(members))
+	for i, node := range members {
+		ids[i] = node.GetIdentifier()
+	}
+	return ids
+}
+
+// NodeCount returns the number of nodes on the ring.
+func (h *HashRing) NodeCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.sortedKeysOfNodes)
+}
+
+// HasNode reports whether a node with identifier id is on the ring.
+func (h *HashRing) HasNode(id string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	_, ok := h.hostMap.Load(id)
+	return ok
+}
+
 func (h *HashRing) search(key uint64) (int, error) {
 	if len(h.sortedKeysOfNodes) == 0 {
 		return -1, ErrNoConnectedNodes
@@ -155,6 +518,57 @@ func (h *HashRing) search(key uint64) (int, error) {
 	return index, nil
 }
 
+// hashKey hashes a lookup key, applying the key normalizer and hash tags
+// when configured. Node identifiers are hashed with generateHash directly.
+func (h *HashRing) hashKey(key string) (uint64, error) {
+	if h.config.KeyNormalizer != nil {
+		key = h.config.KeyNormalizer(key)
+	}
+	if h.config.HashTags {
+		key = hashTag(key)
+	}
+	return h.generateHash(key)
+}
+
+func (h *HashRing) hashKeyBytes(key []byte) (uint64, error) {
+	if h.config.KeyNormalizer != nil {
+		key = []byte(h.config.KeyNormalizer(string(key)))
+	}
+	if h.config.HashTags {
+		key = hashTagBytes(key)
+	}
+	return h.generateHashBytes(key)
+}
+
+// hashTag returns the non-empty substring between the first '{' and the
+// next '}', or key itself.
+func hashTag(key string) string {
+	if open := strings.IndexByte(key, '{'); open >= 0 {
+		if end := strings.IndexByte(key[open+1:], '}'); end > 0 {
+			return key[open+1 : open+1+end]
+		}
+	}
+	return key
+}
+
+func hashTagBytes(key []byte) []byte {
+	if open := bytes.IndexByte(key, '{'); open >= 0 {
+		if end := bytes.IndexByte(key[open+1:], '}'); end > 0 {
+			return key[open+1 : open+1+end]
+		}
+	}
+	return key
+}
+
+func (h *HashRing) generateHashBytes(key []byte) (uint64, error) {
+	hash := h.config.HashFunction()
+	if _, err := hash.Write(key); err != nil {
+		return 0,err
+	}
+
+	return hash.Sum64(), nil
+}
+
 func (h *HashRing) generateHash(key string) (uint64, error) {
 	hash := h.config.HashFunction()
 	if _, err := hash.Write([]byte(key)); err != nil {
@@ -164,3 +578,81 @@ func (h *HashRing) generateHash(key string) (uint64, error) {
 	return hash.Sum64(), nil
 }
 
+
+// describeMaxTokens caps how many tokens String prints.
+const describeMaxTokens = 20
+
+// String summarises the ring for debugging: node count, hash function and
+// the first tokens in ring order. The output is deterministic for a given
+// layout, so it can be diffed.
+func (h *HashRing) String() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "HashRing{nodes: %d, vnodes: %d, hash: %s}\n", len(h.sortedKeysOfNodes), h.VirtualNodes(), h.HashFunctionName())
+	for i, hash := range h.sortedKeysOfNodes {
+		if i == describeMaxTokens {
+			fmt.Fprintf(&b, "  … and %d more tokens\n", len(h.sortedKeysOfNodes)-i)
+			break
+		}
+		id := "?"
+		if node, ok := h.nodes.Load(hash); ok {
+			id = node.(ICacheNode).GetIdentifier()
+		}
+		fmt.Fprintf(&b, "  %20d %s\n", hash, id)
+	}
+	return b.String()
+}
+
+// VirtualNodes returns how many positions each node gets. This ring places
+// every node exactly once.
+func (h *HashRing) VirtualNodes() int {
+	return 1
+}
+
+// HashFunctionName returns the name given with SetHashFunctionNamed, or the
+// hash function's qualified symbol name otherwise.
+func (h *HashRing) HashFunctionName() string {
+	if h.config.HashName != "" {
+		return h.config.HashName
+	}
+	return funcName(h.config.HashFunction)
+}
+
+// Checksum digests the token table, each token with the identifier of the
+// node owning it, so that rings built independently from the same membership
+// can confirm they place keys identically by comparing one number. Any add or
+// remove alters it. It always uses FNV-1a, not the ring's hash function, and
+// depends only on the layout, so it is stable across processes.
+func (h *HashRing) Checksum() uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	sum := fnv.New64a()
+	var buf [8]byte
+	for _, nodeHash := range h.sortedKeysOfNodes {
+		node, ok := h.nodes.Load(nodeHash)
+		if !ok {
+			continue
+		}
+		binary.BigEndian.PutUint64(buf[:], nodeHash)
+		sum.Write(buf[:])
+		sum.Write([]byte(node.(ICacheNode).GetIdentifier()))
+		// separates identifiers so "ab"+"c" and "a"+"bc" differ
+		sum.Write([]byte{0})
+	}
+	return sum.Sum64()
+}
+
+// funcName returns the qualified name of a function value, or "<nil>".
+func funcName(f any) string {
+	v := reflect.ValueOf(f)
+	if !v.IsValid() || v.IsNil() {
+		return "<nil>"
+	}
+	if fn := runtime.FuncForPC(v.Pointer()); fn != nil {
+		return fn.Name()
+	}
+	return "<unknown>"
+}
@@ -0,0 +1,951 @@
+package hashing
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testNode string
+
+func (n testNode) GetIdentifier() string { return string(n) }
+
+// numericHash hashes decimal input to its own value, so a node named "200"
+// sits at ring position 200. Anything else falls back to FNV-1a.
+type numericHash struct{ buf []byte }
+
+func newNumericHash() hash.Hash64 { return &numericHash{} }
+
+func (n *numericHash) Write(p []byte) (int, error) {
+	n.buf = append(n.buf, p...)
+	return len(p), nil
+}
+
+func (n *numericHash) Sum64() uint64 {
+	if v, err := strconv.ParseUint(string(n.buf), 10, 64); err == nil {
+		return v
+	}
+	f := fnv.New64a()
+	f.Write(n.buf)
+	return f.Sum64()
+}
+
+func (n *numericHash) Sum(b []byte) []byte {
+	return binary.BigEndian.AppendUint64(b, n.Sum64())
+}
+func (n *numericHash) Reset()         { n.buf = n.buf[:0] }
+func (n *numericHash) Size() int      { return 8 }
+func (n *numericHash) BlockSize() int { return 1 }
+
+// newTestRing builds a ring on numericHash holding the given node ids.
+func newTestRing(t testing.TB, ids ...string) *HashRing {
+	t.Helper()
+	nodes := make([]ICacheNode, len(ids))
+	for i, id := range ids {
+		nodes[i] = testNode(id)
+	}
+	ring, err := InitHashRingE(SetHashFunction(newNumericHash), WithNodes(nodes...))
+	if err != nil {
+		t.Fatalf("InitHashRingE: %v", err)
+	}
+	return ring
+}
+
+// ids lists the identifiers of nodes in order.
+func ids(nodes []ICacheNode) []string {
+	out := make([]string, len(nodes))
+	for i, n := range nodes {
+		out[i] = n.GetIdentifier()
+	}
+	return out
+}
+
+func TestGetNServers(t *testing.T) {
+	ring := newTestRing(t, "100", "200", "300")
+
+	tests := []struct {
+		name string
+		key  string
+		n    int
+		want []string
+	}{
+		{"one", "150", 1, []string{"200"}},
+		{"clockwise order", "150", 2, []string{"200", "300"}},
+		{"wraps past the end", "250", 3, []string{"300", "100", "200"}},
+		{"exact position", "100", 2, []string{"100", "200"}},
+		{"more than the ring holds", "350", 5, []string{"100", "200", "300"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ring.GetNServers(tt.key, tt.n)
+			if err != nil {
+				t.Fatalf("GetNServers(%q, %d): %v", tt.key, tt.n, err)
+			}
+			if !slices.Equal(ids(got), tt.want) {
+				t.Fatalf("GetNServers(%q, %d) = %v, want %v", tt.key, tt.n, ids(got), tt.want)
+			}
+			primary, err := ring.GetServer(tt.key)
+			if err != nil {
+				t.Fatalf("GetServer(%q): %v", tt.key, err)
+			}
+			if got[0].GetIdentifier() != primary.GetIdentifier() {
+				t.Fatalf("first server %s, GetServer says %s", got[0].GetIdentifier(), primary.GetIdentifier())
+			}
+		})
+	}
+}
+
+func TestGetNServersErrors(t *testing.T) {
+	ring := newTestRing(t, "100")
+	if _, err := ring.GetNServers("k", 0); !errors.Is(err, ErrInvalidCount) {
+		t.Fatalf("n=0: got %v, want ErrInvalidCount", err)
+	}
+	if _, err := InitHashRing().GetNServers("k", 1); !errors.Is(err, ErrNoConnectedNodes) {
+		t.Fatalf("empty ring: got %v, want ErrNoConnectedNodes", err)
+	}
+}
+
+func TestAddServerRejectsDuplicates(t *testing.T) {
+	ring := newTestRing(t, "100", "200")
+
+	if err := ring.AddServer(testNode("100")); !errors.Is(err, ErrNodeExists) {
+		t.Fatalf("duplicate add: got %v, want ErrNodeExists", err)
+	}
+	if got := len(ring.sortedKeysOfNodes); got != 2 {
+		t.Fatalf("%d tokens after a duplicate add, want 2", got)
+	}
+	if got := ring.NodeCount(); got != 2 {
+		t.Fatalf("%d nodes after a duplicate add, want 2", got)
+	}
+
+	// 100 still owns exactly its own arc, (200, 100] wrapping
+	counts, err := ring.Distribution([]string{"50", "100", "150", "200", "250"})
+	if err != nil {
+		t.Fatalf("Distribution: %v", err)
+	}
+	if counts["100"] != 3 || counts["200"] != 2 {
+		t.Fatalf("distribution %v, want 100:3 200:2", counts)
+	}
+}
+
+func TestRemoveServerExactToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		remove string
+		want   map[string]string // key -> owner afterwards
+	}{
+		{"max token", "300", map[string]string{"50": "100", "250": "100", "350": "100", "150": "200"}},
+		{"min token", "100", map[string]string{"50": "200", "150": "200", "250": "300", "350": "200"}},
+		{"neighbours", "200", map[string]string{"150": "300", "199": "300", "201": "300", "50": "100"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring := newTestRing(t, "100", "200", "300")
+			if err := ring.RemoveServerByID(tt.remove); err != nil {
+				t.Fatalf("RemoveServerByID(%s): %v", tt.remove, err)
+			}
+			if got := len(ring.sortedKeysOfNodes); got != 2 {
+				t.Fatalf("%d tokens left, want 2", got)
+			}
+			for key, want := range tt.want {
+				node, err := ring.GetServer(key)
+				if err != nil {
+					t.Fatalf("GetServer(%s): %v", key, err)
+				}
+				if node.GetIdentifier() != want {
+					t.Errorf("GetServer(%s) = %s, want %s", key, node.GetIdentifier(), want)
+				}
+			}
+		})
+	}
+
+	ring := newTestRing(t, "100")
+	if err := ring.RemoveServerByID("999"); !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("unknown node: got %v, want ErrNodeNotFound", err)
+	}
+}
+
+func TestGetServerConcurrentWithWriter(t *testing.T) {
+	ring := newTestRing(t, "100", "200", "300")
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if _, err := ring.GetServer(strconv.Itoa(i % 1000)); err != nil {
+					t.Errorf("GetServer: %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	// the base nodes never leave, so every lookup has an owner
+	for i := range 200 {
+		id := strconv.Itoa(400 + i)
+		if err := ring.AddServer(testNode(id)); err != nil {
+			t.Fatalf("AddServer(%s): %v", id, err)
+		}
+		if err := ring.RemoveServerByID(id); err != nil {
+			t.Fatalf("RemoveServerByID(%s): %v", id, err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func BenchmarkGetServerParallel(b *testing.B) {
+	ring := newTestRing(b, "100", "200", "300", "400", "500")
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			if _, err := ring.GetServer(keys[i%len(keys)]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestInitHashRingEValidates(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []HashRingConfigFn
+		want int // number of problems reported
+	}{
+		{"defaults", nil, 0},
+		{"nil hash function", []HashRingConfigFn{SetHashFunction(nil)}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring, err := InitHashRingE(tt.opts...)
+			if tt.want == 0 {
+				if err != nil || ring == nil {
+					t.Fatalf("InitHashRingE: got %v, want a ring", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidConfig) || ring != nil {
+				t.Fatalf("InitHashRingE: got %v, want ErrInvalidConfig and no ring", err)
+			}
+			joined, ok := err.(interface{ Unwrap() []error })
+			if !ok || len(joined.Unwrap()) != tt.want {
+				t.Fatalf("InitHashRingE reported %v, want %d problems", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveServerAndByIDAgree(t *testing.T) {
+	byNode := newTestRing(t, "100", "200", "300")
+	byID := newTestRing(t, "100", "200", "300")
+
+	if err := byNode.RemoveServer(testNode("200")); err != nil {
+		t.Fatalf("RemoveServer: %v", err)
+	}
+	if err := byID.RemoveServerByID("200"); err != nil {
+		t.Fatalf("RemoveServerByID: %v", err)
+	}
+	if !slices.Equal(byNode.sortedKeysOfNodes, byID.sortedKeysOfNodes) {
+		t.Fatalf("tokens %v and %v differ", byNode.sortedKeysOfNodes, byID.sortedKeysOfNodes)
+	}
+
+	// either method sees the other's removal
+	if err := byNode.RemoveServerByID("200"); !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("RemoveServerByID after RemoveServer: got %v, want ErrNodeNotFound", err)
+	}
+	if err := byID.RemoveServer(testNode("200")); !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("RemoveServer after RemoveServerByID: got %v, want ErrNodeNotFound", err)
+	}
+	for _, key := range []string{"150", "250", "350"} {
+		a, errA := byNode.GetServer(key)
+		b, errB := byID.GetServer(key)
+		if errA != nil || errB != nil || a.GetIdentifier() != b.GetIdentifier() {
+			t.Fatalf("GetServer(%s): %v (%v) vs %v (%v)", key, a, errA, b, errB)
+		}
+	}
+}
+
+func TestMembersUnderConcurrentChanges(t *testing.T) {
+	ring := newTestRing(t, "100", "200")
+	base := []string{"100", "200"}
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			id := strconv.Itoa(300 + i)
+			if err := ring.AddServer(testNode(id)); err != nil {
+				t.Errorf("AddServer(%s): %v", id, err)
+				return
+			}
+			if err := ring.RemoveServerByID(id); err != nil {
+				t.Errorf("RemoveServerByID(%s): %v", id, err)
+				return
+			}
+		}
+	}()
+
+	for range 500 {
+		members := ring.Members()
+		got := ids(members)
+		if !slices.IsSorted(got) || len(slices.Compact(slices.Clone(got))) != len(got) {
+			t.Fatalf("Members returned %v, want sorted and distinct", got)
+		}
+		for _, id := range base {
+			if !slices.Contains(got, id) {
+				t.Fatalf("Members returned %v without %s", got, id)
+			}
+		}
+		if memberIDs := ring.MemberIDs(); !slices.IsSorted(memberIDs) {
+			t.Fatalf("MemberIDs returned %v, want sorted", memberIDs)
+		}
+		// the snapshot is the caller's to keep
+		members[0] = nil
+	}
+	close(stop)
+	wg.Wait()
+
+	if got := ring.MemberIDs(); !slices.Equal(got, base) {
+		t.Fatalf("MemberIDs = %v, want %v", got, base)
+	}
+	if got := ids(ring.Members()); !slices.Equal(got, base) {
+		t.Fatalf("Members = %v, want %v", got, base)
+	}
+}
+
+func TestNodeCounts(t *testing.T) {
+	ring := newTestRing(t, "100", "200")
+	check := func(step string, nodes int) {
+		t.Helper()
+		if got := ring.NodeCount(); got != nodes {
+			t.Fatalf("%s: NodeCount = %d, want %d", step, got, nodes)
+		}
+		if got := len(ring.sortedKeysOfNodes); got != nodes*1 {
+			t.Fatalf("%s: %d tokens, want %d", step, got, nodes*1)
+		}
+	}
+	check("initial", 2)
+
+	id := strconv.Itoa(300 + 0)
+	if err := ring.AddServer(testNode(id)); err != nil {
+		t.Fatalf("AddServer: %v", err)
+	}
+	check("after add", 3)
+
+	if err := ring.AddServer(testNode(id)); !errors.Is(err, ErrNodeExists) {
+		t.Fatalf("duplicate add: got %v, want ErrNodeExists", err)
+	}
+	check("after duplicate add", 3)
+
+	if err := ring.RemoveServerByID(id); err != nil {
+		t.Fatalf("RemoveServerByID: %v", err)
+	}
+	check("after remove", 2)
+
+	if err := ring.RemoveServerByID(id); !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("second remove: got %v, want ErrNodeNotFound", err)
+	}
+	check("after failed remove", 2)
+}
+
+func TestHasNode(t *testing.T) {
+	ring := newTestRing(t, "100", "200")
+	id := strconv.Itoa(300 + 0)
+
+	if ring.HasNode(id) {
+		t.Fatalf("HasNode(%s) before it was added", id)
+	}
+	if err := ring.AddServer(testNode(id)); err != nil {
+		t.Fatalf("AddServer: %v", err)
+	}
+	if !ring.HasNode(id) {
+		t.Fatalf("HasNode(%s) false right after the add", id)
+	}
+	if err := ring.RemoveServerByID(id); err != nil {
+		t.Fatalf("RemoveServerByID: %v", err)
+	}
+	if ring.HasNode(id) {
+		t.Fatalf("HasNode(%s) true after the remove", id)
+	}
+}
+
+func TestHasNodeUnderConcurrentChanges(t *testing.T) {
+	ring := newTestRing(t, "100", "200")
+	base := []string{"100", "200"}
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				for _, id := range base {
+					if !ring.HasNode(id) {
+						t.Errorf("HasNode(%s) false while it was never removed", id)
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	// every add and remove is visible to the writer straight away
+	for i := range 200 {
+		id := strconv.Itoa(300 + i)
+		if err := ring.AddServer(testNode(id)); err != nil {
+			t.Fatalf("AddServer(%s): %v", id, err)
+		}
+		if !ring.HasNode(id) {
+			t.Fatalf("HasNode(%s) false right after the add", id)
+		}
+		if err := ring.RemoveServerByID(id); err != nil {
+			t.Fatalf("RemoveServerByID(%s): %v", id, err)
+		}
+		if ring.HasNode(id) {
+			t.Fatalf("HasNode(%s) true after the remove", id)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestGetServerWithFallback(t *testing.T) {
+	ring := newTestRing(t, "100", "200", "300")
+	down := map[string]bool{"200": true}
+	healthy := func(n ICacheNode) bool { return !down[n.GetIdentifier()] }
+
+	node, err := ring.GetServerWithFallback("150", healthy)
+	if err != nil || node.GetIdentifier() != "300" {
+		t.Fatalf("GetServerWithFallback(150) = %v, %v; want 300", node, err)
+	}
+
+	// the walk wraps past the last token
+	down["300"] = true
+	node, err = ring.GetServerWithFallback("150", healthy)
+	if err != nil || node.GetIdentifier() != "100" {
+		t.Fatalf("GetServerWithFallback(150) = %v, %v; want 100", node, err)
+	}
+
+	down["100"] = true
+	if _, err := ring.GetServerWithFallback("150", healthy); !errors.Is(err, ErrNoHealthyNodes) {
+		t.Fatalf("every node down: got %v, want ErrNoHealthyNodes", err)
+	}
+	if _, err := ring.GetServerWithFallback("150", nil); !errors.Is(err, ErrNilPredicate) {
+		t.Fatalf("nil predicate: got %v, want ErrNilPredicate", err)
+	}
+}
+
+func TestGetServerWithFallbackDoesNotHoldLock(t *testing.T) {
+	ring := newTestRing(t, "100", "200", "300")
+
+	calls := 0
+	healthy := func(ICacheNode) bool {
+		calls++
+		if calls > 1 {
+			return true
+		}
+		// a slow health check must not keep writers out
+		done := make(chan error, 1)
+		go func() { done <- ring.AddServer(testNode("400")) }()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("AddServer: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("AddServer blocked while the health check ran")
+		}
+		return false
+	}
+
+	if _, err := ring.GetServerWithFallback("150", healthy); err != nil {
+		t.Fatalf("GetServerWithFallback: %v", err)
+	}
+	if !ring.HasNode("400") {
+		t.Fatal("the add made during the health check is missing")
+	}
+}
+
+func TestGetServerByHashAgreesWithGetServer(t *testing.T) {
+	ring := InitHashRing(SetHashFunction(fnv.New64a))
+	for i := range 20 {
+		if err := ring.AddServer(testNode(fmt.Sprintf("node-%d", i))); err != nil {
+			t.Fatalf("AddServer: %v", err)
+		}
+	}
+
+	for i := range 500 {
+		key := fmt.Sprintf("user:%d", i)
+		h := fnv.New64a()
+		h.Write([]byte(key))
+
+		viaKey, err := ring.GetServer(key)
+		if err != nil {
+			t.Fatalf("GetServer(%s): %v", key, err)
+		}
+		viaHash, err := ring.GetServerByHash(h.Sum64())
+		if err != nil {
+			t.Fatalf("GetServerByHash: %v", err)
+		}
+		if viaKey.GetIdentifier() != viaHash.GetIdentifier() {
+			t.Fatalf("key %s: GetServer %s, GetServerByHash %s", key, viaKey.GetIdentifier(), viaHash.GetIdentifier())
+		}
+	}
+}
+
+func TestGetServerByHashEmptyRing(t *testing.T) {
+	ring := InitHashRing()
+	_, keyErr := ring.GetServer("k")
+	_, hashErr := ring.GetServerByHash(42)
+	if !errors.Is(keyErr, ErrNoConnectedNodes) || !errors.Is(hashErr, ErrNoConnectedNodes) {
+		t.Fatalf("empty ring: GetServer %v, GetServerByHash %v; want ErrNoConnectedNodes from both", keyErr, hashErr)
+	}
+}
+
+func TestGetServerBytes(t *testing.T) {
+	ring := InitHashRing()
+	for i := range 10 {
+		if err := ring.AddServer(testNode(fmt.Sprintf("node-%d", i))); err != nil {
+			t.Fatalf("AddServer: %v", err)
+		}
+	}
+
+	for i := range 500 {
+		key := fmt.Sprintf("user:%d", i)
+		viaString, err := ring.GetServer(key)
+		if err != nil {
+			t.Fatalf("GetServer(%s): %v", key, err)
+		}
+		viaBytes, err := ring.GetServerBytes([]byte(key))
+		if err != nil {
+			t.Fatalf("GetServerBytes(%s): %v", key, err)
+		}
+		if viaString.GetIdentifier() != viaBytes.GetIdentifier() {
+			t.Fatalf("key %s: GetServer %s, GetServerBytes %s", key, viaString.GetIdentifier(), viaBytes.GetIdentifier())
+		}
+	}
+
+	// the byte path saves the conversions a string lookup needs
+	key := []byte("user:42")
+	viaBytes := testing.AllocsPerRun(100, func() { ring.GetServerBytes(key) })
+	viaString := testing.AllocsPerRun(100, func() { ring.GetServer(string(key)) })
+	if viaBytes >= viaString {
+		t.Fatalf("GetServerBytes allocates %v times per lookup, GetServer(string(key)) %v", viaBytes, viaString)
+	}
+}
+
+func BenchmarkGetServerBytes(b *testing.B) {
+	ring := InitHashRing()
+	for i := range 10 {
+		if err := ring.AddServer(testNode(fmt.Sprintf("node-%d", i))); err != nil {
+			b.Fatal(err)
+		}
+	}
+	key := []byte("user:42")
+
+	b.Run("string", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := ring.GetServer(string(key)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("bytes", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := ring.GetServerBytes(key); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestString(t *testing.T) {
+	build := func(ids ...string) *HashRing {
+		ring := InitHashRing(SetHashFunctionNamed("numeric", newNumericHash))
+		for _, id := range ids {
+			if err := ring.AddServer(testNode(id)); err != nil {
+				t.Fatalf("AddServer(%s): %v", id, err)
+			}
+		}
+		return ring
+	}
+
+	want := `HashRing{nodes: 3, vnodes: 1, hash: numeric}
+                   100 100
+                   200 200
+                   300 300
+`
+	ring := build("300", "100", "200")
+	if got := fmt.Sprint(ring); got != want {
+		t.Fatalf("String() =\n%s\nwant\n%s", got, want)
+	}
+	if got := build("200", "300", "100").String(); got != want {
+		t.Fatalf("insertion order changed String():\n%s", got)
+	}
+
+	ids := make([]string, 25)
+	for i := range ids {
+		ids[i] = strconv.Itoa(100 + i)
+	}
+	big := build(ids...)
+
+	// large rings end with a count of the tokens left out
+	lines := strings.Split(strings.TrimSuffix(big.String(), "\n"), "\n")
+	if got := lines[len(lines)-1]; got != "  … and 5 more tokens" {
+		t.Fatalf("last line %q, want the truncation notice", got)
+	}
+	if got := len(lines); got != 22 {
+		t.Fatalf("%d lines, want 22", got)
+	}
+}
+
+// genNode is a node object that carries a generation, like a connection
+// wrapper recreated on reconnect.
+type genNode struct {
+	id  string
+	gen int
+}
+
+func (n genNode) GetIdentifier() string { return n.id }
+
+func TestUpdateServerConcurrentLookups(t *testing.T) {
+	ring := newTestRing(t, "100", "200", "300")
+	checksum := ring.Checksum()
+	keys := []string{"50", "150", "250", "350"}
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				node, err := ring.GetServer(keys[i%len(keys)])
+				if err != nil || node == nil {
+					t.Errorf("lookup during update: %v, %v", node, err)
+					return
+				}
+			}
+		}()
+	}
+
+	for gen := range 500 {
+		if err := ring.UpdateServer(genNode{"100", gen}); err != nil {
+			t.Fatalf("UpdateServer: %v", err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	if ring.Checksum() != checksum {
+		t.Fatal("UpdateServer moved tokens")
+	}
+	found := false
+	for _, node := range ring.Members() {
+		if node.GetIdentifier() == "100" {
+			found = node == genNode{"100", 499}
+		}
+	}
+	if !found {
+		t.Fatal("the latest object is not the one stored")
+	}
+	if err := ring.UpdateServer(testNode("missing")); !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("unknown node: got %v, want ErrNodeNotFound", err)
+	}
+}
+
+var hashTagCases = []struct{ key, want string }{
+	{"order:{cust42}:items", "cust42"},
+	{"{cust42}", "cust42"},
+	{"plain-key", "plain-key"},
+	{"empty:{}:tag", "empty:{}:tag"},
+	{"{}{cust42}", "{}{cust42}"},
+	{"nested:{{cust42}}", "{cust42"},
+	{"open:{cust42", "open:{cust42"},
+	{"close:}cust42{", "close:}cust42{"},
+	{"first:{a}:then:{b}", "a"},
+}
+
+func TestHashTag(t *testing.T) {
+	for _, tt := range hashTagCases {
+		if got := hashTag(tt.key); got != tt.want {
+			t.Errorf("hashTag(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+		if got := string(hashTagBytes([]byte(tt.key))); got != tt.want {
+			t.Errorf("hashTagBytes(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestEnableHashTags(t *testing.T) {
+	// nodes a quarter of the ring apart, so FNV-hashed keys spread over all
+	// of them while a numeric tag lands on a known node
+	quarters := []string{"4611686018427387904", "9223372036854775808", "13835058055282163712"}
+	nodes := make([]ICacheNode, len(quarters))
+	for i, id := range quarters {
+		nodes[i] = testNode(id)
+	}
+	tagged, err := InitHashRingE(SetHashFunction(newNumericHash), EnableHashTags(true), WithNodes(nodes...))
+	if err != nil {
+		t.Fatalf("InitHashRingE: %v", err)
+	}
+	untagged := newTestRing(t, quarters...)
+	lookup := func(r *HashRing, key string) string {
+		t.Helper()
+		node, err := r.GetServer(key)
+		if err != nil {
+			t.Fatalf("GetServer(%q): %v", key, err)
+		}
+		return node.GetIdentifier()
+	}
+
+	for _, key := range []string{"order:{150}:items", "order:{150}:total", "{150}"} {
+		if got := lookup(tagged, key); got != quarters[0] {
+			t.Errorf("GetServer(%q) = %s, want %s", key, got, quarters[0])
+		}
+		node, err := tagged.GetServerBytes([]byte(key))
+		if err != nil {
+			t.Fatalf("GetServerBytes(%q): %v", key, err)
+		}
+		if node.GetIdentifier() != quarters[0] {
+			t.Errorf("GetServerBytes(%q) = %s, want %s", key, node.GetIdentifier(), quarters[0])
+		}
+	}
+
+	// keys without a usable tag are hashed whole, as they are without the
+	// option, and keys sharing a tag only co-locate when it is enabled
+	spread := make(map[string]bool)
+	for i := range 50 {
+		for _, key := range []string{fmt.Sprintf("k%d", i), fmt.Sprintf("k%d:{}", i), fmt.Sprintf("k%d:{cust", i)} {
+			if got, want := lookup(tagged, key), lookup(untagged, key); got != want {
+				t.Errorf("GetServer(%q) = %s with hash tags, %s without", key, got, want)
+			}
+		}
+		key := fmt.Sprintf("user:{cust42}:%d", i)
+		if got, want := lookup(tagged, key), lookup(tagged, "cust42"); got != want {
+			t.Errorf("GetServer(%q) = %s, want %s like its tag", key, got, want)
+		}
+		spread[lookup(untagged, key)] = true
+	}
+	if len(spread) < 2 {
+		t.Errorf("keys sharing a tag all land on %v without hash tags", spread)
+	}
+}
+
+func TestDefaultKeyNormalizer(t *testing.T) {
+	tests := []struct{ key, want string }{
+		{"User:1001 ", "user:1001"},
+		{"\tSESSION:Abc\n", "session:abc"},
+		{"already:lower", "already:lower"},
+		{"Ünïcode:KEY", "Ünïcode:key"},
+		{"   ", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := DefaultKeyNormalizer(tt.key); got != tt.want {
+			t.Errorf("DefaultKeyNormalizer(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestSetKeyNormalizer(t *testing.T) {
+	quarters := []string{"4611686018427387904", "9223372036854775808", "13835058055282163712"}
+	nodes := make([]ICacheNode, len(quarters))
+	for i, id := range quarters {
+		nodes[i] = testNode(id)
+	}
+	normalized, err := InitHashRingE(SetHashFunction(newNumericHash), SetKeyNormalizer(DefaultKeyNormalizer), WithNodes(nodes...))
+	if err != nil {
+		t.Fatalf("InitHashRingE: %v", err)
+	}
+	plain := newTestRing(t, quarters...)
+	lookup := func(r *HashRing, key string) string {
+		t.Helper()
+		node, err := r.GetServer(key)
+		if err != nil {
+			t.Fatalf("GetServer(%q): %v", key, err)
+		}
+		return node.GetIdentifier()
+	}
+
+	if a, b := lookup(normalized, "User:1001 "), lookup(normalized, "user:1001"); a != b {
+		t.Errorf("with the normalizer %q is on %s and %q on %s", "User:1001 ", a, "user:1001", b)
+	}
+	if a, b := lookup(plain, "User:1001 "), lookup(plain, "user:1001"); a == b {
+		t.Errorf("without the normalizer both spellings land on %s", a)
+	}
+
+	for i := range 100 {
+		key := fmt.Sprintf("user:%d:session", i)
+		messy := " " + strings.ToUpper(key) + "\t"
+		if got, want := lookup(normalized, messy), lookup(plain, key); got != want {
+			t.Fatalf("GetServer(%q) = %s, want %s like %q", messy, got, want, key)
+		}
+		node, err := normalized.GetServerBytes([]byte(messy))
+		if err != nil {
+			t.Fatalf("GetServerBytes(%q): %v", messy, err)
+		}
+		if got := node.GetIdentifier(); got != lookup(plain, key) {
+			t.Fatalf("GetServerBytes(%q) = %s, want %s", messy, got, lookup(plain, key))
+		}
+	}
+
+	// node identifiers are hashed as given
+	mixed, err := InitHashRingE(SetKeyNormalizer(DefaultKeyNormalizer), WithNodes(testNode("Cache-A")))
+	if err != nil {
+		t.Fatalf("InitHashRingE: %v", err)
+	}
+	if !mixed.HasNode("Cache-A") || mixed.HasNode("cache-a") {
+		t.Error("the normalizer was applied to node identifiers")
+	}
+	if id := lookup(mixed, "key"); id != "Cache-A" {
+		t.Errorf("GetServer returned %s, want Cache-A", id)
+	}
+}
+
+func TestSetKeyNormalizerNil(t *testing.T) {
+	ring, err := InitHashRingE(SetKeyNormalizer(nil), WithNodes(testNode("a"), testNode("b")))
+	if err != nil {
+		t.Fatalf("InitHashRingE: %v", err)
+	}
+	node, err := ring.GetServer("user:1001")
+	if err != nil {
+		t.Fatalf("GetServer with a nil normalizer: %v", err)
+	}
+	plain, err := InitHashRingE(WithNodes(testNode("a"), testNode("b")))
+	if err != nil {
+		t.Fatalf("InitHashRingE: %v", err)
+	}
+	want, err := plain.GetServer("user:1001")
+	if err != nil {
+		t.Fatalf("GetServer: %v", err)
+	}
+	if node.GetIdentifier() != want.GetIdentifier() {
+		t.Fatalf("a nil normalizer moved the key from %s to %s", want.GetIdentifier(), node.GetIdentifier())
+	}
+}
+
+func TestWithNodes(t *testing.T) {
+	manual := InitHashRing()
+	for _, id := range []string{"a", "b", "c"} {
+		if err := manual.AddServer(testNode(id)); err != nil {
+			t.Fatalf("AddServer: %v", err)
+		}
+	}
+	seeded, err := InitHashRingE(WithNodes(testNode("a"), testNode("b")), WithNodes(testNode("c")))
+	if err != nil {
+		t.Fatalf("InitHashRingE: %v", err)
+	}
+	if !slices.Equal(seeded.sortedKeysOfNodes, manual.sortedKeysOfNodes) {
+		t.Fatal("a seeded ring has different tokens from one built with AddServer")
+	}
+	for i := range 500 {
+		key := fmt.Sprintf("user:%d:session", i)
+		got, err := seeded.GetServer(key)
+		if err != nil {
+			t.Fatalf("GetServer(%q): %v", key, err)
+		}
+		want, err := manual.GetServer(key)
+		if err != nil {
+			t.Fatalf("GetServer(%q): %v", key, err)
+		}
+		if got.GetIdentifier() != want.GetIdentifier() {
+			t.Fatalf("key %q on %s in the seeded ring, %s in the manual one", key, got.GetIdentifier(), want.GetIdentifier())
+		}
+	}
+}
+
+func TestWithNodesDuplicate(t *testing.T) {
+	seeds := []ICacheNode{testNode("a"), testNode("b"), testNode("a")}
+	ring, err := InitHashRingE(WithNodes(seeds...))
+	if !errors.Is(err, ErrNodeExists) {
+		t.Fatalf("InitHashRingE with a duplicate seed = %v, want ErrNodeExists", err)
+	}
+	if ring != nil {
+		t.Fatal("InitHashRingE returned a ring alongside the error")
+	}
+
+	// InitHashRing keeps the seeds that could be added
+	partial := InitHashRing(WithNodes(seeds...))
+	if got := slices.Sorted(slices.Values(ids(partial.Members()))); !slices.Equal(got, []string{"a", "b"}) {
+		t.Fatalf("InitHashRing kept %v, want [a b]", got)
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	ring := newTestRing(t, "100", "200", "300")
+	base := ring.Checksum()
+
+	// the same membership added in another order agrees
+	if other := newTestRing(t, "300", "100", "200"); other.Checksum() != base {
+		t.Fatal("rings with the same membership have different checksums")
+	}
+
+	// a fixed layout always digests to the same value, whichever process
+	// computes it
+	const golden uint64 = 8486727445734369922
+	if got := newTestRing(t, "100", "200").Checksum(); got != golden {
+		t.Errorf("Checksum() = %d, want %d", got, golden)
+	}
+
+	if err := ring.AddServer(testNode("400")); err != nil {
+		t.Fatalf("AddServer: %v", err)
+	}
+	added := ring.Checksum()
+	if added == base {
+		t.Fatal("adding a node left the checksum unchanged")
+	}
+	if err := ring.RemoveServerByID("400"); err != nil {
+		t.Fatalf("RemoveServerByID: %v", err)
+	}
+	if ring.Checksum() != base {
+		t.Fatal("restoring the membership did not restore the checksum")
+	}
+	if err := ring.RemoveServerByID("100"); err != nil {
+		t.Fatalf("RemoveServerByID: %v", err)
+	}
+	if removed := ring.Checksum(); removed == base || removed == added {
+		t.Fatal("removing a node did not give a new checksum")
+	}
+}
@@ -19,7 +19,7 @@ func (n *CacheNode) GetIdentifier() string {
 }
 
 func main() {
-	fmt.Println("=== Consistent Hashing Demo ===\n")
+	fmt.Print("=== Consistent Hashing Demo ===\n\n")
 
 	// Create test nodes
 	nodes := []*CacheNode{
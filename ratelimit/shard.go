@@ -0,0 +1,145 @@
+package ratelimit
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+
+	replicationhashing "github.com/Tanishq4501/go-hash/replication-hashing"
+)
+
+// DefaultMaxTrackedClients is how many recently sharded clients a Sharder
+// from NewSharder remembers for migration reports.
+const DefaultMaxTrackedClients = 10000
+
+// Migration describes a client whose rate-limit counter moved to a different
+// backend after a topology change.
+type Migration struct {
+	ClientID string
+	From     string
+	To       string
+}
+
+// Sharder pins rate-limit counters to backends using a consistent hash ring,
+// so a given client always increments the same counter node.
+type Sharder struct {
+	mu   sync.RWMutex // held for writing while backends change
+	ring *replicationhashing.HashRing
+
+	// the most recently sharded clients, for migration reports
+	trackMu    sync.Mutex
+	maxTracked int
+	recent     *list.List               // of *trackedClient, most recent first
+	owners     map[string]*list.Element // clientID -> element in recent
+}
+
+type trackedClient struct {
+	id    string
+	owner string // backend id
+}
+
+// NewSharder builds a Sharder tracking up to DefaultMaxTrackedClients
+// clients.
+func NewSharder(opts ...replicationhashing.HashRingConfigFn) *Sharder {
+	return NewSharderWithLimit(DefaultMaxTrackedClients, opts...)
+}
+
+// NewSharderWithLimit builds a Sharder that remembers the maxTracked most
+// recently sharded clients; migrations are only reported for those. Older
+// clients are forgotten, not moved: their next Shard call still finds the
+// right backend. maxTracked below 1 tracks nothing.
+func NewSharderWithLimit(maxTracked int, opts ...replicationhashing.HashRingConfigFn) *Sharder {
+	return &Sharder{
+		ring:       replicationhashing.InitHashRing(opts...),
+		maxTracked: maxTracked,
+		recent:     list.New(),
+		owners:     make(map[string]*list.Element),
+	}
+}
+
+// Shard returns the backend holding the counter for clientID. Lookups only
+// share a read lock, so concurrent Shard calls don't serialize on the ring.
+func (s *Sharder) Shard(clientID string) (replicationhashing.ICacheNode, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	node, err := s.ring.GetServer(clientID)
+	if err != nil {
+		return nil, err
+	}
+	s.track(clientID, node.GetIdentifier())
+	return node, nil
+}
+
+// track records clientID as the most recently sharded client, forgetting
+// the least recent once more than maxTracked are known.
+func (s *Sharder) track(clientID, owner string) {
+	if s.maxTracked < 1 {
+		return
+	}
+	s.trackMu.Lock()
+	defer s.trackMu.Unlock()
+
+	if e, ok := s.owners[clientID]; ok {
+		e.Value.(*trackedClient).owner = owner
+		s.recent.MoveToFront(e)
+		return
+	}
+	s.owners[clientID] = s.recent.PushFront(&trackedClient{id: clientID, owner: owner})
+	if s.recent.Len() > s.maxTracked {
+		oldest := s.recent.Remove(s.recent.Back()).(*trackedClient)
+		delete(s.owners, oldest.id)
+	}
+}
+
+// AddBackend adds a counter backend and reports the known clients whose
+// counters now belong to a different backend.
+func (s *Sharder) AddBackend(node replicationhashing.ICacheNode) ([]Migration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ring.AddServer(node); err != nil {
+		return nil, err
+	}
+	return s.reshard(), nil
+}
+
+// RemoveBackend removes a counter backend and reports the known clients whose
+// counters must be moved elsewhere.
+func (s *Sharder) RemoveBackend(node replicationhashing.ICacheNode) ([]Migration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ring.RemoveServer(node); err != nil {
+		return nil, err
+	}
+	return s.reshard(), nil
+}
+
+// reshard re-resolves every tracked client and records the ones that moved.
+// Callers must hold s.mu for writing.
+func (s *Sharder) reshard() []Migration {
+	s.trackMu.Lock()
+	defer s.trackMu.Unlock()
+
+	migrations := make([]Migration, 0)
+	for e := s.recent.Front(); e != nil; {
+		next := e.Next()
+		client := e.Value.(*trackedClient)
+		node, err := s.ring.GetServer(client.id)
+		if err != nil {
+			// ring is empty, nothing can own the counter anymore
+			s.recent.Remove(e)
+			delete(s.owners, client.id)
+			migrations = append(migrations, Migration{ClientID: client.id, From: client.owner})
+		} else if to := node.GetIdentifier(); to != client.owner {
+			migrations = append(migrations, Migration{ClientID: client.id, From: client.owner, To: to})
+			client.owner = to
+		}
+		e = next
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].ClientID < migrations[j].ClientID
+	})
+	return migrations
+}
@@ -0,0 +1,200 @@
+package ratelimit
+
+import (
+	"fmt"
+	"testing"
+
+	replicationhashing "github.com/Tanishq4501/go-hash/replication-hashing"
+)
+
+type backend string
+
+func (b backend) GetIdentifier() string { return string(b) }
+
+func newTestSharder(t *testing.T, ids ...string) *Sharder {
+	t.Helper()
+	s := NewSharder(replicationhashing.SetVirtualNodes(50))
+	for _, id := range ids {
+		if _, err := s.AddBackend(backend(id)); err != nil {
+			t.Fatalf("AddBackend(%s): %v", id, err)
+		}
+	}
+	return s
+}
+
+func clients(n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("client-%d-%d", i, i*7919)
+	}
+	return ids
+}
+
+func TestShardIsStable(t *testing.T) {
+	s := newTestSharder(t, "redis-1", "redis-2", "redis-3")
+
+	for _, client := range clients(200) {
+		first, err := s.Shard(client)
+		if err != nil {
+			t.Fatalf("Shard(%s): %v", client, err)
+		}
+		for i := 0; i < 5; i++ {
+			again, err := s.Shard(client)
+			if err != nil {
+				t.Fatalf("Shard(%s): %v", client, err)
+			}
+			if again.GetIdentifier() != first.GetIdentifier() {
+				t.Fatalf("client %s moved from %s to %s without a topology change",
+					client, first.GetIdentifier(), again.GetIdentifier())
+			}
+		}
+	}
+}
+
+func TestRemoveBackendRemapsMinimally(t *testing.T) {
+	s := newTestSharder(t, "redis-1", "redis-2", "redis-3", "redis-4")
+
+	before := make(map[string]string)
+	for _, client := range clients(500) {
+		node, err := s.Shard(client)
+		if err != nil {
+			t.Fatalf("Shard(%s): %v", client, err)
+		}
+		before[client] = node.GetIdentifier()
+	}
+
+	migrations, err := s.RemoveBackend(backend("redis-2"))
+	if err != nil {
+		t.Fatalf("RemoveBackend: %v", err)
+	}
+
+	onRemoved := 0
+	for _, id := range before {
+		if id == "redis-2" {
+			onRemoved++
+		}
+	}
+	if len(migrations) != onRemoved {
+		t.Fatalf("%d migrations, want exactly the %d clients of the removed backend", len(migrations), onRemoved)
+	}
+	for _, m := range migrations {
+		if m.From != "redis-2" || m.To == "" || m.To == "redis-2" {
+			t.Fatalf("unexpected migration %+v", m)
+		}
+	}
+
+	for client, was := range before {
+		node, err := s.Shard(client)
+		if err != nil {
+			t.Fatalf("Shard(%s): %v", client, err)
+		}
+		if was != "redis-2" && node.GetIdentifier() != was {
+			t.Fatalf("client %s moved from %s to %s although its backend stayed", client, was, node.GetIdentifier())
+		}
+	}
+}
+
+func TestRemoveLastBackend(t *testing.T) {
+	s := newTestSharder(t, "redis-1")
+	if _, err := s.Shard("client"); err != nil {
+		t.Fatalf("Shard: %v", err)
+	}
+
+	migrations, err := s.RemoveBackend(backend("redis-1"))
+	if err != nil {
+		t.Fatalf("RemoveBackend: %v", err)
+	}
+	if len(migrations) != 1 || migrations[0].To != "" {
+		t.Fatalf("got %+v, want one migration with no destination", migrations)
+	}
+	if _, err := s.Shard("client"); err == nil {
+		t.Fatal("Shard on an empty ring succeeded")
+	}
+}
+
+func TestShardTracksRecentClients(t *testing.T) {
+	s := NewSharderWithLimit(100, replicationhashing.SetVirtualNodes(50))
+	for _, id := range []string{"redis-1", "redis-2", "redis-3"} {
+		if _, err := s.AddBackend(backend(id)); err != nil {
+			t.Fatalf("AddBackend(%s): %v", id, err)
+		}
+	}
+
+	all := clients(300)
+	before := make(map[string]string)
+	for _, client := range all {
+		node, err := s.Shard(client)
+		if err != nil {
+			t.Fatalf("Shard(%s): %v", client, err)
+		}
+		before[client] = node.GetIdentifier()
+	}
+	if len(s.owners) != 100 {
+		t.Fatalf("tracking %d clients, want the limit of 100", len(s.owners))
+	}
+	// sharding an old client again makes it recent, evicting the oldest
+	if _, err := s.Shard(all[0]); err != nil {
+		t.Fatalf("Shard(%s): %v", all[0], err)
+	}
+	recent := append([]string{all[0]}, all[201:]...)
+
+	migrations, err := s.RemoveBackend(backend("redis-2"))
+	if err != nil {
+		t.Fatalf("RemoveBackend: %v", err)
+	}
+	want := 0
+	for _, client := range recent {
+		if before[client] == "redis-2" {
+			want++
+		}
+	}
+	if len(migrations) != want {
+		t.Fatalf("%d migrations, want the %d recent clients of the removed backend", len(migrations), want)
+	}
+	tracked := make(map[string]bool, len(recent))
+	for _, client := range recent {
+		tracked[client] = true
+	}
+	for _, m := range migrations {
+		if !tracked[m.ClientID] {
+			t.Fatalf("migration reported for forgotten client %s", m.ClientID)
+		}
+	}
+}
+
+func TestShardConcurrentWithTopologyChanges(t *testing.T) {
+	s := newTestSharder(t, "redis-1", "redis-2", "redis-3")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 20 {
+			if _, err := s.AddBackend(backend("redis-4")); err != nil {
+				t.Errorf("AddBackend: %v", err)
+				return
+			}
+			if _, err := s.RemoveBackend(backend("redis-4")); err != nil {
+				t.Errorf("RemoveBackend: %v", err)
+				return
+			}
+		}
+	}()
+	for range 10 {
+		for _, client := range clients(100) {
+			if _, err := s.Shard(client); err != nil {
+				t.Fatalf("Shard(%s): %v", client, err)
+			}
+		}
+	}
+	<-done
+
+	// every tracked owner matches the settled ring
+	for client, e := range s.owners {
+		node, err := s.ring.GetServer(client)
+		if err != nil {
+			t.Fatalf("GetServer(%s): %v", client, err)
+		}
+		if owner := e.Value.(*trackedClient).owner; owner != node.GetIdentifier() {
+			t.Fatalf("client %s tracked on %s, the ring says %s", client, owner, node.GetIdentifier())
+		}
+	}
+}
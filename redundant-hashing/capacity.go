@@ -0,0 +1,178 @@
+package redundanthashring
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+// SetReplicaCapacityAware makes GetNodesForKey prefer other nodes for
+// replica (not primary) duty on arcs where a node already holds more than
+// its share of replica assignments, that share being its fraction of the
+// routable ring positions, so small nodes aren't overloaded with secondary
+// copies. The shares are computed from the layout alone, so a key's replica
+// set depends only on membership.
+func SetReplicaCapacityAware(enabled bool) HashRingConfigFn {
+	return func(cfg *hashRingConfig) {
+		cfg.ReplicaCapacityAware = enabled
+	}
+}
+
+// SetMaxReplicaShare caps the fraction of all replica assignments any single
+// node may take across the hash space, measured by the length of the arcs it
+// holds replicas for. A node is skipped for replica duty on arcs where it has
+// already reached the cap and GetNodesForKey keeps walking clockwise, which
+// can yield fewer than ReplicationFactor nodes on small or skewed rings.
+// Zero disables the cap.
+func SetMaxReplicaShare(fraction float64) HashRingConfigFn {
+	return func(cfg *hashRingConfig) {
+		cfg.MaxReplicaShare = fraction
+	}
+}
+
+// underReplicaCap reports whether node may take replica duty for keys whose
+// walk starts at ring index start. Callers must hold ring.mu.
+func (ring *HashRing) underReplicaCap(start int, node ICacheNode) bool {
+	_, over := ring.overCap[ring.sortedKeys[start]][node.GetIdentifier()]
+	return !over
+}
+
+// hasReplicaHeadroom reports whether node is within its weighted share of
+// replica assignments for keys whose walk starts at ring index start.
+// Callers must hold ring.mu.
+func (ring *HashRing) hasReplicaHeadroom(start int, node ICacheNode) bool {
+	_, over := ring.overShare[ring.sortedKeys[start]][node.GetIdentifier()]
+	return !over
+}
+
+// refreshReplicaDuty recomputes which nodes are kept off replica duty for
+// each arc, by MaxReplicaShare or by their weighted share. It runs once
+// after every batch of changes to the layout or the drained set, so a key's
+// replica set is a pure function of membership. Callers must hold ring.mu
+// for writing.
+func (ring *HashRing) refreshReplicaDuty() {
+	ring.overCap, ring.overShare = nil, nil
+	if len(ring.sortedKeys) == 0 || (ring.config.MaxReplicaShare == 0 && !ring.config.ReplicaCapacityAware) {
+		return
+	}
+	ring.overCap, ring.overShare = ring.planReplicaDuty()
+}
+
+// planReplicaDuty hands out replica duty arc by arc in ring order, weighting
+// each assignment by the length of the arc, the way replicasFor will place
+// it. A node is barred from an arc while it already holds MaxReplicaShare of
+// what has been handed out so far, with one arc of slack so the very first
+// replicas can be placed at all. With ReplicaCapacityAware a node that would
+// rise above its weighted share by taking an arc is passed over on it; when
+// too few nodes have room, those furthest below their share take the arc
+// anyway. Both results map an arc's closing token to the nodes affected on
+// it. Callers must hold ring.mu.
+func (ring *HashRing) planReplicaDuty() (barred, overShare map[uint64]map[string]struct{}) {
+	keep := ring.routable(nil)
+	want := ring.config.ReplicationFactor
+	limit := ring.config.MaxReplicaShare
+	aware := ring.config.ReplicaCapacityAware
+
+	// weighted shares are taken over the nodes that can hold replicas
+	fair := make(map[string]float64, len(ring.positions))
+	if aware {
+		routable := 0
+		for id, n := range ring.positions {
+			if _, drained := ring.drained[id]; !drained {
+				routable += n
+			}
+		}
+		for id, n := range ring.positions {
+			if _, drained := ring.drained[id]; !drained {
+				fair[id] = float64(n) / float64(routable)
+			}
+		}
+	}
+
+	barred = make(map[uint64]map[string]struct{})
+	overShare = make(map[uint64]map[string]struct{})
+	load := make(map[string]float64)
+	total := 0.0
+	for i, h := range ring.sortedKeys {
+		arc := ring.arcLength(i)
+		var capped map[string]struct{}
+		for id, held := range load {
+			if limit > 0 && held >= limit*(total+arc) {
+				if capped == nil {
+					capped = make(map[string]struct{})
+				}
+				capped[id] = struct{}{}
+			}
+		}
+		if capped != nil {
+			barred[h] = capped
+		}
+
+		uncapped := ring.routable(func(n ICacheNode) bool {
+			_, skip := capped[n.GetIdentifier()]
+			return !skip
+		})
+		nodes := ring.collectNodes(i, make([]ICacheNode, 0, want), 1, keep)
+		if aware && len(nodes) > 0 {
+			if over := ring.overSharers(nodes[0], capped, fair, load, total, arc); over != nil {
+				overShare[h] = over
+				nodes = ring.collectNodes(i, nodes, want, func(n ICacheNode) bool {
+					_, skip := over[n.GetIdentifier()]
+					return !skip && uncapped(n)
+				})
+			}
+		}
+		nodes = ring.collectNodes(i, nodes, want, uncapped)
+		for _, r := range nodes[min(1, len(nodes)):] {
+			load[r.GetIdentifier()] += arc
+			total += arc
+		}
+	}
+	return barred, overShare
+}
+
+// overSharers returns the replica candidates for an arc owned by primary
+// that would rise above their weighted share by taking it, leaving at least
+// ReplicationFactor-1 candidates free, picked by how far below their share
+// they are. Capped nodes and the primary aren't candidates. Callers must
+// hold ring.mu.
+func (ring *HashRing) overSharers(primary ICacheNode, capped map[string]struct{}, fair, load map[string]float64, total, arc float64) map[string]struct{} {
+	spare := ring.config.ReplicationFactor - 1
+	handed := total + arc*float64(spare)
+
+	var candidates []string
+	for id := range fair {
+		if _, skip := capped[id]; !skip && id != primary.GetIdentifier() {
+			candidates = append(candidates, id)
+		}
+	}
+	// lowest fill ratio first, ties broken by ID so the plan is deterministic
+	fill := func(id string) float64 { return (load[id] + arc) / fair[id] }
+	slices.SortFunc(candidates, func(a, b string) int {
+		return cmp.Or(cmp.Compare(fill(a), fill(b)), cmp.Compare(a, b))
+	})
+
+	var over map[string]struct{}
+	for k, id := range candidates {
+		if k < spare || load[id]+arc <= fair[id]*handed {
+			continue
+		}
+		if over == nil {
+			over = make(map[string]struct{})
+		}
+		over[id] = struct{}{}
+	}
+	return over
+}
+
+// arcLength returns the length of the arc of keys whose walk starts at ring
+// index i, i.e. those in (previous token, sortedKeys[i]]. Callers must hold
+// ring.mu and ensure the ring is not empty.
+func (ring *HashRing) arcLength(i int) float64 {
+	n := len(ring.sortedKeys)
+	if n == 1 {
+		return math.MaxUint64
+	}
+	// unsigned subtraction wraps naturally for the first arc
+	return float64(ring.sortedKeys[i] - ring.sortedKeys[(i+n-1)%n])
+}
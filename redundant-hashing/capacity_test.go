@@ -0,0 +1,257 @@
+package redundanthashring
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+// newWeightedRing builds a ring with every node added at its weight.
+func newWeightedRing(t testing.TB, weights map[string]float64, opts ...HashRingConfigFn) *HashRing {
+	t.Helper()
+	ring := InitHashRing(opts...)
+	for _, id := range slices.Sorted(maps.Keys(weights)) {
+		if err := ring.AddNodeWithWeight(testNode(id), weights[id]); err != nil {
+			t.Fatalf("AddNodeWithWeight(%s): %v", id, err)
+		}
+	}
+	return ring
+}
+
+var skewedWeights = map[string]float64{"big1": 3, "big2": 3, "mid": 1, "small1": 0.25, "small2": 0.25}
+
+// replicaShares returns each node's fraction of the replica (not primary)
+// assignments for keys.
+func replicaShares(t *testing.T, ring *HashRing, keys []string) map[string]float64 {
+	t.Helper()
+	_, replicas, err := ring.ReplicaDistribution(keys)
+	if err != nil {
+		t.Fatalf("ReplicaDistribution: %v", err)
+	}
+	total := 0
+	for _, c := range replicas {
+		total += c
+	}
+	shares := make(map[string]float64, len(replicas))
+	for id, c := range replicas {
+		shares[id] = float64(c) / float64(total)
+	}
+	return shares
+}
+
+func TestReplicaCapacityAwareSparesSmallNodes(t *testing.T) {
+	// one secondary per key, so the weighted shares are reachable: the big
+	// nodes can back each other up on every arc
+	opts := []HashRingConfigFn{SetVirtualNodes(50), SetReplicationFactor(2), SetHashFunction(newSHAHash)}
+	ring := newWeightedRing(t, skewedWeights, append(opts, SetReplicaCapacityAware(true))...)
+	plain := newWeightedRing(t, skewedWeights, opts...)
+
+	keys := sampleKeys(20000)
+	before, after := replicaShares(t, plain, keys), replicaShares(t, ring, keys)
+	overloaded := false
+	for _, id := range []string{"small1", "small2"} {
+		weighted := float64(ring.positions[id]) / float64(len(ring.sortedKeys))
+		overloaded = overloaded || before[id] > weighted
+		// keys sample the arcs the plan balances, so allow a little noise
+		if after[id] > 1.1*weighted {
+			t.Errorf("%s holds %.3f of replicas, want at most its %.3f share", id, after[id], weighted)
+		}
+		if after[id] > before[id] {
+			t.Errorf("%s holds %.3f of replicas, more than the %.3f it holds without the option", id, after[id], before[id])
+		}
+	}
+	if !overloaded {
+		t.Fatal("no small node holds more than its share without the option; the layout proves nothing")
+	}
+}
+
+func TestReplicaCapacityAwareKeepsEqualWeightsBalanced(t *testing.T) {
+	keys := sampleKeys(20000)
+	nodes := testNodes("a", "b", "c", "d")
+
+	t.Run("even layout", func(t *testing.T) {
+		ring := newTestRing(t, nodes, SetVirtualNodes(50), SetReplicationFactor(2), SetHashFunction(newSHAHash), SetReplicaCapacityAware(true))
+		shares := replicaShares(t, ring, keys)
+		for _, n := range nodes {
+			if share := shares[n.GetIdentifier()]; share < 0.2 || share > 0.3 {
+				t.Errorf("%s holds %.3f of replicas, want about a quarter", n.GetIdentifier(), share)
+			}
+		}
+	})
+
+	// the default hash clusters virtual nodes, so no placement evens this
+	// layout out, but the option must not make it worse
+	t.Run("clustered layout", func(t *testing.T) {
+		opts := []HashRingConfigFn{SetVirtualNodes(50), SetReplicationFactor(2)}
+		before := replicaShares(t, newTestRing(t, nodes, opts...), keys)
+		after := replicaShares(t, newTestRing(t, nodes, append(opts, SetReplicaCapacityAware(true))...), keys)
+		spread := func(shares map[string]float64) (lo, hi float64) {
+			lo, hi = 1, 0
+			for _, n := range nodes {
+				lo, hi = min(lo, shares[n.GetIdentifier()]), max(hi, shares[n.GetIdentifier()])
+			}
+			return lo, hi
+		}
+		lo, hi := spread(after)
+		wantLo, wantHi := spread(before)
+		if lo < wantLo || hi > wantHi {
+			t.Errorf("replica shares span [%.3f, %.3f] with the option, want within [%.3f, %.3f] as without it: %v", lo, hi, wantLo, wantHi, after)
+		}
+	})
+}
+
+func TestReplicaCapacityAwareIsStable(t *testing.T) {
+	ring := newWeightedRing(t, skewedWeights, SetVirtualNodes(40), SetReplicationFactor(3), SetReplicaCapacityAware(true))
+	keys := sampleKeys(200)
+
+	first := make(map[string][]string, len(keys))
+	for _, key := range keys {
+		nodes, err := ring.GetNodesForKey(key)
+		if err != nil {
+			t.Fatalf("GetNodesForKey(%q): %v", key, err)
+		}
+		first[key] = ids(nodes)
+	}
+
+	// repeated lookups must not shift placement
+	for range 200 {
+		for _, key := range keys[:10] {
+			nodes, err := ring.GetNodesForKey(key)
+			if err != nil {
+				t.Fatalf("GetNodesForKey(%q): %v", key, err)
+			}
+			if got := ids(nodes); !slices.Equal(got, first[key]) {
+				t.Fatalf("key %q moved from %v to %v after repeated lookups", key, first[key], got)
+			}
+		}
+	}
+
+	primaries, replicas, err := ring.ReplicaDistribution(keys)
+	if err != nil {
+		t.Fatalf("ReplicaDistribution: %v", err)
+	}
+	wantPrimaries, wantReplicas := make(map[string]int), make(map[string]int)
+	for _, key := range keys {
+		set := first[key]
+		wantPrimaries[set[0]]++
+		for _, id := range set[1:] {
+			wantReplicas[id]++
+		}
+
+		repair, err := ring.RepairOrder(key)
+		if err != nil {
+			t.Fatalf("RepairOrder(%q): %v", key, err)
+		}
+		got := ids(repair)
+		if got[0] != set[0] || !slices.Equal(slices.Sorted(slices.Values(got)), slices.Sorted(slices.Values(set))) {
+			t.Fatalf("key %q: RepairOrder %v disagrees with GetNodesForKey %v", key, got, set)
+		}
+
+		read, err := ring.GetReplicaForRead(key, "reader-1")
+		if err != nil {
+			t.Fatalf("GetReplicaForRead(%q): %v", key, err)
+		}
+		if !slices.Contains(set, read.GetIdentifier()) {
+			t.Fatalf("key %q: GetReplicaForRead picked %s outside %v", key, read.GetIdentifier(), set)
+		}
+	}
+	for id, n := range wantPrimaries {
+		if primaries[id] != n {
+			t.Fatalf("ReplicaDistribution gives %s %d primaries, GetNodesForKey %d", id, primaries[id], n)
+		}
+	}
+	for id, n := range wantReplicas {
+		if replicas[id] != n {
+			t.Fatalf("ReplicaDistribution gives %s %d replicas, GetNodesForKey %d", id, replicas[id], n)
+		}
+	}
+}
+
+func TestMaxReplicaShare(t *testing.T) {
+	const limit = 0.25
+	ring := newWeightedRing(t, skewedWeights, SetVirtualNodes(40), SetReplicationFactor(3), SetMaxReplicaShare(limit))
+	plain := newWeightedRing(t, skewedWeights, SetVirtualNodes(40), SetReplicationFactor(3))
+
+	keys := sampleKeys(20000)
+	shares := func(r *HashRing) map[string]float64 {
+		_, replicas, err := r.ReplicaDistribution(keys)
+		if err != nil {
+			t.Fatalf("ReplicaDistribution: %v", err)
+		}
+		total := 0
+		for _, c := range replicas {
+			total += c
+		}
+		out := make(map[string]float64, len(replicas))
+		for id, c := range replicas {
+			out[id] = float64(c) / float64(total)
+		}
+		return out
+	}
+
+	skewed := false
+	for _, share := range shares(plain) {
+		skewed = skewed || share > limit
+	}
+	if !skewed {
+		t.Fatal("no node exceeds the cap without it, so the test proves nothing")
+	}
+
+	// the cap is enforced over the hash space; allow for sampling noise
+	for id, share := range shares(ring) {
+		if share > limit+0.01 {
+			t.Errorf("%s holds %.3f of replicas, want at most %.2f", id, share, limit)
+		}
+	}
+
+	for _, key := range keys[:200] {
+		first, err := ring.GetNodesForKey(key)
+		if err != nil {
+			t.Fatalf("GetNodesForKey(%q): %v", key, err)
+		}
+		for range 5 {
+			again, err := ring.GetNodesForKey(key)
+			if err != nil {
+				t.Fatalf("GetNodesForKey(%q): %v", key, err)
+			}
+			if !slices.Equal(ids(again), ids(first)) {
+				t.Fatalf("key %q moved from %v to %v after repeated lookups", key, ids(first), ids(again))
+			}
+		}
+	}
+}
+
+func TestMaxReplicaShareFollowsMembership(t *testing.T) {
+	ring := newWeightedRing(t, skewedWeights, SetVirtualNodes(40), SetReplicationFactor(3), SetMaxReplicaShare(0.25))
+	keys := sampleKeys(300)
+
+	placed := func(r *HashRing) map[string][]string {
+		out := make(map[string][]string, len(keys))
+		for _, key := range keys {
+			nodes, err := r.GetNodesForKey(key)
+			if err != nil {
+				t.Fatalf("GetNodesForKey(%q): %v", key, err)
+			}
+			out[key] = ids(nodes)
+		}
+		return out
+	}
+	before := placed(ring)
+
+	// a ring built from the same membership places every key the same way,
+	// however many lookups the first one has served
+	fresh := newWeightedRing(t, skewedWeights, SetVirtualNodes(40), SetReplicationFactor(3), SetMaxReplicaShare(0.25))
+	if !maps.EqualFunc(before, placed(fresh), slices.Equal) {
+		t.Fatal("rings with the same membership place keys differently")
+	}
+
+	if err := ring.AddNode(testNode("extra")); err != nil {
+		t.Fatalf("AddNode: %v", err)
+	}
+	if err := ring.RemoveNodeByID("extra"); err != nil {
+		t.Fatalf("RemoveNodeByID: %v", err)
+	}
+	if !maps.EqualFunc(before, placed(ring), slices.Equal) {
+		t.Fatal("placement did not return after adding and removing a node")
+	}
+}
@@ -0,0 +1,34 @@
+package redundanthashring
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+)
+
+// Checksum digests the replication factor and the token table, each token
+// with the identifier of the node owning it, so that rings built
+// independently from the same membership can confirm they place replicas
+// identically by comparing one number. Any add, remove or weight change
+// alters it. It always uses FNV-1a, not the ring's hash function, and
+// depends only on the layout, so it is stable across processes.
+func (ring *HashRing) Checksum() uint64 {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	sum := fnv.New64a()
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(ring.config.ReplicationFactor))
+	sum.Write(buf[:])
+	for _, h := range ring.sortedKeys {
+		node, ok := ring.vNodeMap.Load(h)
+		if !ok {
+			continue
+		}
+		binary.BigEndian.PutUint64(buf[:], h)
+		sum.Write(buf[:])
+		sum.Write([]byte(node.(ICacheNode).GetIdentifier()))
+		// separates identifiers so "ab"+"c" and "a"+"bc" differ
+		sum.Write([]byte{0})
+	}
+	return sum.Sum64()
+}
@@ -0,0 +1,59 @@
+package redundanthashring
+
+import "testing"
+
+func TestChecksum(t *testing.T) {
+	ring := newTestRing(t, testNodes("a", "b", "c"), SetVirtualNodes(10), SetReplicationFactor(2))
+	base := ring.Checksum()
+
+	// the same membership added in another order agrees
+	if other := newTestRing(t, testNodes("c", "a", "b"), SetVirtualNodes(10), SetReplicationFactor(2)); other.Checksum() != base {
+		t.Fatal("rings with the same membership have different checksums")
+	}
+
+	// a fixed layout always digests to the same value, whichever process
+	// computes it
+	pins := map[string]uint64{"a#0": 100, "b#0": 200, "a#1": 300, "b#1": 400}
+	const golden uint64 = 14973436289349278983
+	fixed := newTestRing(t, testNodes("a", "b"), SetVirtualNodes(2), SetReplicationFactor(2), SetHashFunction(newPinnedHash(pins)))
+	if got := fixed.Checksum(); got != golden {
+		t.Errorf("Checksum() = %d, want %d", got, golden)
+	}
+
+	if err := ring.AddNode(testNode("d")); err != nil {
+		t.Fatalf("AddNode: %v", err)
+	}
+	added := ring.Checksum()
+	if added == base {
+		t.Fatal("adding a node left the checksum unchanged")
+	}
+	if err := ring.RemoveNodeByID("d"); err != nil {
+		t.Fatalf("RemoveNodeByID: %v", err)
+	}
+	if ring.Checksum() != base {
+		t.Fatal("restoring the membership did not restore the checksum")
+	}
+	if err := ring.RemoveNodeByID("a"); err != nil {
+		t.Fatalf("RemoveNodeByID: %v", err)
+	}
+	if removed := ring.Checksum(); removed == base || removed == added {
+		t.Fatal("removing a node did not give a new checksum")
+	}
+
+	weighted := newWeightedRing(t, map[string]float64{"a": 1, "b": 2, "c": 1}, SetVirtualNodes(10), SetReplicationFactor(2))
+	if weighted.Checksum() == base {
+		t.Fatal("a weight change left the checksum unchanged")
+	}
+	factor3 := newTestRing(t, testNodes("a", "b", "c"), SetVirtualNodes(10), SetReplicationFactor(3))
+	if factor3.Checksum() == base {
+		t.Fatal("the replication factor is not part of the checksum")
+	}
+}
+
+func TestChecksumSeparatesIdentifiers(t *testing.T) {
+	a := newTestRing(t, testNodes("ab", "c"), SetVirtualNodes(1), SetHashFunction(newPinnedHash(map[string]uint64{"ab#0": 100, "c#0": 200})))
+	b := newTestRing(t, testNodes("a", "bc"), SetVirtualNodes(1), SetHashFunction(newPinnedHash(map[string]uint64{"a#0": 100, "bc#0": 200})))
+	if a.Checksum() == b.Checksum() {
+		t.Fatal("node identifiers run together in the checksum")
+	}
+}
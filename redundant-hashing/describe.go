@@ -0,0 +1,75 @@
+package redundanthashring
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// describeMaxTokens caps how many tokens String prints.
+const describeMaxTokens = 20
+
+// String summarises the ring for debugging: counts, replication factor, hash
+// function, members and the first tokens in ring order. The output is
+// deterministic for a given layout, so it can be diffed.
+func (ring *HashRing) String() string {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	ids := ring.memberIDs()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "HashRing{nodes: %d, tokens: %d, vnodes: %d, replicas: %d, hash: %s}\n",
+		len(ids), len(ring.sortedKeys), ring.config.VirtualNodes, ring.config.ReplicationFactor, ring.config.hashName())
+	fmt.Fprintf(&b, "  members: %s\n", strings.Join(ids, ", "))
+	for i, h := range ring.sortedKeys {
+		if i == describeMaxTokens {
+			fmt.Fprintf(&b, "  … and %d more tokens\n", len(ring.sortedKeys)-i)
+			break
+		}
+		id := "?"
+		if node, ok := ring.vNodeMap.Load(h); ok {
+			id = node.(ICacheNode).GetIdentifier()
+		}
+		fmt.Fprintf(&b, "  %20d %s\n", h, id)
+	}
+	return b.String()
+}
+
+// VirtualNodes returns the number of virtual nodes a node of weight 1 gets.
+func (ring *HashRing) VirtualNodes() int {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+	return ring.config.VirtualNodes
+}
+
+// ReplicationFactor returns how many nodes GetNodesForKey returns per key.
+func (ring *HashRing) ReplicationFactor() int {
+	return ring.config.ReplicationFactor
+}
+
+// HashFunctionName returns the name given with SetHashFunctionNamed, or the
+// hash function's qualified symbol name otherwise.
+func (ring *HashRing) HashFunctionName() string {
+	return ring.config.hashName()
+}
+
+func (cfg *hashRingConfig) hashName() string {
+	if cfg.HashName != "" {
+		return cfg.HashName
+	}
+	return funcName(cfg.HashFunction)
+}
+
+// funcName returns the qualified name of a function value, or "<nil>".
+func funcName(f any) string {
+	v := reflect.ValueOf(f)
+	if !v.IsValid() || v.IsNil() {
+		return "<nil>"
+	}
+	if fn := runtime.FuncForPC(v.Pointer()); fn != nil {
+		return fn.Name()
+	}
+	return "<unknown>"
+}
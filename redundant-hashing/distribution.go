@@ -0,0 +1,42 @@
+package redundanthashring
+
+// ReplicaDistribution resolves the replicas of every key and counts, per node
+// identifier, how many keys it is primary for and how many it holds as a
+// secondary. The read lock is taken per key, so a large sample doesn't hold
+// up writers. Keys whose placement falls short of the replication factor
+// count what was placed.
+func (ring *HashRing) ReplicaDistribution(keys []string) (primaries, replicas map[string]int, err error) {
+	primaries = make(map[string]int)
+	replicas = make(map[string]int)
+	for _, key := range keys {
+		nodes, err := ring.placement(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		primaries[nodes[0].GetIdentifier()]++
+		for _, n := range nodes[1:] {
+			replicas[n.GetIdentifier()]++
+		}
+	}
+	return primaries, replicas, nil
+}
+
+// placement is nodesForKey for the ring's replication factor without
+// reporting shortfalls.
+func (ring *HashRing) placement(key string) ([]ICacheNode, error) {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	if len(ring.sortedKeys) == 0 {
+		return nil, ErrNoNodesAvailable
+	}
+	h, err := ring.hashKey(key)
+	if err != nil {
+		return nil, err
+	}
+	nodes := ring.replicasFor(h, max(ring.config.ReplicationFactor, 1), nil)
+	if len(nodes) == 0 {
+		return nil, ErrNoNodesAvailable
+	}
+	return nodes, nil
+}
@@ -0,0 +1,76 @@
+package redundanthashring
+
+import (
+	"fmt"
+	"log"
+)
+
+// DrainNode stops routing keys to id while keeping it on the ring, e.g.
+// ahead of decommissioning a host. A drained node stays in Members but is
+// never returned as a primary or replica; the next nodes clockwise take its
+// place. Draining the last undrained node fails with ErrLastActiveNode.
+func (ring *HashRing) DrainNode(id string) error {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	if _, ok := ring.hostSet.Load(id); !ok {
+		return fmt.Errorf("%w: %s", ErrNodeNotFound, id)
+	}
+	if _, already := ring.drained[id]; already {
+		return nil
+	}
+	if len(ring.positions)-len(ring.drained) <= 1 {
+		return fmt.Errorf("%w: %s", ErrLastActiveNode, id)
+	}
+
+	if ring.drained == nil {
+		ring.drained = make(map[string]struct{})
+	}
+	ring.drained[id] = struct{}{}
+	ring.refreshReplicaDuty()
+
+	if ring.config.EnableLogs {
+		log.Printf("🚰 Node drained %s", id)
+	}
+	return nil
+}
+
+// UndrainNode resumes routing keys to a drained node.
+func (ring *HashRing) UndrainNode(id string) error {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	if _, ok := ring.hostSet.Load(id); !ok {
+		return fmt.Errorf("%w: %s", ErrNodeNotFound, id)
+	}
+	delete(ring.drained, id)
+	ring.refreshReplicaDuty()
+	return nil
+}
+
+// routable narrows keep to nodes that aren't drained. Callers must hold
+// ring.mu.
+func (ring *HashRing) routable(keep func(ICacheNode) bool) func(ICacheNode) bool {
+	if len(ring.drained) == 0 {
+		return keep
+	}
+	return func(n ICacheNode) bool {
+		if _, drained := ring.drained[n.GetIdentifier()]; drained {
+			return false
+		}
+		return keep == nil || keep(n)
+	}
+}
+
+// primaryFor returns the first undrained node clockwise from h. Callers
+// must hold ring.mu.
+func (ring *HashRing) primaryFor(h uint64) (ICacheNode, error) {
+	if len(ring.sortedKeys) == 0 {
+		return nil, ErrNoNodesAvailable
+	}
+	nodes := ring.collectNodes(ring.search(h), nil, 1, ring.routable(nil))
+	if len(nodes) == 0 {
+		return nil, ErrNoNodesAvailable
+	}
+	return nodes[0], nil
+}
@@ -0,0 +1,125 @@
+package redundanthashring
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestDrainNode(t *testing.T) {
+	ring := newTestRing(t, testNodes("a", "b", "c", "d"), SetVirtualNodes(20), SetReplicationFactor(2))
+	keys := sampleKeys(2000)
+
+	held := 0
+	for _, key := range keys {
+		nodes, err := ring.GetNodesForKey(key)
+		if err != nil {
+			t.Fatalf("GetNodesForKey(%q): %v", key, err)
+		}
+		if slices.Contains(ids(nodes), "b") {
+			held++
+		}
+	}
+	if held == 0 {
+		t.Fatal("b holds no keys, so the test proves nothing")
+	}
+
+	if err := ring.DrainNode("b"); err != nil {
+		t.Fatalf("DrainNode: %v", err)
+	}
+	if !slices.Contains(ids(ring.Members()), "b") {
+		t.Fatal("a drained node left Members")
+	}
+	for _, key := range keys {
+		primary, err := ring.GetPrimaryNode(key)
+		if err != nil {
+			t.Fatalf("GetPrimaryNode(%q): %v", key, err)
+		}
+		if primary.GetIdentifier() == "b" {
+			t.Fatalf("key %q has drained node b as primary", key)
+		}
+		nodes, err := ring.GetNodesForKey(key)
+		if err != nil {
+			t.Fatalf("GetNodesForKey(%q): %v", key, err)
+		}
+		// another node backfills b's slot
+		if got := ids(nodes); len(got) != 2 || slices.Contains(got, "b") {
+			t.Fatalf("key %q placed on %v with b drained", key, got)
+		}
+		if nodes[0].GetIdentifier() != primary.GetIdentifier() {
+			t.Fatalf("key %q: GetPrimaryNode %s disagrees with GetNodesForKey %v", key, primary.GetIdentifier(), ids(nodes))
+		}
+	}
+
+	// draining twice is a no-op
+	if err := ring.DrainNode("b"); err != nil {
+		t.Fatalf("second DrainNode: %v", err)
+	}
+
+	if err := ring.UndrainNode("b"); err != nil {
+		t.Fatalf("UndrainNode: %v", err)
+	}
+	fresh := newTestRing(t, testNodes("a", "b", "c", "d"), SetVirtualNodes(20), SetReplicationFactor(2))
+	for _, key := range keys {
+		got, err := ring.GetNodesForKey(key)
+		if err != nil {
+			t.Fatalf("GetNodesForKey(%q): %v", key, err)
+		}
+		want, err := fresh.GetNodesForKey(key)
+		if err != nil {
+			t.Fatalf("GetNodesForKey(%q): %v", key, err)
+		}
+		if !slices.Equal(ids(got), ids(want)) {
+			t.Fatalf("key %q on %v after UndrainNode, want %v", key, ids(got), ids(want))
+		}
+	}
+}
+
+func TestDrainNodeLastActive(t *testing.T) {
+	ring := newTestRing(t, testNodes("a", "b"))
+	if err := ring.DrainNode("a"); err != nil {
+		t.Fatalf("DrainNode(a): %v", err)
+	}
+	if err := ring.DrainNode("b"); !errors.Is(err, ErrLastActiveNode) {
+		t.Fatalf("DrainNode(b) = %v, want ErrLastActiveNode", err)
+	}
+	primary, err := ring.GetPrimaryNode("key")
+	if err != nil {
+		t.Fatalf("GetPrimaryNode: %v", err)
+	}
+	if primary.GetIdentifier() != "b" {
+		t.Fatalf("GetPrimaryNode returned %s, want the only undrained node b", primary.GetIdentifier())
+	}
+
+	single := newTestRing(t, testNodes("only"))
+	if err := single.DrainNode("only"); !errors.Is(err, ErrLastActiveNode) {
+		t.Fatalf("DrainNode(only) = %v, want ErrLastActiveNode", err)
+	}
+}
+
+func TestDrainNodeUnknown(t *testing.T) {
+	ring := newTestRing(t, testNodes("a", "b"))
+	if err := ring.DrainNode("ghost"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("DrainNode(ghost) = %v, want ErrNodeNotFound", err)
+	}
+	if err := ring.UndrainNode("ghost"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("UndrainNode(ghost) = %v, want ErrNodeNotFound", err)
+	}
+}
+
+func TestRemovedNodeForgetsDrain(t *testing.T) {
+	ring := newTestRing(t, testNodes("a", "b"))
+	if err := ring.DrainNode("b"); err != nil {
+		t.Fatalf("DrainNode: %v", err)
+	}
+	if err := ring.RemoveNodeByID("b"); err != nil {
+		t.Fatalf("RemoveNodeByID: %v", err)
+	}
+	if err := ring.AddNode(testNode("b")); err != nil {
+		t.Fatalf("AddNode: %v", err)
+	}
+	// with b routable again, a can be drained
+	if err := ring.DrainNode("a"); err != nil {
+		t.Fatalf("DrainNode(a) after re-adding b: %v", err)
+	}
+}
@@ -12,21 +12,50 @@ import (
 )
 
 var (
-	ErrNoNodesAvailable = errors.New("no connected nodes available")
-	ErrNodeExists       = errors.New("node already exists")
-	ErrNodeNotFound     = errors.New("node not found")
-	ErrHashingKey       = errors.New("failed to hash key")
+	ErrNoNodesAvailable  = errors.New("no connected nodes available")
+	ErrNodeExists        = errors.New("node already exists")
+	ErrNodeNotFound      = errors.New("node not found")
+	ErrHashingKey        = errors.New("failed to hash key")
+	ErrInvalidFactor     = errors.New("replication factor must be at least 1")
+	ErrInsufficientNodes = errors.New("fewer nodes than the replication factor")
+	ErrInvalidConfig     = errors.New("invalid ring configuration")
+	ErrInvalidWeight     = errors.New("weight must be positive")
+	ErrLastActiveNode    = errors.New("cannot drain the last undrained node")
+	ErrInsufficientZones = errors.New("replicas span fewer zones than required")
+	ErrTokenCollision    = errors.New("virtual nodes hash to the same token")
 )
 
 type ICacheNode interface {
 	GetIdentifier() string
 }
 
+// IZoneAware can be implemented by nodes that know their failure zone.
+// Nodes that don't implement it are treated as belonging to zone "".
+type IZoneAware interface {
+	GetZone() string
+}
+
+func zoneOf(node ICacheNode) string {
+	if z, ok := node.(IZoneAware); ok {
+		return z.GetZone()
+	}
+	return ""
+}
+
 type hashRingConfig struct {
-	VirtualNodes      int
-	ReplicationFactor int
-	HashFunction      func() hash.Hash64
-	EnableLogs        bool
+	VirtualNodes         int
+	ReplicationFactor    int
+	HashFunction         func() hash.Hash64
+	HashName             string
+	EnableLogs           bool
+	PrimaryZonePeer      bool
+	ReplicaCapacityAware bool
+	MaxReplicaShare      float64
+	MinZones             int
+	HashTags             bool
+	KeyNormalizer        func(string) string
+	Namespace            string
+	SeedNodes            []ICacheNode
 }
 
 type HashRingConfigFn func(*hashRingConfig)
@@ -46,6 +75,41 @@ func SetReplicationFactor(replication int) HashRingConfigFn {
 func SetHashFunction(f func() hash.Hash64) HashRingConfigFn {
 	return func(cfg *hashRingConfig) {
 		cfg.HashFunction = f
+		cfg.HashName = ""
+	}
+}
+
+// SetHashFunctionNamed is SetHashFunction with a display name reported by
+// HashFunctionName and String in place of the function's symbol.
+func SetHashFunctionNamed(name string, f func() hash.Hash64) HashRingConfigFn {
+	return func(cfg *hashRingConfig) {
+		cfg.HashFunction = f
+		cfg.HashName = name
+	}
+}
+
+// SetPrimaryZonePeer makes GetNodesForKey place the first replica in the
+// primary's zone when such a node exists, for fast local recovery.
+func SetPrimaryZonePeer(enabled bool) HashRingConfigFn {
+	return func(cfg *hashRingConfig) {
+		cfg.PrimaryZonePeer = enabled
+	}
+}
+
+// WithNodes adds nodes to the ring as it is constructed. InitHashRingE fails
+// if any of them can't be added; InitHashRing skips those.
+func WithNodes(nodes ...ICacheNode) HashRingConfigFn {
+	return func(cfg *hashRingConfig) {
+		cfg.SeedNodes = append(cfg.SeedNodes, nodes...)
+	}
+}
+
+// SetNamespace folds name into every virtual node's hash input, giving rings
+// that share node identifiers independent token layouts. It is fixed for the
+// ring's lifetime, since changing it would move every token.
+func SetNamespace(name string) HashRingConfigFn {
+	return func(cfg *hashRingConfig) {
+		cfg.Namespace = name
 	}
 }
 
@@ -59,11 +123,36 @@ type HashRing struct {
 	mu         sync.RWMutex
 	config     hashRingConfig
 	vNodeMap   sync.Map // hash → node
-	hostSet    sync.Map // nodeID → bool
+	hostSet    sync.Map // nodeID → node
 	sortedKeys []uint64
+	positions  map[string]int                 // nodeID → number of ring positions
+	weights    map[string]float64             // nodeID → weight, absent for weight 1
+	drained    map[string]struct{}            // nodes kept on the ring but not routed to
+	overCap    map[uint64]map[string]struct{} // arc → nodes barred from its replica duty by MaxReplicaShare
+	overShare  map[uint64]map[string]struct{} // arc → nodes past their weighted replica share on it
 }
 
 func InitHashRing(opts ...HashRingConfigFn) *HashRing {
+	ring := newHashRing(opts...)
+	ring.seed()
+	return ring
+}
+
+// InitHashRingE is InitHashRing but rejects nonsensical options instead of
+// building a ring that fails later. Every problem found is reported. A seed
+// node from WithNodes that can't be added fails construction as well.
+func InitHashRingE(opts ...HashRingConfigFn) (*HashRing, error) {
+	ring := newHashRing(opts...)
+	if err := ring.config.validate(); err != nil {
+		return nil, err
+	}
+	if err := ring.seed(); err != nil {
+		return nil, err
+	}
+	return ring, nil
+}
+
+func newHashRing(opts ...HashRingConfigFn) *HashRing {
 	cfg := &hashRingConfig{
 		VirtualNodes:      3,
 		ReplicationFactor: 2,
@@ -75,45 +164,124 @@ func InitHashRing(opts ...HashRingConfigFn) *HashRing {
 	return &HashRing{
 		config:     *cfg,
 		sortedKeys: make([]uint64, 0),
+		positions:  make(map[string]int),
+		weights:    make(map[string]float64),
 	}
 }
 
+// seed adds the nodes given with WithNodes.
+func (ring *HashRing) seed() error {
+	nodes := ring.config.SeedNodes
+	ring.config.SeedNodes = nil
+
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	var errs []error
+	for _, node := range nodes {
+		if err := ring.addNode(node, 1); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", node.GetIdentifier(), err))
+		}
+	}
+	ring.refreshReplicaDuty()
+	return errors.Join(errs...)
+}
+
+func (cfg *hashRingConfig) validate() error {
+	var errs []error
+	if cfg.VirtualNodes < 1 {
+		errs = append(errs, fmt.Errorf("%w: virtual nodes must be at least 1, got %d", ErrInvalidConfig, cfg.VirtualNodes))
+	}
+	if cfg.ReplicationFactor < 1 {
+		errs = append(errs, fmt.Errorf("%w: %w, got %d", ErrInvalidConfig, ErrInvalidFactor, cfg.ReplicationFactor))
+	}
+	if cfg.HashFunction == nil {
+		errs = append(errs, fmt.Errorf("%w: hash function must not be nil", ErrInvalidConfig))
+	}
+	if cfg.MinZones < 0 || cfg.MinZones > max(cfg.ReplicationFactor, 1) {
+		errs = append(errs, fmt.Errorf("%w: required zones must be within [0, replication factor], got %d", ErrInvalidConfig, cfg.MinZones))
+	}
+	if cfg.MaxReplicaShare < 0 || cfg.MaxReplicaShare > 1 {
+		errs = append(errs, fmt.Errorf("%w: max replica share must be within [0, 1], got %g", ErrInvalidConfig, cfg.MaxReplicaShare))
+	}
+	return errors.Join(errs...)
+}
+
 func (ring *HashRing) AddNode(node ICacheNode) error {
 	ring.mu.Lock()
 	defer ring.mu.Unlock()
+	if err := ring.addNode(node, 1); err != nil {
+		return err
+	}
+	ring.refreshReplicaDuty()
+	return nil
+}
 
+// addNode places node's virtual nodes, VirtualNodes scaled by weight.
+// Callers must hold ring.mu and refresh replica duty once their batch of
+// changes is done.
+func (ring *HashRing) addNode(node ICacheNode, weight float64) error {
 	id := node.GetIdentifier()
 	if _, exists := ring.hostSet.Load(id); exists {
 		return ErrNodeExists
 	}
 
-	for i := 0; i < ring.config.VirtualNodes; i++ {
-		vID := fmt.Sprintf("%s#%d", id, i)
+	// hash every virtual node before storing anything so a failure part way
+	// through leaves the ring untouched
+	count := ring.vnodesFor(weight)
+	hashes := make([]uint64, 0, count)
+	for i := 0; i < count; i++ {
+		vID := ring.namespaced(fmt.Sprintf("%s#%d", id, i))
 		h, err := ring.generateHash(vID)
 		if err != nil {
 			return fmt.Errorf("%w: %s", ErrHashingKey, vID)
 		}
-		ring.vNodeMap.Store(h, node)
-		ring.sortedKeys = append(ring.sortedKeys, h)
+		hashes = append(hashes, h)
 
 		if ring.config.EnableLogs {
 			log.Printf("🧩 Virtual node added %s → %d", vID, h)
 		}
 	}
-	ring.hostSet.Store(id, true)
+
+	for _, h := range hashes {
+		ring.vNodeMap.Store(h, node)
+	}
+	ring.sortedKeys = append(ring.sortedKeys, hashes...)
+	ring.positions[id] = count
+	if weight != 1 {
+		ring.weights[id] = weight
+	}
+	ring.hostSet.Store(id, node)
 	slices.Sort(ring.sortedKeys)
 	return nil
 }
 
 func (ring *HashRing) RemoveNode(node ICacheNode) error {
+	return ring.RemoveNodeByID(node.GetIdentifier())
+}
+
+// RemoveNodeByID removes the node registered under id, for callers that no
+// longer hold the node itself.
+func (ring *HashRing) RemoveNodeByID(id string) error {
 	ring.mu.Lock()
 	defer ring.mu.Unlock()
+	if err := ring.removeNode(id); err != nil {
+		return err
+	}
+	ring.refreshReplicaDuty()
+	return nil
+}
 
-	id := node.GetIdentifier()
+// removeNode drops id and all its virtual nodes. Callers must hold ring.mu
+// and refresh replica duty once their batch of changes is done.
+func (ring *HashRing) removeNode(id string) error {
 	if _, ok := ring.hostSet.Load(id); !ok {
 		return ErrNodeNotFound
 	}
 	ring.hostSet.Delete(id)
+	delete(ring.positions, id)
+	delete(ring.weights, id)
+	delete(ring.drained, id)
 
 	// remove all virtual nodes
 	newKeys := make([]uint64, 0, len(ring.sortedKeys))
@@ -134,18 +302,90 @@ func (ring *HashRing) GetPrimaryNode(key string) (ICacheNode, error) {
 	ring.mu.RLock()
 	defer ring.mu.RUnlock()
 
-	h, err := ring.generateHash(key)
+	h, err := ring.hashKey(key)
 	if err != nil {
 		return nil, err
 	}
 
-	idx := ring.search(h)
-	node, _ := ring.vNodeMap.Load(ring.sortedKeys[idx])
-	return node.(ICacheNode), nil
+	return ring.primaryFor(h)
 }
 
-// ✅ GetNodesForKey returns N unique physical nodes for redundancy
+// ✅ GetNodesForKey returns N unique physical nodes for redundancy, where N
+// is the ring's replication factor, primary first. See GetReplicaSet and
+// GetNodesForKeyN.
 func (ring *HashRing) GetNodesForKey(key string) ([]ICacheNode, error) {
+	set, err := ring.GetReplicaSet(key)
+	return set.All(), err
+}
+
+// GetNodesForKeyN is GetNodesForKey with the replication factor n used for
+// this lookup only, e.g. to replicate hot keys more widely. If fewer than n
+// nodes can be found, the ones that were are returned together with
+// ErrInsufficientNodes so callers can decide whether degraded placement is
+// acceptable.
+func (ring *HashRing) GetNodesForKeyN(key string, n int) ([]ICacheNode, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidFactor, n)
+	}
+	return ring.nodesForKey(key, n, nil)
+}
+
+// GetNodesForKeyWithFactor is GetNodesForKey with factor replicas for this
+// lookup only, for data classes needing more or less durability than the
+// configured ReplicationFactor. Unlike GetNodesForKeyN the factor is capped
+// at the number of distinct routable nodes, so asking for more than the ring
+// holds returns every node without ErrInsufficientNodes.
+func (ring *HashRing) GetNodesForKeyWithFactor(key string, factor int) ([]ICacheNode, error) {
+	if factor < 1 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidFactor, factor)
+	}
+
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	if len(ring.sortedKeys) == 0 {
+		return nil, ErrNoNodesAvailable
+	}
+	h, err := ring.hashKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return ring.nodesForHash(h, min(factor, len(ring.positions)-len(ring.drained)), nil)
+}
+
+// GetNodesForKeyExcluding is GetNodesForKey but skips the nodes named in
+// exclude, e.g. replicas already known to be down, so the next nodes
+// clockwise take their place. Excluding the primary promotes the first
+// replica.
+func (ring *HashRing) GetNodesForKeyExcluding(key string, exclude []string) ([]ICacheNode, error) {
+	skip := make(map[string]struct{}, len(exclude))
+	for _, id := range exclude {
+		skip[id] = struct{}{}
+	}
+	return ring.nodesForKey(key, ring.config.ReplicationFactor, func(n ICacheNode) bool {
+		_, excluded := skip[n.GetIdentifier()]
+		return !excluded
+	})
+}
+
+func (ring *HashRing) nodesForKey(key string, want int, keep func(ICacheNode) bool) ([]ICacheNode, error) {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	if len(ring.sortedKeys) == 0 {
+		return nil, ErrNoNodesAvailable
+	}
+
+	h, err := ring.hashKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return ring.nodesForHash(h, want, keep)
+}
+
+// GetPrimaryNodeBytes is GetPrimaryNode for keys held as bytes, hashing them
+// without converting to a string first.
+func (ring *HashRing) GetPrimaryNodeBytes(key []byte) (ICacheNode, error) {
 	ring.mu.RLock()
 	defer ring.mu.RUnlock()
 
@@ -153,40 +393,155 @@ func (ring *HashRing) GetNodesForKey(key string) ([]ICacheNode, error) {
 		return nil, ErrNoNodesAvailable
 	}
 
-	h, err := ring.generateHash(key)
+	h, err := ring.hashKeyBytes(key)
 	if err != nil {
 		return nil, err
 	}
 
-	seen := make(map[string]struct{})
-	nodes := make([]ICacheNode, 0, ring.config.ReplicationFactor)
+	return ring.primaryFor(h)
+}
+
+// GetNodesForKeyBytes is GetNodesForKey for keys held as bytes, hashing them
+// without converting to a string first.
+func (ring *HashRing) GetNodesForKeyBytes(key []byte) ([]ICacheNode, error) {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	if ring.config.ReplicationFactor < 1 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidFactor, ring.config.ReplicationFactor)
+	}
+	if len(ring.sortedKeys) == 0 {
+		return nil, ErrNoNodesAvailable
+	}
+
+	h, err := ring.hashKeyBytes(key)
+	if err != nil {
+		return nil, err
+	}
+	return ring.nodesForHash(h, ring.config.ReplicationFactor, nil)
+}
 
+// GetNodesForHash is GetNodesForKey for callers that already hashed the key.
+// h is used as the ring position as is, so GetNodesForHash(hash(k)) always
+// agrees with GetNodesForKey(k).
+func (ring *HashRing) GetNodesForHash(h uint64) ([]ICacheNode, error) {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	if ring.config.ReplicationFactor < 1 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidFactor, ring.config.ReplicationFactor)
+	}
+	if len(ring.sortedKeys) == 0 {
+		return nil, ErrNoNodesAvailable
+	}
+	return ring.nodesForHash(h, ring.config.ReplicationFactor, nil)
+}
+
+// nodesForHash resolves the replicas for a key hash. Callers must hold
+// ring.mu and ensure the ring is not empty.
+func (ring *HashRing) nodesForHash(h uint64, want int, keep func(ICacheNode) bool) ([]ICacheNode, error) {
+	nodes := ring.replicasFor(h, want, keep)
+	if len(nodes) == 0 {
+		return nil, ErrNoNodesAvailable
+	}
+	if len(nodes) < want {
+		return nodes, fmt.Errorf("%w: found %d of %d", ErrInsufficientNodes, len(nodes), want)
+	}
+	if zones := distinctZones(nodes); zones < ring.config.MinZones {
+		return nodes, fmt.Errorf("%w: replicas span %d of %d", ErrInsufficientZones, zones, ring.config.MinZones)
+	}
+	return nodes, nil
+}
+
+// replicasFor builds the list of want replicas for a key hash, primary
+// first, considering only undrained nodes accepted by keep (nil accepts
+// all). Callers must hold ring.mu and ensure the ring is not empty.
+func (ring *HashRing) replicasFor(h uint64, want int, keep func(ICacheNode) bool) []ICacheNode {
+	keep = ring.routable(keep)
 	start := ring.search(h)
-	i := start
+	nodes := ring.collectNodes(start, make([]ICacheNode, 0, want), 1, keep)
+
+	// replica duty may be capped, the primary never is
+	eligible := keep
+	if ring.config.MaxReplicaShare > 0 {
+		eligible = func(n ICacheNode) bool {
+			return (keep == nil || keep(n)) && ring.underReplicaCap(start, n)
+		}
+	}
+	accepts := func(n ICacheNode) bool {
+		return eligible == nil || eligible(n)
+	}
 
-	for len(nodes) < ring.config.ReplicationFactor {
-		vHash := ring.sortedKeys[i%len(ring.sortedKeys)]
-		node, ok := ring.vNodeMap.Load(vHash)
+	if ring.config.PrimaryZonePeer && len(nodes) == 1 && want > 1 {
+		zone := zoneOf(nodes[0])
+		nodes = ring.collectNodes(start, nodes, 2, func(n ICacheNode) bool {
+			return zoneOf(n) == zone && accepts(n)
+		})
+	}
+	if ring.config.MinZones > 1 {
+		nodes = ring.spreadZones(start, nodes, want, accepts)
+	}
+	if ring.config.ReplicaCapacityAware {
+		nodes = ring.collectNodes(start, nodes, want, func(n ICacheNode) bool {
+			return ring.hasReplicaHeadroom(start, n) && accepts(n)
+		})
+	}
+	return ring.collectNodes(start, nodes, want, eligible)
+}
+
+// GetReplicaForRead picks one of key's replicas for readerID. The choice is
+// deterministic per (key, reader) pair, so a reader keeps hitting the same
+// replica while different readers spread across the replica set.
+func (ring *HashRing) GetReplicaForRead(key string, readerID string) (ICacheNode, error) {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	if len(ring.sortedKeys) == 0 {
+		return nil, ErrNoNodesAvailable
+	}
+
+	h, err := ring.hashKey(key)
+	if err != nil {
+		return nil, err
+	}
+	nodes := ring.replicasFor(h, ring.config.ReplicationFactor, nil)
+	if len(nodes) == 0 {
+		return nil, ErrNoNodesAvailable
+	}
+
+	pick, err := ring.generateHash(key + "\x00" + readerID)
+	if err != nil {
+		return nil, err
+	}
+	return nodes[pick%uint64(len(nodes))], nil
+}
+
+// collectNodes walks clockwise from start, appending distinct physical nodes
+// accepted by keep (nil accepts all) to selected until it holds want nodes or
+// the whole ring has been visited. Callers must hold ring.mu.
+func (ring *HashRing) collectNodes(start int, selected []ICacheNode, want int, keep func(ICacheNode) bool) []ICacheNode {
+	seen := make(map[string]struct{}, len(selected))
+	for _, n := range selected {
+		seen[n.GetIdentifier()] = struct{}{}
+	}
+
+	for i := 0; i < len(ring.sortedKeys) && len(selected) < want; i++ {
+		node, ok := ring.vNodeMap.Load(ring.sortedKeys[(start+i)%len(ring.sortedKeys)])
 		if !ok {
-			i++
 			continue
 		}
 		n := node.(ICacheNode)
 		id := n.GetIdentifier()
-		if _, already := seen[id]; !already {
-			seen[id] = struct{}{}
-			nodes = append(nodes, n)
+		if _, already := seen[id]; already {
+			continue
 		}
-		i++
-		if i-start > len(ring.sortedKeys) {
-			break // avoid infinite loop if not enough unique nodes
+		if keep != nil && !keep(n) {
+			continue
 		}
+		seen[id] = struct{}{}
+		selected = append(selected, n)
 	}
-
-	if len(nodes) == 0 {
-		return nil, ErrNoNodesAvailable
-	}
-	return nodes, nil
+	return selected
 }
 
 // 🧠 search returns index of first key ≥ hash or wraps around
@@ -200,6 +555,22 @@ func (ring *HashRing) search(h uint64) int {
 	return idx
 }
 
+// namespaced prefixes a virtual node's hash input with the ring's namespace.
+func (ring *HashRing) namespaced(vID string) string {
+	if ring.config.Namespace == "" {
+		return vID
+	}
+	return ring.config.Namespace + "/" + vID
+}
+
+func (ring *HashRing) generateHashBytes(key []byte) (uint64, error) {
+	h := ring.config.HashFunction()
+	if _, err := h.Write(key); err != nil {
+		return 0, err
+	}
+	return h.Sum64(), nil
+}
+
 func (ring *HashRing) generateHash(key string) (uint64, error) {
 	h := ring.config.HashFunction()
 	if _, err := h.Write([]byte(key)); err != nil {
@@ -0,0 +1,544 @@
+package redundanthashring
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"slices"
+	"testing"
+)
+
+type testNode string
+
+func (n testNode) GetIdentifier() string { return string(n) }
+
+// zoneNode is a node that reports its failure zone.
+type zoneNode struct{ id, zone string }
+
+func (n zoneNode) GetIdentifier() string { return n.id }
+func (n zoneNode) GetZone() string       { return n.zone }
+
+// newTestRing builds a ring holding nodes, failing the test if any of them
+// can't be added.
+func newTestRing(t testing.TB, nodes []ICacheNode, opts ...HashRingConfigFn) *HashRing {
+	t.Helper()
+	ring, err := InitHashRingE(append(opts, WithNodes(nodes...))...)
+	if err != nil {
+		t.Fatalf("InitHashRingE: %v", err)
+	}
+	return ring
+}
+
+// testNodes turns identifiers into nodes.
+func testNodes(ids ...string) []ICacheNode {
+	nodes := make([]ICacheNode, len(ids))
+	for i, id := range ids {
+		nodes[i] = testNode(id)
+	}
+	return nodes
+}
+
+// sampleKeys returns n distinct lookup keys.
+func sampleKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("user:%d:session", i*7919)
+	}
+	return keys
+}
+
+// ids lists the identifiers of nodes in order.
+func ids(nodes []ICacheNode) []string {
+	out := make([]string, len(nodes))
+	for i, n := range nodes {
+		out[i] = n.GetIdentifier()
+	}
+	return out
+}
+
+func TestPrimaryZonePeer(t *testing.T) {
+	nodes := []ICacheNode{
+		zoneNode{"a1", "a"}, zoneNode{"a2", "a"},
+		zoneNode{"b1", "b"}, zoneNode{"b2", "b"}, zoneNode{"b3", "b"},
+		zoneNode{"c1", "c"},
+	}
+	ring := newTestRing(t, nodes, SetVirtualNodes(20), SetReplicationFactor(3), SetPrimaryZonePeer(true))
+	plain := newTestRing(t, nodes, SetVirtualNodes(20), SetReplicationFactor(3))
+
+	crossZone := 0
+	for _, key := range sampleKeys(500) {
+		replicas, err := ring.GetNodesForKey(key)
+		if err != nil {
+			t.Fatalf("GetNodesForKey(%q): %v", key, err)
+		}
+		primary := replicas[0].(zoneNode)
+		if primary.zone == "c" {
+			// no peer exists, so the walk fills the set as usual
+			if len(replicas) != 3 {
+				t.Fatalf("key %q: got %v, want 3 replicas", key, ids(replicas))
+			}
+			continue
+		}
+		if peer := replicas[1].(zoneNode); peer.zone != primary.zone {
+			t.Fatalf("key %q: replicas %v have no peer in the primary's zone %s", key, ids(replicas), primary.zone)
+		}
+
+		without, err := plain.GetNodesForKey(key)
+		if err != nil {
+			t.Fatalf("GetNodesForKey(%q): %v", key, err)
+		}
+		if without[0].GetIdentifier() != primary.id {
+			t.Fatalf("key %q: the option changed the primary", key)
+		}
+		if without[1].(zoneNode).zone != primary.zone {
+			crossZone++
+		}
+	}
+	if crossZone == 0 {
+		t.Fatal("the plain walk always picked a zone peer, so the test proves nothing")
+	}
+}
+
+func TestGetReplicaForRead(t *testing.T) {
+	ring := newTestRing(t, testNodes("a", "b", "c", "d", "e"), SetVirtualNodes(20), SetReplicationFactor(3))
+
+	for _, key := range sampleKeys(50) {
+		replicas, err := ring.GetNodesForKey(key)
+		if err != nil {
+			t.Fatalf("GetNodesForKey(%q): %v", key, err)
+		}
+		set := ids(replicas)
+
+		picked := make(map[string]int)
+		for r := range 60 {
+			reader := fmt.Sprintf("reader-%d", r)
+			first, err := ring.GetReplicaForRead(key, reader)
+			if err != nil {
+				t.Fatalf("GetReplicaForRead(%q, %q): %v", key, reader, err)
+			}
+			if !slices.Contains(set, first.GetIdentifier()) {
+				t.Fatalf("key %q: picked %s outside its replicas %v", key, first.GetIdentifier(), set)
+			}
+			again, err := ring.GetReplicaForRead(key, reader)
+			if err != nil {
+				t.Fatalf("GetReplicaForRead(%q, %q): %v", key, reader, err)
+			}
+			if again.GetIdentifier() != first.GetIdentifier() {
+				t.Fatalf("key %q, %s: picked %s then %s", key, reader, first.GetIdentifier(), again.GetIdentifier())
+			}
+			picked[first.GetIdentifier()]++
+		}
+		if len(picked) != len(set) {
+			t.Fatalf("key %q: 60 readers only hit %v of %v", key, picked, set)
+		}
+	}
+}
+
+func TestGetNodesForKeyN(t *testing.T) {
+	ring := newTestRing(t, testNodes("a", "b", "c", "d", "e", "f"), SetVirtualNodes(20), SetReplicationFactor(3))
+
+	for _, key := range sampleKeys(100) {
+		full, err := ring.GetNodesForKeyN(key, 6)
+		if err != nil {
+			t.Fatalf("GetNodesForKeyN(%q, 6): %v", key, err)
+		}
+		for _, n := range []int{1, 3, 5} {
+			got, err := ring.GetNodesForKeyN(key, n)
+			if err != nil {
+				t.Fatalf("GetNodesForKeyN(%q, %d): %v", key, n, err)
+			}
+			if !slices.Equal(ids(got), ids(full[:n])) {
+				t.Fatalf("key %q: factor %d gave %v, want the prefix %v", key, n, ids(got), ids(full[:n]))
+			}
+		}
+	}
+
+	// asking for more than the ring holds returns every node
+	got, err := ring.GetNodesForKeyN("k", 9)
+	if !errors.Is(err, ErrInsufficientNodes) || len(got) != 6 {
+		t.Fatalf("factor 9: got %d nodes, %v; want 6 with ErrInsufficientNodes", len(got), err)
+	}
+	if _, err := ring.GetNodesForKeyN("k", 0); !errors.Is(err, ErrInvalidFactor) {
+		t.Fatalf("factor 0: got %v, want ErrInvalidFactor", err)
+	}
+}
+
+func TestGetNodesForKeyMatchesRingFactor(t *testing.T) {
+	ring := newTestRing(t, testNodes("a", "b", "c", "d"), SetVirtualNodes(20), SetReplicationFactor(2))
+
+	for _, key := range sampleKeys(200) {
+		want, err := ring.GetNodesForKeyN(key, 2)
+		if err != nil {
+			t.Fatalf("GetNodesForKeyN(%q, 2): %v", key, err)
+		}
+		got, err := ring.GetNodesForKey(key)
+		if err != nil {
+			t.Fatalf("GetNodesForKey(%q): %v", key, err)
+		}
+		if !slices.Equal(ids(got), ids(want)) {
+			t.Fatalf("key %q: GetNodesForKey %v, GetNodesForKeyN %v", key, ids(got), ids(want))
+		}
+	}
+
+	// a per-call factor leaves the ring's own untouched
+	if _, err := ring.GetNodesForKeyN("k", 4); err != nil {
+		t.Fatalf("GetNodesForKeyN(k, 4): %v", err)
+	}
+	if nodes, err := ring.GetNodesForKey("k"); err != nil || len(nodes) != 2 {
+		t.Fatalf("GetNodesForKey after a wider lookup: %v, %v; want 2 nodes", ids(nodes), err)
+	}
+	if _, err := ring.GetNodesForKeyN("k", -1); !errors.Is(err, ErrInvalidFactor) {
+		t.Fatalf("factor -1: got %v, want ErrInvalidFactor", err)
+	}
+}
+
+func TestGetNodesForKeyInsufficientNodes(t *testing.T) {
+	const factor = 3
+	tests := []struct {
+		name    string
+		nodes   []string
+		wantLen int
+		wantErr error
+	}{
+		{"empty ring", nil, 0, ErrNoNodesAvailable},
+		{"one short", []string{"a", "b"}, 2, ErrInsufficientNodes},
+		{"exactly the factor", []string{"a", "b", "c"}, 3, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring := newTestRing(t, testNodes(tt.nodes...), SetReplicationFactor(factor))
+			for _, key := range sampleKeys(50) {
+				nodes, err := ring.GetNodesForKey(key)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("GetNodesForKey(%q): got %v, want %v", key, err, tt.wantErr)
+				}
+				if len(nodes) != tt.wantLen {
+					t.Fatalf("GetNodesForKey(%q) returned %v, want %d nodes", key, ids(nodes), tt.wantLen)
+				}
+				if got := slices.Sorted(slices.Values(ids(nodes))); !slices.Equal(got, slices.Compact(slices.Clone(got))) {
+					t.Fatalf("GetNodesForKey(%q) repeats a node: %v", key, got)
+				}
+			}
+		})
+	}
+}
+
+func TestInitHashRingEValidates(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []HashRingConfigFn
+		want int // number of problems reported
+	}{
+		{"defaults", nil, 0},
+		{"zero virtual nodes", []HashRingConfigFn{SetVirtualNodes(0)}, 1},
+		{"negative virtual nodes", []HashRingConfigFn{SetVirtualNodes(-5)}, 1},
+		{"zero replication factor", []HashRingConfigFn{SetReplicationFactor(0)}, 1},
+		{"nil hash function", []HashRingConfigFn{SetHashFunction(nil)}, 1},
+		{"more zones than replicas", []HashRingConfigFn{SetReplicationFactor(2), RequireDistinctZones(3)}, 1},
+		{"replica share above one", []HashRingConfigFn{SetMaxReplicaShare(1.5)}, 1},
+		{"every problem at once", []HashRingConfigFn{SetVirtualNodes(0), SetReplicationFactor(0), SetHashFunction(nil)}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring, err := InitHashRingE(tt.opts...)
+			if tt.want == 0 {
+				if err != nil || ring == nil {
+					t.Fatalf("InitHashRingE: got %v, want a ring", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidConfig) || ring != nil {
+				t.Fatalf("InitHashRingE: got %v, want ErrInvalidConfig and no ring", err)
+			}
+			joined, ok := err.(interface{ Unwrap() []error })
+			if !ok || len(joined.Unwrap()) != tt.want {
+				t.Fatalf("InitHashRingE reported %v, want %d problems", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveNodeAndByIDAgree(t *testing.T) {
+	byNode := newTestRing(t, testNodes("a", "b", "c"), SetReplicationFactor(2))
+	byID := newTestRing(t, testNodes("a", "b", "c"), SetReplicationFactor(2))
+
+	if err := byNode.RemoveNode(testNode("b")); err != nil {
+		t.Fatalf("RemoveNode: %v", err)
+	}
+	if err := byID.RemoveNodeByID("b"); err != nil {
+		t.Fatalf("RemoveNodeByID: %v", err)
+	}
+	if !slices.Equal(byNode.sortedKeys, byID.sortedKeys) {
+		t.Fatal("rings hold different tokens after the same removal")
+	}
+
+	// either method sees the other's removal
+	if err := byNode.RemoveNodeByID("b"); !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("RemoveNodeByID after RemoveNode: got %v, want ErrNodeNotFound", err)
+	}
+	if err := byID.RemoveNode(testNode("b")); !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("RemoveNode after RemoveNodeByID: got %v, want ErrNodeNotFound", err)
+	}
+	for _, key := range sampleKeys(200) {
+		a, errA := byNode.GetNodesForKey(key)
+		b, errB := byID.GetNodesForKey(key)
+		if errA != nil || errB != nil || !slices.Equal(ids(a), ids(b)) {
+			t.Fatalf("GetNodesForKey(%q): %v (%v) vs %v (%v)", key, ids(a), errA, ids(b), errB)
+		}
+	}
+}
+
+func TestGetNodesForKeyExcluding(t *testing.T) {
+	ring := newTestRing(t, testNodes("a", "b", "c", "d"), SetVirtualNodes(20), SetReplicationFactor(2))
+
+	for _, key := range sampleKeys(200) {
+		full, err := ring.GetNodesForKeyN(key, 4)
+		if err != nil {
+			t.Fatalf("GetNodesForKeyN(%q, 4): %v", key, err)
+		}
+		order := ids(full)
+
+		// excluding the primary promotes the first replica
+		got, err := ring.GetNodesForKeyExcluding(key, order[:1])
+		if err != nil {
+			t.Fatalf("GetNodesForKeyExcluding(%q, %v): %v", key, order[:1], err)
+		}
+		if !slices.Equal(ids(got), order[1:3]) {
+			t.Fatalf("key %q: excluding %s gave %v, want %v", key, order[0], ids(got), order[1:3])
+		}
+
+		// excluding a replica pulls in the next node clockwise
+		got, err = ring.GetNodesForKeyExcluding(key, order[1:2])
+		if err != nil {
+			t.Fatalf("GetNodesForKeyExcluding(%q, %v): %v", key, order[1:2], err)
+		}
+		if want := []string{order[0], order[2]}; !slices.Equal(ids(got), want) {
+			t.Fatalf("key %q: excluding %s gave %v, want %v", key, order[1], ids(got), want)
+		}
+	}
+
+	got, err := ring.GetNodesForKeyExcluding("k", []string{"a", "b", "c"})
+	if !errors.Is(err, ErrInsufficientNodes) || len(got) != 1 || got[0].GetIdentifier() != "d" {
+		t.Fatalf("excluding all but d: got %v, %v; want [d] with ErrInsufficientNodes", ids(got), err)
+	}
+	if got, err := ring.GetNodesForKeyExcluding("k", []string{"a", "b", "c", "d"}); !errors.Is(err, ErrNoNodesAvailable) || got != nil {
+		t.Fatalf("excluding every node: got %v, %v; want ErrNoNodesAvailable", ids(got), err)
+	}
+}
+
+func TestGetNodesForHashAgreesWithGetNodesForKey(t *testing.T) {
+	ring := newTestRing(t, testNodes("a", "b", "c", "d"), SetVirtualNodes(20), SetReplicationFactor(2),
+		SetHashFunction(fnv.New64a))
+
+	for _, key := range sampleKeys(500) {
+		h := fnv.New64a()
+		h.Write([]byte(key))
+
+		viaKey, err := ring.GetNodesForKey(key)
+		if err != nil {
+			t.Fatalf("GetNodesForKey(%q): %v", key, err)
+		}
+		viaHash, err := ring.GetNodesForHash(h.Sum64())
+		if err != nil {
+			t.Fatalf("GetNodesForHash: %v", err)
+		}
+		if !slices.Equal(ids(viaKey), ids(viaHash)) {
+			t.Fatalf("key %q: GetNodesForKey %v, GetNodesForHash %v", key, ids(viaKey), ids(viaHash))
+		}
+	}
+
+	empty := InitHashRing()
+	_, keyErr := empty.GetNodesForKey("k")
+	_, hashErr := empty.GetNodesForHash(42)
+	if !errors.Is(keyErr, ErrNoNodesAvailable) || !errors.Is(hashErr, ErrNoNodesAvailable) {
+		t.Fatalf("empty ring: GetNodesForKey %v, GetNodesForHash %v; want ErrNoNodesAvailable from both", keyErr, hashErr)
+	}
+}
+
+func TestByteKeyLookups(t *testing.T) {
+	ring := newTestRing(t, testNodes("a", "b", "c", "d"), SetVirtualNodes(20), SetReplicationFactor(2))
+
+	for _, key := range sampleKeys(500) {
+		viaString, err := ring.GetNodesForKey(key)
+		if err != nil {
+			t.Fatalf("GetNodesForKey(%q): %v", key, err)
+		}
+		viaBytes, err := ring.GetNodesForKeyBytes([]byte(key))
+		if err != nil {
+			t.Fatalf("GetNodesForKeyBytes(%q): %v", key, err)
+		}
+		if !slices.Equal(ids(viaString), ids(viaBytes)) {
+			t.Fatalf("key %q: GetNodesForKey %v, GetNodesForKeyBytes %v", key, ids(viaString), ids(viaBytes))
+		}
+		primary, err := ring.GetPrimaryNodeBytes([]byte(key))
+		if err != nil || primary.GetIdentifier() != viaString[0].GetIdentifier() {
+			t.Fatalf("key %q: GetPrimaryNodeBytes %v (%v), want %s", key, primary, err, viaString[0].GetIdentifier())
+		}
+	}
+
+	// the byte path saves the conversions a string lookup needs
+	key := []byte("user:42")
+	viaBytes := testing.AllocsPerRun(100, func() { ring.GetPrimaryNodeBytes(key) })
+	viaString := testing.AllocsPerRun(100, func() { ring.GetPrimaryNode(string(key)) })
+	if viaBytes >= viaString {
+		t.Fatalf("GetPrimaryNodeBytes allocates %v times per lookup, GetPrimaryNode(string(key)) %v", viaBytes, viaString)
+	}
+}
+
+func BenchmarkGetPrimaryNodeBytes(b *testing.B) {
+	ring := newTestRing(b, testNodes("a", "b", "c", "d", "e"))
+	key := []byte("user:42")
+
+	b.Run("string", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := ring.GetPrimaryNode(string(key)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("bytes", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := ring.GetPrimaryNodeBytes(key); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestSetNamespace(t *testing.T) {
+	fleet := testNodes("a", "b", "c", "d")
+	keys := sampleKeys(2000)
+	placed := func(opts ...HashRingConfigFn) map[string]string {
+		t.Helper()
+		ring := newTestRing(t, fleet, append([]HashRingConfigFn{SetVirtualNodes(20), SetReplicationFactor(2)}, opts...)...)
+		out := make(map[string]string, len(keys))
+		for _, key := range keys {
+			nodes, err := ring.GetNodesForKey(key)
+			if err != nil {
+				t.Fatalf("GetNodesForKey(%q): %v", key, err)
+			}
+			out[key] = fmt.Sprint(ids(nodes))
+		}
+		return out
+	}
+	sessions := placed(SetNamespace("sessions"))
+	profiles := placed(SetNamespace("profiles"))
+	plain := placed()
+
+	differ := func(x, y map[string]string) int {
+		n := 0
+		for key, set := range x {
+			if y[key] != set {
+				n++
+			}
+		}
+		return n
+	}
+	if n := differ(sessions, profiles); n < len(keys)/2 {
+		t.Errorf("rings in different namespaces disagree on only %d of %d replica sets", n, len(keys))
+	}
+	if n := differ(sessions, plain); n < len(keys)/2 {
+		t.Errorf("a namespaced ring disagrees with an unnamespaced one on only %d of %d replica sets", n, len(keys))
+	}
+	if !maps.Equal(sessions, placed(SetNamespace("sessions"))) {
+		t.Error("rings in the same namespace place keys differently")
+	}
+	if !maps.Equal(plain, placed(SetNamespace(""))) {
+		t.Error("an empty namespace changed the token layout")
+	}
+
+	// resizing keeps the namespace
+	ring := newTestRing(t, fleet, SetVirtualNodes(10), SetNamespace("sessions"))
+	if err := ring.ResizeVirtualNodes(20); err != nil {
+		t.Fatalf("ResizeVirtualNodes: %v", err)
+	}
+	fresh := newTestRing(t, fleet, SetVirtualNodes(20), SetNamespace("sessions"))
+	if !slices.Equal(ring.sortedKeys, fresh.sortedKeys) {
+		t.Error("a resized ring dropped its namespace")
+	}
+}
+
+func TestWithNodes(t *testing.T) {
+	manual := InitHashRing(SetVirtualNodes(10), SetReplicationFactor(2))
+	for _, node := range testNodes("a", "b", "c") {
+		if err := manual.AddNode(node); err != nil {
+			t.Fatalf("AddNode: %v", err)
+		}
+	}
+	seeded, err := InitHashRingE(SetVirtualNodes(10), SetReplicationFactor(2), WithNodes(testNodes("a", "b")...), WithNodes(testNode("c")))
+	if err != nil {
+		t.Fatalf("InitHashRingE: %v", err)
+	}
+	if !slices.Equal(seeded.sortedKeys, manual.sortedKeys) {
+		t.Fatal("a seeded ring has different tokens from one built with AddNode")
+	}
+	for _, key := range sampleKeys(500) {
+		got, err := seeded.GetNodesForKey(key)
+		if err != nil {
+			t.Fatalf("GetNodesForKey(%q): %v", key, err)
+		}
+		want, err := manual.GetNodesForKey(key)
+		if err != nil {
+			t.Fatalf("GetNodesForKey(%q): %v", key, err)
+		}
+		if !slices.Equal(ids(got), ids(want)) {
+			t.Fatalf("key %q on %v in the seeded ring, %v in the manual one", key, ids(got), ids(want))
+		}
+	}
+}
+
+func TestWithNodesDuplicate(t *testing.T) {
+	seeds := testNodes("a", "b", "a")
+	ring, err := InitHashRingE(WithNodes(seeds...))
+	if !errors.Is(err, ErrNodeExists) {
+		t.Fatalf("InitHashRingE with a duplicate seed = %v, want ErrNodeExists", err)
+	}
+	if ring != nil {
+		t.Fatal("InitHashRingE returned a ring alongside the error")
+	}
+
+	// InitHashRing keeps the seeds that could be added
+	partial := InitHashRing(WithNodes(seeds...))
+	if got := slices.Sorted(slices.Values(ids(partial.Members()))); !slices.Equal(got, []string{"a", "b"}) {
+		t.Fatalf("InitHashRing kept %v, want [a b]", got)
+	}
+}
+
+func TestGetNodesForKeyWithFactor(t *testing.T) {
+	ring := newTestRing(t, testNodes("a", "b", "c", "d", "e", "f"), SetVirtualNodes(20), SetReplicationFactor(3))
+
+	for _, key := range sampleKeys(100) {
+		full, err := ring.GetNodesForKeyN(key, 6)
+		if err != nil {
+			t.Fatalf("GetNodesForKeyN(%q, 6): %v", key, err)
+		}
+		for _, factor := range []int{1, 5} {
+			got, err := ring.GetNodesForKeyWithFactor(key, factor)
+			if err != nil {
+				t.Fatalf("GetNodesForKeyWithFactor(%q, %d): %v", key, factor, err)
+			}
+			if !slices.Equal(ids(got), ids(full[:factor])) {
+				t.Fatalf("key %q: factor %d gave %v, want the prefix %v", key, factor, ids(got), ids(full[:factor]))
+			}
+		}
+	}
+
+	// the factor is capped at the distinct node count
+	small := newTestRing(t, testNodes("a", "b", "c"), SetVirtualNodes(20))
+	got, err := small.GetNodesForKeyWithFactor("k", 5)
+	if err != nil || len(got) != 3 {
+		t.Fatalf("factor 5 on 3 nodes: got %v, %v; want all 3 nodes and no error", ids(got), err)
+	}
+	if err := small.DrainNode("c"); err != nil {
+		t.Fatalf("DrainNode: %v", err)
+	}
+	if got, err := small.GetNodesForKeyWithFactor("k", 5); err != nil || len(got) != 2 {
+		t.Fatalf("factor 5 with c drained: got %v, %v; want 2 nodes and no error", ids(got), err)
+	}
+	if _, err := ring.GetNodesForKeyWithFactor("k", 0); !errors.Is(err, ErrInvalidFactor) {
+		t.Fatalf("factor 0: got %v, want ErrInvalidFactor", err)
+	}
+}
@@ -0,0 +1,85 @@
+package redundanthashring
+
+import (
+	"bytes"
+	"strings"
+)
+
+// EnableHashTags makes lookups hash only the part of a key between the first
+// '{' and the next '}', as Redis Cluster does, so keys sharing a tag such as
+// order:{cust42}:items and order:{cust42}:total land on the same node. Keys
+// without a tag, or with an empty one, are hashed whole.
+func EnableHashTags(enabled bool) HashRingConfigFn {
+	return func(cfg *hashRingConfig) {
+		cfg.HashTags = enabled
+	}
+}
+
+// SetKeyNormalizer rewrites every lookup key with normalize before it is
+// hashed, e.g. DefaultKeyNormalizer so "User:1001 " and "user:1001" share a
+// node. Node identifiers are never normalized. nil disables normalization.
+func SetKeyNormalizer(normalize func(string) string) HashRingConfigFn {
+	return func(cfg *hashRingConfig) {
+		cfg.KeyNormalizer = normalize
+	}
+}
+
+// DefaultKeyNormalizer trims surrounding white space and lowercases ASCII
+// letters.
+func DefaultKeyNormalizer(key string) string {
+	key = strings.TrimSpace(key)
+	for i := 0; i < len(key); i++ {
+		if c := key[i]; 'A' <= c && c <= 'Z' {
+			lowered := []byte(key)
+			for j := i; j < len(lowered); j++ {
+				if c := lowered[j]; 'A' <= c && c <= 'Z' {
+					lowered[j] = c + ('a' - 'A')
+				}
+			}
+			return string(lowered)
+		}
+	}
+	return key
+}
+
+// hashKey hashes a lookup key, applying the key normalizer and hash tags
+// when configured. Node identifiers are hashed with generateHash directly.
+func (ring *HashRing) hashKey(key string) (uint64, error) {
+	if ring.config.KeyNormalizer != nil {
+		key = ring.config.KeyNormalizer(key)
+	}
+	if ring.config.HashTags {
+		key = hashTag(key)
+	}
+	return ring.generateHash(key)
+}
+
+func (ring *HashRing) hashKeyBytes(key []byte) (uint64, error) {
+	if ring.config.KeyNormalizer != nil {
+		key = []byte(ring.config.KeyNormalizer(string(key)))
+	}
+	if ring.config.HashTags {
+		key = hashTagBytes(key)
+	}
+	return ring.generateHashBytes(key)
+}
+
+// hashTag returns the non-empty substring between the first '{' and the
+// next '}', or key itself.
+func hashTag(key string) string {
+	if open := strings.IndexByte(key, '{'); open >= 0 {
+		if end := strings.IndexByte(key[open+1:], '}'); end > 0 {
+			return key[open+1 : open+1+end]
+		}
+	}
+	return key
+}
+
+func hashTagBytes(key []byte) []byte {
+	if open := bytes.IndexByte(key, '{'); open >= 0 {
+		if end := bytes.IndexByte(key[open+1:], '}'); end > 0 {
+			return key[open+1 : open+1+end]
+		}
+	}
+	return key
+}
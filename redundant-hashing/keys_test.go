@@ -0,0 +1,153 @@
+package redundanthashring
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var hashTagCases = []struct{ key, want string }{
+	{"order:{cust42}:items", "cust42"},
+	{"{cust42}", "cust42"},
+	{"plain-key", "plain-key"},
+	{"empty:{}:tag", "empty:{}:tag"},
+	{"{}{cust42}", "{}{cust42}"},
+	{"nested:{{cust42}}", "{cust42"},
+	{"open:{cust42", "open:{cust42"},
+	{"close:}cust42{", "close:}cust42{"},
+	{"first:{a}:then:{b}", "a"},
+}
+
+func TestHashTag(t *testing.T) {
+	for _, tt := range hashTagCases {
+		if got := hashTag(tt.key); got != tt.want {
+			t.Errorf("hashTag(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+		if got := string(hashTagBytes([]byte(tt.key))); got != tt.want {
+			t.Errorf("hashTagBytes(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestEnableHashTags(t *testing.T) {
+	fleet := testNodes("a", "b", "c", "d", "e")
+	tagged := newTestRing(t, fleet, SetVirtualNodes(20), SetReplicationFactor(2), EnableHashTags(true))
+	untagged := newTestRing(t, fleet, SetVirtualNodes(20), SetReplicationFactor(2))
+
+	lookup := func(r *HashRing, key string) []string {
+		t.Helper()
+		nodes, err := r.GetNodesForKey(key)
+		if err != nil {
+			t.Fatalf("GetNodesForKey(%q): %v", key, err)
+		}
+		return ids(nodes)
+	}
+
+	spread := make(map[string]bool)
+	for i := range 50 {
+		tag := fmt.Sprintf("cust%d", i)
+		want := lookup(tagged, tag)
+		for _, key := range []string{"order:{" + tag + "}:items", "order:{" + tag + "}:total"} {
+			if got := lookup(tagged, key); !slices.Equal(got, want) {
+				t.Errorf("GetNodesForKey(%q) = %v, want %v like its tag", key, got, want)
+			}
+			primary, err := tagged.GetPrimaryNodeBytes([]byte(key))
+			if err != nil {
+				t.Fatalf("GetPrimaryNodeBytes(%q): %v", key, err)
+			}
+			if primary.GetIdentifier() != want[0] {
+				t.Errorf("GetPrimaryNodeBytes(%q) = %s, want %s", key, primary.GetIdentifier(), want[0])
+			}
+		}
+
+		// keys without a usable tag are hashed whole, as without the option
+		for _, key := range []string{tag, tag + ":{}", tag + ":{open"} {
+			if got, want := lookup(tagged, key), lookup(untagged, key); !slices.Equal(got, want) {
+				t.Errorf("GetNodesForKey(%q) = %v with hash tags, %v without", key, got, want)
+			}
+		}
+		spread[lookup(untagged, fmt.Sprintf("user:{cust42}:%d", i))[0]] = true
+	}
+	if len(spread) < 2 {
+		t.Errorf("keys sharing a tag all land on %v without hash tags", spread)
+	}
+}
+
+func TestDefaultKeyNormalizer(t *testing.T) {
+	tests := []struct{ key, want string }{
+		{"User:1001 ", "user:1001"},
+		{"\tSESSION:Abc\n", "session:abc"},
+		{"already:lower", "already:lower"},
+		{"Ünïcode:KEY", "Ünïcode:key"},
+		{"   ", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := DefaultKeyNormalizer(tt.key); got != tt.want {
+			t.Errorf("DefaultKeyNormalizer(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestSetKeyNormalizer(t *testing.T) {
+	fleet := testNodes("Cache-A", "Cache-B", "Cache-C", "Cache-D")
+	normalized := newTestRing(t, fleet, SetVirtualNodes(20), SetReplicationFactor(2), SetKeyNormalizer(DefaultKeyNormalizer))
+	plain := newTestRing(t, fleet, SetVirtualNodes(20), SetReplicationFactor(2))
+	lookup := func(r *HashRing, key string) []string {
+		t.Helper()
+		nodes, err := r.GetNodesForKey(key)
+		if err != nil {
+			t.Fatalf("GetNodesForKey(%q): %v", key, err)
+		}
+		return ids(nodes)
+	}
+
+	if a, b := lookup(normalized, "User:1001 "), lookup(normalized, "user:1001"); !slices.Equal(a, b) {
+		t.Errorf("with the normalizer %q is on %v and %q on %v", "User:1001 ", a, "user:1001", b)
+	}
+	if a, b := lookup(plain, "User:1001 "), lookup(plain, "user:1001"); slices.Equal(a, b) {
+		t.Errorf("without the normalizer both spellings land on %v", a)
+	}
+
+	for _, key := range sampleKeys(200) {
+		messy := " " + strings.ToUpper(key) + "\t"
+		want := lookup(plain, key)
+		if got := lookup(normalized, messy); !slices.Equal(got, want) {
+			t.Fatalf("GetNodesForKey(%q) = %v, want %v like %q", messy, got, want, key)
+		}
+		primary, err := normalized.GetPrimaryNodeBytes([]byte(messy))
+		if err != nil {
+			t.Fatalf("GetPrimaryNodeBytes(%q): %v", messy, err)
+		}
+		if primary.GetIdentifier() != want[0] {
+			t.Fatalf("GetPrimaryNodeBytes(%q) = %s, want %s", messy, primary.GetIdentifier(), want[0])
+		}
+	}
+
+	// node identifiers keep their case and their tokens
+	if !normalized.HasNode("Cache-A") || normalized.HasNode("cache-a") {
+		t.Error("the normalizer was applied to node identifiers")
+	}
+	if !slices.Equal(normalized.sortedKeys, plain.sortedKeys) {
+		t.Error("the normalizer moved node tokens")
+	}
+}
+
+func TestSetKeyNormalizerNil(t *testing.T) {
+	ring := newTestRing(t, testNodes("a", "b", "c"), SetKeyNormalizer(nil))
+	plain := newTestRing(t, testNodes("a", "b", "c"))
+	for _, key := range sampleKeys(200) {
+		got, err := ring.GetPrimaryNode(key)
+		if err != nil {
+			t.Fatalf("GetPrimaryNode(%q): %v", key, err)
+		}
+		want, err := plain.GetPrimaryNode(key)
+		if err != nil {
+			t.Fatalf("GetPrimaryNode(%q): %v", key, err)
+		}
+		if got.GetIdentifier() != want.GetIdentifier() {
+			t.Fatalf("a nil normalizer moved %q from %s to %s", key, want.GetIdentifier(), got.GetIdentifier())
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package redundanthashring
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Members returns a snapshot of the ring's physical nodes sorted by
+// identifier.
+func (ring *HashRing) Members() []ICacheNode {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+	return ring.members()
+}
+
+// members is Members without the locking. Callers must hold ring.mu.
+func (ring *HashRing) members() []ICacheNode {
+	var members []ICacheNode
+	ring.hostSet.Range(func(_, val any) bool {
+		members = append(members, val.(ICacheNode))
+		return true
+	})
+	slices.SortFunc(members, func(a, b ICacheNode) int {
+		return strings.Compare(a.GetIdentifier(), b.GetIdentifier())
+	})
+	return members
+}
+
+// MemberIDs is Members reduced to the sorted identifiers.
+func (ring *HashRing) MemberIDs() []string {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+	return ring.memberIDs()
+}
+
+// memberIDs is MemberIDs without the locking. Callers must hold ring.mu.
+func (ring *HashRing) memberIDs() []string {
+	members := ring.members()
+	ids := make([]string, len(members))
+	for i, node := range members {
+		ids[i] = node.GetIdentifier()
+	}
+	return ids
+}
+
+// NodeCount returns the number of physical nodes on the ring.
+func (ring *HashRing) NodeCount() int {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+	return len(ring.positions)
+}
+
+// VirtualNodeCount returns the number of tokens on the ring.
+func (ring *HashRing) VirtualNodeCount() int {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+	return len(ring.sortedKeys)
+}
+
+// HasNode reports whether a node with identifier id is on the ring.
+func (ring *HashRing) HasNode(id string) bool {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+	_, ok := ring.hostSet.Load(id)
+	return ok
+}
+
+// UpdateNode replaces the stored object for node's identifier, e.g. after a
+// reconnect hands out a new object for the same host. Its tokens stay where
+// they are, so no keys move.
+func (ring *HashRing) UpdateNode(node ICacheNode) error {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	id := node.GetIdentifier()
+	if _, ok := ring.hostSet.Load(id); !ok {
+		return fmt.Errorf("%w: %s", ErrNodeNotFound, id)
+	}
+	ring.hostSet.Store(id, node)
+	for _, h := range ring.sortedKeys {
+		if val, ok := ring.vNodeMap.Load(h); ok && val.(ICacheNode).GetIdentifier() == id {
+			ring.vNodeMap.Store(h, node)
+		}
+	}
+	return nil
+}
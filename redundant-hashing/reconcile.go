@@ -0,0 +1,59 @@
+package redundanthashring
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+)
+
+// Reconcile makes the ring's membership match target by identifier: nodes
+// missing from the ring are added and nodes absent from target are removed,
+// all under one write lock so readers never see a half-converged ring. The
+// identifiers added and removed are returned sorted. Nodes that fail to join
+// are named in err while the rest of the change still applies.
+func (ring *HashRing) Reconcile(target []ICacheNode) (added, removed []string, err error) {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	wanted := make(map[string]struct{}, len(target))
+	var joining []ICacheNode
+	for _, node := range target {
+		id := node.GetIdentifier()
+		if _, dup := wanted[id]; dup {
+			continue
+		}
+		wanted[id] = struct{}{}
+		if _, exists := ring.hostSet.Load(id); !exists {
+			joining = append(joining, node)
+		}
+	}
+
+	ring.hostSet.Range(func(key, _ any) bool {
+		if _, keep := wanted[key.(string)]; !keep {
+			removed = append(removed, key.(string))
+		}
+		return true
+	})
+	slices.Sort(removed)
+	for _, id := range removed {
+		ring.removeNode(id)
+	}
+
+	var errs []error
+	for _, node := range joining {
+		if err := ring.addNode(node, 1); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", node.GetIdentifier(), err))
+			continue
+		}
+		added = append(added, node.GetIdentifier())
+	}
+	slices.Sort(added)
+	ring.refreshReplicaDuty()
+
+	if ring.config.EnableLogs && (len(added) > 0 || len(removed) > 0) {
+		log.Printf("🔄 Reconciled membership: added %v, removed %v", added, removed)
+	}
+
+	return added, removed, errors.Join(errs...)
+}
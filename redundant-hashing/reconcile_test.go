@@ -0,0 +1,64 @@
+package redundanthashring
+
+import (
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestReconcile(t *testing.T) {
+	ring := newTestRing(t, testNodes("a", "b", "c"), SetVirtualNodes(20))
+
+	added, removed, err := ring.Reconcile(testNodes("b", "c", "d", "d", "e"))
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if !slices.Equal(added, []string{"d", "e"}) || !slices.Equal(removed, []string{"a"}) {
+		t.Fatalf("Reconcile added %v and removed %v, want [d e] and [a]", added, removed)
+	}
+	if got := ring.MemberIDs(); !slices.Equal(got, []string{"b", "c", "d", "e"}) {
+		t.Fatalf("members %v, want [b c d e]", got)
+	}
+	if got := ring.VirtualNodeCount(); got != 80 {
+		t.Fatalf("%d tokens, want 80", got)
+	}
+
+	// a second pass with the same target changes nothing
+	added, removed, err = ring.Reconcile(testNodes("e", "d", "c", "b"))
+	if err != nil || len(added) != 0 || len(removed) != 0 {
+		t.Fatalf("repeated Reconcile: added %v, removed %v, err %v", added, removed, err)
+	}
+}
+
+func TestReconcileIsAtomicForReaders(t *testing.T) {
+	sets := [][]string{{"a", "b", "c"}, {"d", "e"}}
+	ring := newTestRing(t, testNodes(sets[0]...))
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if got := ring.MemberIDs(); !slices.Equal(got, sets[0]) && !slices.Equal(got, sets[1]) {
+					t.Errorf("reader saw a half-converged ring: %v", got)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := range 200 {
+		if _, _, err := ring.Reconcile(testNodes(sets[(i+1)%2]...)); err != nil {
+			t.Fatalf("Reconcile: %v", err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
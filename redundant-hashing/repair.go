@@ -0,0 +1,37 @@
+package redundanthashring
+
+// RepairOrder returns key's replica set in the order an anti-entropy process
+// should treat it: the primary, which is the source of truth, first and the
+// remaining replicas in clockwise ring order after it. Replica placement
+// options such as SetPrimaryZonePeer decide which nodes are in the set but
+// not the order they are returned in.
+func (ring *HashRing) RepairOrder(key string) ([]ICacheNode, error) {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	if len(ring.sortedKeys) == 0 {
+		return nil, ErrNoNodesAvailable
+	}
+
+	h, err := ring.hashKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	replicas := ring.replicasFor(h, ring.config.ReplicationFactor, nil)
+	if len(replicas) == 0 {
+		return nil, ErrNoNodesAvailable
+	}
+
+	members := make(map[string]struct{}, len(replicas))
+	for _, n := range replicas {
+		members[n.GetIdentifier()] = struct{}{}
+	}
+
+	// the clockwise walk visits the primary first, so filtering it down to the
+	// replica set yields the repair order directly
+	return ring.collectNodes(ring.search(h), make([]ICacheNode, 0, len(replicas)), len(replicas), func(n ICacheNode) bool {
+		_, ok := members[n.GetIdentifier()]
+		return ok
+	}), nil
+}
@@ -0,0 +1,38 @@
+package redundanthashring
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRepairOrder(t *testing.T) {
+	nodes := []ICacheNode{
+		zoneNode{"a1", "a"}, zoneNode{"a2", "a"},
+		zoneNode{"b1", "b"}, zoneNode{"b2", "b"}, zoneNode{"c1", "c"},
+	}
+	ring := newTestRing(t, nodes, SetVirtualNodes(20), SetReplicationFactor(3), SetPrimaryZonePeer(true))
+	plain := newTestRing(t, nodes, SetVirtualNodes(20), SetReplicationFactor(3))
+
+	for _, key := range sampleKeys(300) {
+		set, err := ring.GetNodesForKey(key)
+		if err != nil {
+			t.Fatalf("GetNodesForKey(%q): %v", key, err)
+		}
+		order, err := ring.RepairOrder(key)
+		if err != nil {
+			t.Fatalf("RepairOrder(%q): %v", key, err)
+		}
+		if order[0].GetIdentifier() != set[0].GetIdentifier() {
+			t.Fatalf("key %q: repair starts at %s, the primary is %s", key, order[0].GetIdentifier(), set[0].GetIdentifier())
+		}
+
+		// the clockwise walk over every node, filtered to the replica set
+		walk, _ := plain.GetNodesForKeyN(key, len(nodes))
+		want := slices.DeleteFunc(ids(walk), func(id string) bool {
+			return !slices.Contains(ids(set), id)
+		})
+		if got := ids(order); !slices.Equal(got, want) {
+			t.Fatalf("key %q: repair order %v, want clockwise %v", key, got, want)
+		}
+	}
+}
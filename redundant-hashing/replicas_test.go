@@ -0,0 +1,31 @@
+package redundanthashring
+
+import (
+	"fmt"
+	"testing"
+)
+
+type replicaNode string
+
+func (n replicaNode) GetIdentifier() string { return string(n) }
+
+func TestGetNodesForKeyDistinctPhysicalNodes(t *testing.T) {
+	// with many virtual nodes per host, consecutive tokens often share one
+	ring := InitHashRing(SetVirtualNodes(50), SetReplicationFactor(2))
+	for _, id := range []string{"a", "b"} {
+		if err := ring.AddNode(replicaNode(id)); err != nil {
+			t.Fatalf("AddNode(%s): %v", id, err)
+		}
+	}
+
+	for i := range 1000 {
+		key := fmt.Sprintf("key-%d", i)
+		nodes, err := ring.GetNodesForKey(key)
+		if err != nil {
+			t.Fatalf("GetNodesForKey(%q): %v", key, err)
+		}
+		if len(nodes) != 2 || nodes[0].GetIdentifier() == nodes[1].GetIdentifier() {
+			t.Fatalf("key %q placed on %v, want two distinct nodes", key, nodes)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package redundanthashring
+
+import "errors"
+
+// ReplicaSet is the placement of one key: the primary node followed by the
+// secondaries in ring order.
+type ReplicaSet struct {
+	Primary     ICacheNode
+	Secondaries []ICacheNode
+}
+
+func newReplicaSet(nodes []ICacheNode) ReplicaSet {
+	if len(nodes) == 0 {
+		return ReplicaSet{}
+	}
+	return ReplicaSet{Primary: nodes[0], Secondaries: nodes[1:]}
+}
+
+// All returns every node of the set, primary first, or nil for an empty set.
+func (s ReplicaSet) All() []ICacheNode {
+	if s.Primary == nil {
+		return nil
+	}
+	return append([]ICacheNode{s.Primary}, s.Secondaries...)
+}
+
+// Contains reports whether the node with the given ID is part of the set.
+func (s ReplicaSet) Contains(nodeID string) bool {
+	if s.Primary != nil && s.Primary.GetIdentifier() == nodeID {
+		return true
+	}
+	for _, n := range s.Secondaries {
+		if n.GetIdentifier() == nodeID {
+			return true
+		}
+	}
+	return false
+}
+
+// GetReplicaSet is GetNodesForKey with the primary and secondaries kept
+// apart. As with GetNodesForKeyN, a short or badly spread placement is
+// returned together with ErrInsufficientNodes or ErrInsufficientZones.
+func (ring *HashRing) GetReplicaSet(key string) (ReplicaSet, error) {
+	nodes, err := ring.GetNodesForKeyN(key, ring.config.ReplicationFactor)
+	return newReplicaSet(nodes), err
+}
+
+// GetSecondaryNodes returns the key's replicas without the primary, e.g. for
+// asynchronous replication after a synchronous write to the primary. A ring
+// with a single usable node has no secondaries, which is not an error;
+// otherwise a short placement is reported as by GetReplicaSet.
+func (ring *HashRing) GetSecondaryNodes(key string) ([]ICacheNode, error) {
+	set, err := ring.GetReplicaSet(key)
+	if set.Primary == nil {
+		return nil, err
+	}
+	if errors.Is(err, ErrInsufficientNodes) && len(set.Secondaries) == 0 {
+		return []ICacheNode{}, nil
+	}
+	return set.Secondaries, err
+}
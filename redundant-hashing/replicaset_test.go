@@ -0,0 +1,55 @@
+package redundanthashring
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestGetSecondaryNodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodes   []string
+		factor  int
+		want    int
+		wantErr error
+	}{
+		{"factor 1", []string{"a", "b", "c"}, 1, 0, nil},
+		{"factor 2", []string{"a", "b", "c"}, 2, 1, nil},
+		{"factor above node count", []string{"a", "b", "c"}, 5, 2, ErrInsufficientNodes},
+		{"single node", []string{"solo"}, 3, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring := newTestRing(t, testNodes(tt.nodes...), SetVirtualNodes(20), SetReplicationFactor(tt.factor))
+			for _, key := range sampleKeys(300) {
+				secondaries, err := ring.GetSecondaryNodes(key)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("GetSecondaryNodes(%q) error = %v, want %v", key, err, tt.wantErr)
+				}
+				if secondaries == nil {
+					t.Fatalf("GetSecondaryNodes(%q) returned nil, want an empty slice", key)
+				}
+				if len(secondaries) != tt.want {
+					t.Fatalf("GetSecondaryNodes(%q) = %v, want %d nodes", key, ids(secondaries), tt.want)
+				}
+
+				// the replica set minus its primary, in ring order
+				all, _ := ring.GetNodesForKeyN(key, tt.factor)
+				if !slices.Equal(ids(secondaries), ids(all[1:])) {
+					t.Fatalf("GetSecondaryNodes(%q) = %v, want %v after primary %s", key, ids(secondaries), ids(all[1:]), all[0].GetIdentifier())
+				}
+				if slices.Contains(ids(secondaries), all[0].GetIdentifier()) {
+					t.Fatalf("GetSecondaryNodes(%q) includes the primary %s", key, all[0].GetIdentifier())
+				}
+			}
+		})
+	}
+}
+
+func TestGetSecondaryNodesEmptyRing(t *testing.T) {
+	ring := InitHashRing(SetReplicationFactor(2))
+	if _, err := ring.GetSecondaryNodes("key"); !errors.Is(err, ErrNoNodesAvailable) {
+		t.Fatalf("GetSecondaryNodes on an empty ring = %v, want ErrNoNodesAvailable", err)
+	}
+}
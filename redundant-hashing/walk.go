@@ -0,0 +1,23 @@
+package redundanthashring
+
+// Walk calls fn for every token in ascending order, stopping early when fn
+// returns false. It walks a snapshot, so fn may safely call back into the
+// ring.
+func (ring *HashRing) Walk(fn func(token uint64, node ICacheNode) bool) {
+	ring.mu.RLock()
+	tokens := make([]uint64, 0, len(ring.sortedKeys))
+	nodes := make([]ICacheNode, 0, len(ring.sortedKeys))
+	for _, h := range ring.sortedKeys {
+		if node, ok := ring.vNodeMap.Load(h); ok {
+			tokens = append(tokens, h)
+			nodes = append(nodes, node.(ICacheNode))
+		}
+	}
+	ring.mu.RUnlock()
+
+	for i, token := range tokens {
+		if !fn(token, nodes[i]) {
+			return
+		}
+	}
+}
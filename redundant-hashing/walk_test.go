@@ -0,0 +1,69 @@
+package redundanthashring
+
+import (
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	ring := newTestRing(t, testNodes("a", "b", "c"), SetVirtualNodes(10))
+
+	var tokens []uint64
+	ring.Walk(func(token uint64, node ICacheNode) bool {
+		owner, ok := ring.vNodeMap.Load(token)
+		if !ok || owner.(ICacheNode).GetIdentifier() != node.GetIdentifier() {
+			t.Fatalf("token %d reported for %s, ring has %v", token, node.GetIdentifier(), owner)
+		}
+		// fn may call back into the ring
+		if !ring.HasNode(node.GetIdentifier()) {
+			t.Fatalf("%s is not a member", node.GetIdentifier())
+		}
+		tokens = append(tokens, token)
+		return true
+	})
+	if !slices.Equal(tokens, ring.sortedKeys) {
+		t.Fatal("Walk order differs from the sorted token slice")
+	}
+
+	visited := 0
+	ring.Walk(func(uint64, ICacheNode) bool {
+		visited++
+		return visited < 5
+	})
+	if visited != 5 {
+		t.Fatalf("Walk visited %d tokens after fn returned false at 5", visited)
+	}
+}
+
+func TestString(t *testing.T) {
+	opts := []HashRingConfigFn{SetVirtualNodes(2), SetReplicationFactor(2), SetHashFunctionNamed("fnv64a", fnv.New64a)}
+	ring := newTestRing(t, testNodes("b", "a"), opts...)
+
+	want := `HashRing{nodes: 2, tokens: 4, vnodes: 2, replicas: 2, hash: fnv64a}
+  members: a, b
+  16594067088357469828 a
+  16594068187869098039 a
+  18403359651585666790 b
+  18403360751097295001 b
+`
+	if got := fmt.Sprint(ring); got != want {
+		t.Fatalf("String() =\n%s\nwant\n%s", got, want)
+	}
+	if got := newTestRing(t, testNodes("a", "b"), opts...).String(); got != want {
+		t.Fatalf("insertion order changed String():\n%s", got)
+	}
+
+	big := newTestRing(t, testNodes("a", "b", "c"), SetVirtualNodes(10))
+
+	// large rings end with a count of the tokens left out
+	lines := strings.Split(strings.TrimSuffix(big.String(), "\n"), "\n")
+	if got := lines[len(lines)-1]; got != "  … and 10 more tokens" {
+		t.Fatalf("last line %q, want the truncation notice", got)
+	}
+	if got := len(lines); got != 23 {
+		t.Fatalf("%d lines, want 23", got)
+	}
+}
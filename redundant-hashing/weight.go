@@ -0,0 +1,98 @@
+package redundanthashring
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"slices"
+)
+
+// AddNodeWithWeight adds node with round(VirtualNodes*weight) virtual nodes
+// (at least one), so a node of weight 2 is primary for roughly twice as many
+// keys. Replica sets still hold distinct physical nodes.
+func (ring *HashRing) AddNodeWithWeight(node ICacheNode, weight float64) error {
+	if !(weight > 0) || math.IsInf(weight, 1) {
+		return fmt.Errorf("%w: %g for %s", ErrInvalidWeight, weight, node.GetIdentifier())
+	}
+
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+	if err := ring.addNode(node, weight); err != nil {
+		return err
+	}
+	ring.refreshReplicaDuty()
+	return nil
+}
+
+// vnodesFor scales the configured virtual node count by weight, keeping at
+// least one.
+func (ring *HashRing) vnodesFor(weight float64) int {
+	return max(1, int(math.Round(float64(ring.config.VirtualNodes)*weight)))
+}
+
+// ResizeVirtualNodes regenerates every node's tokens for a new base virtual
+// node count, keeping each node's weight. The new layout is fully computed
+// before it replaces the old one under a single write lock, so readers see
+// either the old or the new ring. If two virtual nodes of the new layout
+// hash to the same token the resize fails with ErrTokenCollision and the
+// ring keeps its old layout.
+func (ring *HashRing) ResizeVirtualNodes(count int) error {
+	if count < 1 {
+		return fmt.Errorf("%w: virtual nodes must be at least 1, got %d", ErrInvalidConfig, count)
+	}
+
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	previous := ring.config.VirtualNodes
+	ring.config.VirtualNodes = count
+
+	owners := make(map[uint64]ICacheNode, len(ring.sortedKeys))
+	var hashErr error
+	ring.hostSet.Range(func(key, val any) bool {
+		id, node := key.(string), val.(ICacheNode)
+		weight, ok := ring.weights[id]
+		if !ok {
+			weight = 1
+		}
+		n := ring.vnodesFor(weight)
+		for i := 0; i < n; i++ {
+			vID := ring.namespaced(fmt.Sprintf("%s#%d", id, i))
+			h, err := ring.generateHash(vID)
+			if err != nil {
+				hashErr = fmt.Errorf("%w: %s", ErrHashingKey, vID)
+				return false
+			}
+			if other, taken := owners[h]; taken {
+				hashErr = fmt.Errorf("%w: %s and a virtual node of %s at %d", ErrTokenCollision, vID, other.GetIdentifier(), h)
+				return false
+			}
+			owners[h] = node
+		}
+		return true
+	})
+	if hashErr != nil {
+		ring.config.VirtualNodes = previous
+		return hashErr
+	}
+
+	for _, h := range ring.sortedKeys {
+		ring.vNodeMap.Delete(h)
+	}
+	sortedKeys := make([]uint64, 0, len(owners))
+	positions := make(map[string]int, len(ring.positions))
+	for h, node := range owners {
+		ring.vNodeMap.Store(h, node)
+		sortedKeys = append(sortedKeys, h)
+		positions[node.GetIdentifier()]++
+	}
+	slices.Sort(sortedKeys)
+	ring.sortedKeys = sortedKeys
+	ring.positions = positions
+	ring.refreshReplicaDuty()
+
+	if ring.config.EnableLogs {
+		log.Printf("🔁 Virtual nodes resized %d → %d, %d tokens", previous, count, len(sortedKeys))
+	}
+	return nil
+}
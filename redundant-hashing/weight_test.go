@@ -0,0 +1,159 @@
+package redundanthashring
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"math"
+	"slices"
+	"testing"
+)
+
+// shaHash is a well-mixed 64-bit hash built on SHA-256, for tests whose
+// outcome depends on virtual nodes spreading evenly. FNV-1a places
+// "a#0", "a#1", ... next to each other.
+type shaHash struct{ buf []byte }
+
+func newSHAHash() hash.Hash64 { return &shaHash{} }
+
+func (s *shaHash) Write(p []byte) (int, error) {
+	s.buf = append(s.buf, p...)
+	return len(p), nil
+}
+
+func (s *shaHash) Sum64() uint64 {
+	sum := sha256.Sum256(s.buf)
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+func (s *shaHash) Sum(b []byte) []byte {
+	return binary.BigEndian.AppendUint64(b, s.Sum64())
+}
+func (s *shaHash) Reset()         { s.buf = s.buf[:0] }
+func (s *shaHash) Size() int      { return 8 }
+func (s *shaHash) BlockSize() int { return 1 }
+
+// pinnedHash hashes the names in pins to fixed tokens and everything else
+// with FNV-1a, so a test can force two virtual nodes onto one token.
+type pinnedHash struct {
+	hash.Hash64
+	buf  []byte
+	pins map[string]uint64
+}
+
+func newPinnedHash(pins map[string]uint64) func() hash.Hash64 {
+	return func() hash.Hash64 { return &pinnedHash{Hash64: fnv.New64a(), pins: pins} }
+}
+
+func (p *pinnedHash) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	return p.Hash64.Write(b)
+}
+
+func (p *pinnedHash) Sum64() uint64 {
+	if token, ok := p.pins[string(p.buf)]; ok {
+		return token
+	}
+	return p.Hash64.Sum64()
+}
+
+func (p *pinnedHash) Reset() {
+	p.buf = p.buf[:0]
+	p.Hash64.Reset()
+}
+
+func TestAddNodeWithWeightPrimaryShare(t *testing.T) {
+	weights := map[string]float64{"a": 1, "b": 2, "c": 1}
+	ring := newWeightedRing(t, weights, SetVirtualNodes(500), SetReplicationFactor(2), SetHashFunction(newSHAHash))
+
+	const keys = 100000
+	primaries := make(map[string]int)
+	for i := range keys {
+		key := fmt.Sprintf("key-%d", i)
+		nodes, err := ring.GetNodesForKey(key)
+		if err != nil {
+			t.Fatalf("GetNodesForKey(%q): %v", key, err)
+		}
+		if nodes[0].GetIdentifier() == nodes[1].GetIdentifier() {
+			t.Fatalf("key %q placed twice on %s", key, nodes[0].GetIdentifier())
+		}
+		primaries[nodes[0].GetIdentifier()]++
+	}
+
+	for id, w := range weights {
+		want := w / 4
+		if got := float64(primaries[id]) / keys; math.Abs(got-want) > 0.03 {
+			t.Errorf("%s is primary for %.3f of keys, want %.2f", id, got, want)
+		}
+	}
+}
+
+func TestAddNodeWithWeightKeepsReplicasDistinct(t *testing.T) {
+	// heavy owns long runs of consecutive tokens
+	ring := newWeightedRing(t, map[string]float64{"heavy": 20, "light": 1}, SetVirtualNodes(10), SetReplicationFactor(2))
+
+	for _, key := range sampleKeys(2000) {
+		nodes, err := ring.GetNodesForKey(key)
+		if err != nil {
+			t.Fatalf("GetNodesForKey(%q): %v", key, err)
+		}
+		if len(nodes) != 2 || nodes[0].GetIdentifier() == nodes[1].GetIdentifier() {
+			t.Fatalf("key %q placed on %v, want heavy and light", key, ids(nodes))
+		}
+	}
+}
+
+func TestResizeVirtualNodes(t *testing.T) {
+	ring := newWeightedRing(t, map[string]float64{"a": 1, "b": 2, "c": 0.5}, SetVirtualNodes(4))
+
+	if err := ring.ResizeVirtualNodes(10); err != nil {
+		t.Fatalf("ResizeVirtualNodes: %v", err)
+	}
+	if got := ring.VirtualNodes(); got != 10 {
+		t.Errorf("VirtualNodes() = %d, want 10", got)
+	}
+	want := map[string]int{"a": 10, "b": 20, "c": 5}
+	for id, n := range want {
+		if ring.positions[id] != n {
+			t.Errorf("%s has %d tokens, want %d", id, ring.positions[id], n)
+		}
+	}
+	if got := len(ring.sortedKeys); got != 35 {
+		t.Errorf("ring has %d tokens, want 35", got)
+	}
+	if !slices.IsSorted(ring.sortedKeys) {
+		t.Error("tokens are not sorted after resize")
+	}
+
+	for _, count := range []int{0, -1} {
+		if err := ring.ResizeVirtualNodes(count); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("ResizeVirtualNodes(%d) = %v, want ErrInvalidConfig", count, err)
+		}
+	}
+}
+
+func TestResizeVirtualNodesRejectsCollisions(t *testing.T) {
+	// b's second virtual node lands on a's first, which only exists once
+	// the resize gives every node two of them
+	pins := map[string]uint64{"a#0": 1000, "b#0": 5000, "b#1": 1000}
+	ring := newTestRing(t, testNodes("a", "b"), SetVirtualNodes(1), SetHashFunction(newPinnedHash(pins)))
+	before := slices.Clone(ring.sortedKeys)
+
+	if err := ring.ResizeVirtualNodes(2); !errors.Is(err, ErrTokenCollision) {
+		t.Fatalf("ResizeVirtualNodes(2) = %v, want ErrTokenCollision", err)
+	}
+	if got := ring.VirtualNodes(); got != 1 {
+		t.Errorf("VirtualNodes() = %d after a failed resize, want 1", got)
+	}
+	if !slices.Equal(ring.sortedKeys, before) {
+		t.Errorf("tokens changed from %v to %v after a failed resize", before, ring.sortedKeys)
+	}
+	for _, id := range []string{"a", "b"} {
+		if ring.positions[id] != 1 {
+			t.Errorf("%s has %d tokens after a failed resize, want 1", id, ring.positions[id])
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package redundanthashring
+
+// RequireDistinctZones makes GetNodesForKey prefer nodes from new zones
+// until the replica set spans at least min zones. When the ring doesn't have
+// enough zones the replicas are still returned, together with
+// ErrInsufficientZones. Nodes that don't implement IZoneAware count as
+// zone "".
+func RequireDistinctZones(min int) HashRingConfigFn {
+	return func(cfg *hashRingConfig) {
+		cfg.MinZones = min
+	}
+}
+
+// spreadZones walks clockwise from start adding nodes from zones not yet in
+// selected until MinZones zones are covered or want nodes are selected.
+// Callers must hold ring.mu.
+func (ring *HashRing) spreadZones(start int, selected []ICacheNode, want int, accepts func(ICacheNode) bool) []ICacheNode {
+	zones := make(map[string]struct{}, ring.config.MinZones)
+	for _, n := range selected {
+		zones[zoneOf(n)] = struct{}{}
+	}
+
+	for len(zones) < ring.config.MinZones && len(selected) < want {
+		before := len(selected)
+		selected = ring.collectNodes(start, selected, before+1, func(n ICacheNode) bool {
+			_, seen := zones[zoneOf(n)]
+			return !seen && accepts(n)
+		})
+		if len(selected) == before {
+			break
+		}
+		zones[zoneOf(selected[before])] = struct{}{}
+	}
+	return selected
+}
+
+func distinctZones(nodes []ICacheNode) int {
+	zones := make(map[string]struct{}, len(nodes))
+	for _, n := range nodes {
+		zones[zoneOf(n)] = struct{}{}
+	}
+	return len(zones)
+}
@@ -0,0 +1,102 @@
+package redundanthashring
+
+import (
+	"errors"
+	"testing"
+)
+
+func zonesOf(nodes []ICacheNode) map[string]struct{} {
+	zones := make(map[string]struct{}, len(nodes))
+	for _, n := range nodes {
+		zones[zoneOf(n)] = struct{}{}
+	}
+	return zones
+}
+
+func TestRequireDistinctZones(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodes   []ICacheNode
+		wantErr error
+		zones   int
+	}{
+		{
+			name: "two zones",
+			nodes: []ICacheNode{
+				zoneNode{"a1", "a"}, zoneNode{"a2", "a"}, zoneNode{"a3", "a"},
+				zoneNode{"b1", "b"}, zoneNode{"b2", "b"},
+			},
+			zones: 2,
+		},
+		{
+			name:    "single zone",
+			nodes:   []ICacheNode{zoneNode{"a1", "a"}, zoneNode{"a2", "a"}, zoneNode{"a3", "a"}},
+			wantErr: ErrInsufficientZones,
+			zones:   1,
+		},
+		{
+			name:    "no zone information",
+			nodes:   testNodes("x", "y", "z"),
+			wantErr: ErrInsufficientZones,
+			zones:   1,
+		},
+		{
+			// plain nodes share zone "", which is distinct from "a"
+			name:  "plain and zoned nodes",
+			nodes: []ICacheNode{zoneNode{"a1", "a"}, zoneNode{"a2", "a"}, testNode("x"), testNode("y")},
+			zones: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring := newTestRing(t, tt.nodes, SetVirtualNodes(20), SetReplicationFactor(2), RequireDistinctZones(2))
+			for _, key := range sampleKeys(500) {
+				nodes, err := ring.GetNodesForKey(key)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("GetNodesForKey(%q) error = %v, want %v", key, err, tt.wantErr)
+				}
+				// a shortfall still returns a full replica set
+				if len(nodes) != 2 || nodes[0].GetIdentifier() == nodes[1].GetIdentifier() {
+					t.Fatalf("key %q placed on %v, want 2 distinct nodes", key, ids(nodes))
+				}
+				if got := len(zonesOf(nodes)); got != tt.zones {
+					t.Fatalf("key %q placed on %v spanning %d zones, want %d", key, ids(nodes), got, tt.zones)
+				}
+			}
+		})
+	}
+}
+
+func TestRequireDistinctZonesKeepsPrimary(t *testing.T) {
+	nodes := []ICacheNode{
+		zoneNode{"a1", "a"}, zoneNode{"a2", "a"}, zoneNode{"a3", "a"},
+		zoneNode{"b1", "b"}, zoneNode{"c1", "c"},
+	}
+	ring := newTestRing(t, nodes, SetVirtualNodes(20), SetReplicationFactor(3), RequireDistinctZones(3))
+	plain := newTestRing(t, nodes, SetVirtualNodes(20), SetReplicationFactor(3))
+
+	for _, key := range sampleKeys(500) {
+		got, err := ring.GetNodesForKey(key)
+		if err != nil {
+			t.Fatalf("GetNodesForKey(%q): %v", key, err)
+		}
+		primary, err := plain.GetPrimaryNode(key)
+		if err != nil {
+			t.Fatalf("GetPrimaryNode(%q): %v", key, err)
+		}
+		if got[0].GetIdentifier() != primary.GetIdentifier() {
+			t.Fatalf("key %q: zone spreading moved the primary from %s to %s", key, primary.GetIdentifier(), got[0].GetIdentifier())
+		}
+		if zones := len(zonesOf(got)); zones != 3 {
+			t.Fatalf("key %q placed on %v spanning %d zones, want 3", key, ids(got), zones)
+		}
+	}
+}
+
+func TestRequireDistinctZonesValidates(t *testing.T) {
+	for _, min := range []int{-1, 3} {
+		if _, err := InitHashRingE(SetReplicationFactor(2), RequireDistinctZones(min)); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("RequireDistinctZones(%d) with factor 2: got %v, want ErrInvalidConfig", min, err)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package replicationhashing
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"slices"
+	"testing"
+)
+
+type plainNode string
+
+func (n plainNode) GetIdentifier() string { return string(n) }
+
+// countdownHash is FNV-1a that fails the write that brings *left to zero.
+// Tests arm it by setting *left; it never fails while *left is zero.
+type countdownHash struct {
+	hash.Hash64
+	left *int
+}
+
+func (c countdownHash) Write(p []byte) (int, error) {
+	if *c.left > 0 {
+		*c.left--
+		if *c.left == 0 {
+			return 0, errors.New("hash failed")
+		}
+	}
+	return c.Hash64.Write(p)
+}
+
+func TestAddServerFailsOnNthHash(t *testing.T) {
+	const vnodes = 3
+	for n := 1; n <= vnodes; n++ {
+		t.Run(fmt.Sprintf("call %d", n), func(t *testing.T) {
+			var left int
+			ring := InitHashRing(SetVirtualNodes(vnodes), SetHashFunction(func() hash.Hash64 {
+				return countdownHash{Hash64: fnv.New64a(), left: &left}
+			}))
+			for _, id := range []string{"a", "b"} {
+				if err := ring.AddServer(plainNode(id)); err != nil {
+					t.Fatalf("AddServer(%s): %v", id, err)
+				}
+			}
+			owners := func() []string {
+				var got []string
+				for i := range 200 {
+					node, err := ring.GetServer(fmt.Sprintf("key-%d", i))
+					if err != nil {
+						t.Fatalf("GetServer: %v", err)
+					}
+					got = append(got, node.GetIdentifier())
+				}
+				return got
+			}
+			before := owners()
+			positions := slices.Clone(ring.positions)
+
+			left = n
+			if err := ring.AddServer(plainNode("c")); !errors.Is(err, ErrInHashingKey) {
+				t.Fatalf("AddServer: got %v, want ErrInHashingKey", err)
+			}
+
+			if !slices.Equal(ring.positions, positions) {
+				t.Fatal("positions changed by a failed add")
+			}
+			if _, ok := ring.hostMap.Load("c"); ok || ring.memberCount() != 2 {
+				t.Fatalf("failed add left c registered, %d members", ring.memberCount())
+			}
+			if got := owners(); !slices.Equal(got, before) {
+				t.Fatal("lookups changed after a failed add")
+			}
+
+			// a retry places every token, with nothing left over to collide with
+			if err := ring.AddServer(plainNode("c")); err != nil {
+				t.Fatalf("AddServer retry: %v", err)
+			}
+			if got := len(ring.positions); got != 3*vnodes {
+				t.Fatalf("%d positions after the retry, want %d", got, 3*vnodes)
+			}
+		})
+	}
+}
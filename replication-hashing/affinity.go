@@ -0,0 +1,18 @@
+package replicationhashing
+
+import "log"
+
+// GetServerForGroup co-locates related keys: every memberKey of a group is
+// placed on the node owning groupKey, regardless of how memberKey itself
+// would hash. memberKey only matters for logging.
+func (h *HashRing) GetServerForGroup(groupKey string, memberKey string) (ICacheNode, error) {
+	node, err := h.GetServer(groupKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.shouldLogLookup() {
+		log.Printf("[HashRing] Key '%s' follows group '%s' to node %s", memberKey, groupKey, node.GetIdentifier())
+	}
+	return node, nil
+}
@@ -0,0 +1,35 @@
+package replicationhashing
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGetServerForGroup(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c", "d"}, SetVirtualNodes(20))
+
+	spread := false
+	for g := range 50 {
+		group := fmt.Sprintf("cart:%d", g)
+		owner, err := ring.GetServer(group)
+		if err != nil {
+			t.Fatalf("GetServer(%q): %v", group, err)
+		}
+		for _, member := range []string{"items", "total", "coupon", "shipping"} {
+			memberKey := group + ":" + member
+			node, err := ring.GetServerForGroup(group, memberKey)
+			if err != nil {
+				t.Fatalf("GetServerForGroup(%q, %q): %v", group, memberKey, err)
+			}
+			if node.GetIdentifier() != owner.GetIdentifier() {
+				t.Fatalf("%s landed on %s, its group on %s", memberKey, node.GetIdentifier(), owner.GetIdentifier())
+			}
+			if own, _ := ring.GetServer(memberKey); own.GetIdentifier() != owner.GetIdentifier() {
+				spread = true
+			}
+		}
+	}
+	if !spread {
+		t.Fatal("every member key hashes to its group's node anyway, so the test proves nothing")
+	}
+}
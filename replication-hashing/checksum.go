@@ -0,0 +1,28 @@
+package replicationhashing
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+)
+
+// Checksum digests the token table, each token with the identifier of the
+// node owning it, so that rings built independently from the same membership
+// can confirm they place keys identically by comparing one number. Any add,
+// remove or weight change alters it. It always uses FNV-1a, not the ring's
+// hash function, and depends only on the layout, so it is stable across
+// processes.
+func (h *HashRing) Checksum() uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	sum := fnv.New64a()
+	var buf [8]byte
+	for _, p := range h.positions {
+		binary.BigEndian.PutUint64(buf[:], p.hash)
+		sum.Write(buf[:])
+		sum.Write([]byte(p.node.GetIdentifier()))
+		// separates identifiers so "ab"+"c" and "a"+"bc" differ
+		sum.Write([]byte{0})
+	}
+	return sum.Sum64()
+}
@@ -0,0 +1,57 @@
+package replicationhashing
+
+import "testing"
+
+func TestChecksum(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c"}, SetVirtualNodes(10))
+	base := ring.Checksum()
+
+	// the same membership added in another order agrees
+	if other := newTestRing(t, []string{"c", "a", "b"}, SetVirtualNodes(10)); other.Checksum() != base {
+		t.Fatal("rings with the same membership have different checksums")
+	}
+
+	// a fixed layout always digests to the same value, whichever process
+	// computes it
+	const golden uint64 = 17940527177879368350
+	if got := newTokenRing(t, map[string][]uint64{"a": {100, 300}, "b": {200}}).Checksum(); got != golden {
+		t.Errorf("Checksum() = %d, want %d", got, golden)
+	}
+
+	seen := map[uint64]string{base: "initial"}
+	change := func(what string, apply func() error) {
+		t.Helper()
+		if err := apply(); err != nil {
+			t.Fatalf("%s: %v", what, err)
+		}
+		sum := ring.Checksum()
+		if prev, dup := seen[sum]; dup {
+			t.Fatalf("checksum after %s equals the one after %s", what, prev)
+		}
+		seen[sum] = what
+	}
+	change("add", func() error { return ring.AddServer(testNode("d")) })
+	change("weight change", func() error { return ring.SetWeight("d", 2) })
+	change("remove", func() error { return ring.RemoveServerByID("a") })
+
+	if got := newTestRing(t, []string{"a", "b"}).Checksum(); got == newTestRing(t, []string{"a", "c"}).Checksum() {
+		t.Fatal("different memberships share a checksum")
+	}
+	if err := ring.AddServer(testNode("a")); err != nil {
+		t.Fatalf("AddServer: %v", err)
+	}
+	if err := ring.RemoveServerByID("d"); err != nil {
+		t.Fatalf("RemoveServerByID: %v", err)
+	}
+	if ring.Checksum() != base {
+		t.Fatal("restoring the membership did not restore the checksum")
+	}
+}
+
+func TestChecksumSeparatesIdentifiers(t *testing.T) {
+	a := newTokenRing(t, map[string][]uint64{"ab": {100}, "c": {200}})
+	b := newTokenRing(t, map[string][]uint64{"a": {100}, "bc": {200}})
+	if a.Checksum() == b.Checksum() {
+		t.Fatal("node identifiers run together in the checksum")
+	}
+}
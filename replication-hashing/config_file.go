@@ -0,0 +1,76 @@
+package replicationhashing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ringFile is the on-disk topology description read by LoadRingFromFile:
+//
+//	{
+//	  "virtualNodes": 100,
+//	  "nodes": [
+//	    {"id": "server-1"},
+//	    {"id": "server-2", "weight": 2, "meta": {"zone": "eu-1"}}
+//	  ]
+//	}
+//
+// weight defaults to 1; meta labels are matched by GetServerMatching.
+type ringFile struct {
+	VirtualNodes int `json:"virtualNodes"`
+	Nodes        []struct {
+		ID     string            `json:"id"`
+		Weight *float64          `json:"weight"`
+		Meta   map[string]string `json:"meta"`
+	} `json:"nodes"`
+}
+
+// LoadRingFromFile builds a ring from a JSON topology file. factory turns each
+// listed identifier into the node stored on the ring; opts are applied before
+// the file's own settings. The resulting configuration is validated as by
+// InitHashRingE.
+func LoadRingFromFile(path string, factory func(id string) ICacheNode, opts ...HashRingConfigFn) (*HashRing, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var spec ringFile
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("%w %s: %v", ErrInvalidRingFile, path, err)
+	}
+
+	if spec.VirtualNodes != 0 {
+		opts = append(opts, SetVirtualNodes(spec.VirtualNodes))
+	}
+	ring, err := InitHashRingE(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %w", ErrInvalidRingFile, path, err)
+	}
+
+	for i, n := range spec.Nodes {
+		if n.ID == "" {
+			return nil, fmt.Errorf("%w %s: node %d has no id", ErrInvalidRingFile, path, i)
+		}
+		weight := 1.0
+		if n.Weight != nil {
+			weight = *n.Weight
+		}
+		if err := checkWeight(weight, n.ID); err != nil {
+			return nil, fmt.Errorf("%w %s: %w", ErrInvalidRingFile, path, err)
+		}
+		node := factory(n.ID)
+		if node == nil {
+			return nil, fmt.Errorf("%w %s: factory returned no node for %s", ErrInvalidRingFile, path, n.ID)
+		}
+		if err := ring.addServer(node, weight, n.Meta); err != nil {
+			return nil, err
+		}
+	}
+
+	return ring, nil
+}
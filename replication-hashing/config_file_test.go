@@ -0,0 +1,85 @@
+package replicationhashing
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeRingFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ring.json")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func nodeFactory(id string) ICacheNode { return testNode(id) }
+
+func TestLoadRingFromFile(t *testing.T) {
+	path := writeRingFile(t, `{
+		"virtualNodes": 10,
+		"nodes": [
+			{"id": "server-1"},
+			{"id": "server-2", "weight": 2, "meta": {"zone": "eu-1"}}
+		]
+	}`)
+
+	ring, err := LoadRingFromFile(path, nodeFactory)
+	if err != nil {
+		t.Fatalf("LoadRingFromFile: %v", err)
+	}
+	if got := ring.VirtualNodes(); got != 10 {
+		t.Fatalf("VirtualNodes() = %d, want 10", got)
+	}
+
+	perNode := make(map[string]int)
+	for _, token := range ring.Tokens() {
+		perNode[token.NodeID]++
+	}
+	if perNode["server-1"] != 10 || perNode["server-2"] != 20 {
+		t.Fatalf("tokens per node %v, want server-1:10 server-2:20", perNode)
+	}
+
+	node, err := ring.GetServerMatching("any-key", map[string]string{"zone": "eu-1"})
+	if err != nil || node.GetIdentifier() != "server-2" {
+		t.Fatalf("GetServerMatching(zone=eu-1) = %v, %v; want server-2", node, err)
+	}
+}
+
+func TestLoadRingFromFileErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		factory  func(string) ICacheNode
+		want     error
+	}{
+		{"malformed", `{"nodes": [`, nodeFactory, ErrInvalidRingFile},
+		{"unknown field", `{"nodes": [{"id": "a", "rack": "r1"}]}`, nodeFactory, ErrInvalidRingFile},
+		{"invalid vnode count", `{"virtualNodes": -1, "nodes": [{"id": "a"}]}`, nodeFactory, ErrInvalidConfig},
+		{"missing id", `{"nodes": [{"weight": 1}]}`, nodeFactory, ErrInvalidRingFile},
+		{"zero weight", `{"nodes": [{"id": "a", "weight": 0}]}`, nodeFactory, ErrInvalidWeight},
+		{"duplicate id", `{"nodes": [{"id": "a"}, {"id": "a"}]}`, nodeFactory, ErrNodeExists},
+		{"nil node", `{"nodes": [{"id": "a"}]}`, func(string) ICacheNode { return nil }, ErrInvalidRingFile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring, err := LoadRingFromFile(writeRingFile(t, tt.contents), tt.factory)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if ring != nil {
+				t.Fatal("a ring was returned alongside the error")
+			}
+		})
+	}
+}
+
+func TestLoadRingFromFileMissing(t *testing.T) {
+	_, err := LoadRingFromFile(filepath.Join(t.TempDir(), "absent.json"), nodeFactory)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("got %v, want os.ErrNotExist", err)
+	}
+}
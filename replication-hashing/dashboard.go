@@ -0,0 +1,93 @@
+package replicationhashing
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+)
+
+// dashboardSampleKeys is the number of synthetic keys routed to draw the load
+// chart.
+const dashboardSampleKeys = 10000
+
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Hash ring</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+td, th { padding: 4px 12px; text-align: left; }
+.bar { background: #4a90d9; height: 14px; }
+</style>
+</head>
+<body>
+<h1>Hash ring</h1>
+<p>{{.Nodes}} nodes, {{.VirtualNodes}} positions, imbalance ratio {{printf "%.2f" .ImbalanceRatio}}</p>
+<table>
+<tr><th>Node</th><th>Virtual nodes</th><th>Sample keys</th><th>Load</th></tr>
+{{range .Rows}}<tr><td>{{.ID}}</td><td>{{.VirtualNodes}}</td><td>{{.Keys}}</td><td><div class="bar" style="width: {{.Width}}px"></div></td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+type dashboardRow struct {
+	ID           string
+	VirtualNodes int
+	Keys         int
+	Width        int
+}
+
+// NewDashboard returns a read-only HTML page describing ring: its nodes,
+// their virtual-node counts, the imbalance ratio and a bar chart of how a
+// fixed sample of synthetic keys spreads across nodes. It responds with 503
+// while the ring is empty.
+func NewDashboard(ring *HashRing) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		metrics := ring.CapacityMetrics()
+		if metrics.Nodes == 0 {
+			http.Error(w, ErrNoConnectedNodes.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
+		view := ring.FrozenView()
+		load := make(map[string]int)
+		for i := 0; i < dashboardSampleKeys; i++ {
+			if node, err := view.Get(fmt.Sprintf("sample-%d", i)); err == nil {
+				load[node.GetIdentifier()]++
+			}
+		}
+
+		rows := make([]dashboardRow, 0, metrics.Nodes)
+		ring.mu.RLock()
+		ring.hostMap.Range(func(key, val any) bool {
+			id := key.(string)
+			rows = append(rows, dashboardRow{
+				ID:           id,
+				VirtualNodes: len(val.(*member).tokens),
+				Keys:         load[id],
+				Width:        load[id] * 400 / dashboardSampleKeys,
+			})
+			return true
+		})
+		ring.mu.RUnlock()
+		sort.Slice(rows, func(i, j int) bool { return rows[i].ID < rows[j].ID })
+
+		// render fully before writing so a template failure can still be
+		// reported with a proper status
+		var page bytes.Buffer
+		if err := dashboardTemplate.Execute(&page, struct {
+			CapacityMetrics
+			Rows []dashboardRow
+		}{metrics, rows}); err != nil {
+			http.Error(w, "rendering dashboard: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		page.WriteTo(w)
+	})
+}
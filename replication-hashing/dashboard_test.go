@@ -0,0 +1,53 @@
+package replicationhashing
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serveDashboard(ring *HashRing) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	NewDashboard(ring).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	return rec
+}
+
+func TestDashboard(t *testing.T) {
+	ring := newTestRing(t, []string{"cache-eu", "cache-us"}, SetVirtualNodes(10))
+
+	rec := serveDashboard(ring)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Fatalf("content type %q", ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"cache-eu", "cache-us", "2 nodes, 20 positions"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page does not mention %q", want)
+		}
+	}
+}
+
+func TestDashboardEmptyRing(t *testing.T) {
+	if rec := serveDashboard(InitHashRing()); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status %d, want 503", rec.Code)
+	}
+}
+
+func TestDashboardRenderError(t *testing.T) {
+	saved := dashboardTemplate
+	t.Cleanup(func() { dashboardTemplate = saved })
+	dashboardTemplate = template.Must(template.New("broken").Parse(`<p>{{.Missing}}</p>`))
+
+	rec := serveDashboard(newTestRing(t, []string{"a"}))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status %d, want 500", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "<p>") {
+		t.Fatal("a partial page was written before the error")
+	}
+}
@@ -0,0 +1,68 @@
+package replicationhashing
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// describeMaxTokens caps how many tokens String prints.
+const describeMaxTokens = 20
+
+// String summarises the ring for debugging: counts, hash function, members
+// and the first tokens in ring order. The output is deterministic for a
+// given layout, so it can be diffed.
+func (h *HashRing) String() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	ids := h.memberIDs()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "HashRing{nodes: %d, tokens: %d, vnodes: %d, hash: %s}\n",
+		len(ids), len(h.positions), h.config.VirtualNodes, h.config.hashName())
+	fmt.Fprintf(&b, "  members: %s\n", strings.Join(ids, ", "))
+	for i, p := range h.positions {
+		if i == describeMaxTokens {
+			fmt.Fprintf(&b, "  … and %d more tokens\n", len(h.positions)-i)
+			break
+		}
+		fmt.Fprintf(&b, "  %20d %s\n", p.hash, p.node.GetIdentifier())
+	}
+	return b.String()
+}
+
+// VirtualNodes returns the number of virtual nodes a node of weight 1 gets.
+func (h *HashRing) VirtualNodes() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.config.VirtualNodes
+}
+
+// HashFunctionName returns the name given with SetHashFunctionNamed, or the
+// hash function's qualified symbol name otherwise.
+func (h *HashRing) HashFunctionName() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.config.hashName()
+}
+
+func (cfg *hashRingConfig) hashName() string {
+	if cfg.HashName != "" {
+		return cfg.HashName
+	}
+	return funcName(cfg.HashFunction)
+}
+
+// funcName returns the qualified name of a function value, or "<nil>".
+func funcName(f any) string {
+	v := reflect.ValueOf(f)
+	if !v.IsValid() || v.IsNil() {
+		return "<nil>"
+	}
+	if fn := runtime.FuncForPC(v.Pointer()); fn != nil {
+		return fn.Name()
+	}
+	return "<unknown>"
+}
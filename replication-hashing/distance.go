@@ -0,0 +1,41 @@
+package replicationhashing
+
+import "fmt"
+
+// NodesWithinDistance returns the distinct physical nodes that have a
+// virtual-node position at most maxDistance clockwise from key's hash,
+// nearest first. Distances wrap around the end of the ring.
+func (h *HashRing) NodesWithinDistance(key string, maxDistance uint64) ([]ICacheNode, error) {
+	if err := h.lockForLookup(); err != nil {
+		return nil, err
+	}
+	defer h.mu.RUnlock()
+
+	hashValue, err := h.hashKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w : %s", ErrInHashingKey, key)
+	}
+
+	positions := h.lookupPositions()
+	index, err := h.search(positions, hashValue)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{})
+	nodes := make([]ICacheNode, 0)
+	for i := 0; i < len(positions); i++ {
+		p := positions[(index+i)%len(positions)]
+		// unsigned subtraction yields the clockwise distance, wrap included
+		if p.hash-hashValue > maxDistance {
+			break
+		}
+		if _, dup := seen[p.node.GetIdentifier()]; dup {
+			continue
+		}
+		seen[p.node.GetIdentifier()] = struct{}{}
+		nodes = append(nodes, p.node)
+	}
+
+	return nodes, nil
+}
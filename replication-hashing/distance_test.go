@@ -0,0 +1,42 @@
+package replicationhashing
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNodesWithinDistance(t *testing.T) {
+	ring := newTokenRing(t, map[string][]uint64{
+		"a": {100, 400},
+		"b": {200},
+		"c": {300, 18446744073709551600},
+	})
+
+	tests := []struct {
+		name     string
+		key      string
+		distance uint64
+		want     []string
+	}{
+		{"nothing in reach", "101", 50, []string{}},
+		{"exact position", "200", 0, []string{"b"}},
+		{"nearest first", "150", 200, []string{"b", "c"}},
+		{"duplicate tokens count once", "50", 400, []string{"a", "b", "c"}},
+		{"wraps past the end", "18446744073709551590", 130, []string{"c", "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, err := ring.NodesWithinDistance(tt.key, tt.distance)
+			if err != nil {
+				t.Fatalf("NodesWithinDistance(%q, %d): %v", tt.key, tt.distance, err)
+			}
+			got := make([]string, 0, len(nodes))
+			for _, n := range nodes {
+				got = append(got, n.GetIdentifier())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("NodesWithinDistance(%q, %d) = %v, want %v", tt.key, tt.distance, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package replicationhashing
+
+// KeyMove is a key whose owner changes under a migration plan.
+type KeyMove struct {
+	Key  string
+	From string
+	To   string
+}
+
+// MigrationPlan describes what happens to a sample of keys when a set of
+// nodes is taken out of rotation.
+type MigrationPlan struct {
+	Moves     []KeyMove
+	Unchanged int
+	// Stranded lists keys left without any owner, e.g. because every node
+	// was drained or the ring is empty.
+	Stranded []string
+}
+
+// DrainNodes plans a simultaneous drain of the nodes in ids without touching
+// the ring: every key currently owned by one of them is reassigned to the
+// next node clockwise that isn't being drained, while all other keys stay
+// where they are. Unknown ids are ignored.
+func (h *HashRing) DrainNodes(ids []string, keys []string) MigrationPlan {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	drained := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		drained[id] = struct{}{}
+	}
+	remaining := func(n ICacheNode) bool {
+		_, ok := drained[n.GetIdentifier()]
+		return !ok
+	}
+
+	plan := MigrationPlan{Moves: make([]KeyMove, 0)}
+	for _, key := range keys {
+		hashValue, err := h.hashKey(key)
+		if err != nil {
+			plan.Stranded = append(plan.Stranded, key)
+			continue
+		}
+		from, _, err := h.ownerOf(hashValue, nil)
+		if err != nil {
+			plan.Stranded = append(plan.Stranded, key)
+			continue
+		}
+		if remaining(from) {
+			plan.Unchanged++
+			continue
+		}
+		to, _, err := h.ownerOf(hashValue, remaining)
+		if err != nil {
+			plan.Stranded = append(plan.Stranded, key)
+			continue
+		}
+		plan.Moves = append(plan.Moves, KeyMove{Key: key, From: from.GetIdentifier(), To: to.GetIdentifier()})
+	}
+
+	return plan
+}
@@ -0,0 +1,57 @@
+package replicationhashing
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDrainNodes(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c", "d", "e"}, SetVirtualNodes(20))
+	keys := sampleKeys(1000)
+	before := owners(t, ring, keys)
+
+	plan := ring.DrainNodes([]string{"b", "d", "unknown"}, keys)
+	if len(plan.Stranded) != 0 {
+		t.Fatalf("stranded keys: %v", plan.Stranded)
+	}
+
+	moved := make(map[string]string, len(plan.Moves))
+	for _, m := range plan.Moves {
+		if m.From != before[m.Key] {
+			t.Fatalf("key %q: plan moves it from %s, it is owned by %s", m.Key, m.From, before[m.Key])
+		}
+		if m.To == "b" || m.To == "d" {
+			t.Fatalf("key %q: plan moves it onto drained node %s", m.Key, m.To)
+		}
+		moved[m.Key] = m.To
+	}
+	for key, id := range before {
+		drained := id == "b" || id == "d"
+		if _, ok := moved[key]; ok != drained {
+			t.Fatalf("key %q on %s: moved=%v", key, id, ok)
+		}
+	}
+	if plan.Unchanged+len(plan.Moves) != len(keys) {
+		t.Fatalf("%d unchanged + %d moves, want %d keys", plan.Unchanged, len(plan.Moves), len(keys))
+	}
+
+	// the plan must predict what removing both nodes actually does
+	if err := ring.RemoveServersByID([]string{"b", "d"}); err != nil {
+		t.Fatalf("RemoveServersByID: %v", err)
+	}
+	after := owners(t, ring, keys)
+	for key, to := range moved {
+		if after[key] != to {
+			t.Fatalf("key %q: planned %s, removal sent it to %s", key, to, after[key])
+		}
+	}
+}
+
+func TestDrainNodesEverything(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b"})
+	keys := sampleKeys(20)
+	plan := ring.DrainNodes([]string{"a", "b"}, keys)
+	if !slices.Equal(plan.Stranded, keys) || len(plan.Moves) != 0 {
+		t.Fatalf("draining every node: %d moves, %d stranded; want all %d stranded", len(plan.Moves), len(plan.Stranded), len(keys))
+	}
+}
@@ -0,0 +1,41 @@
+package replicationhashing
+
+import (
+	"log"
+	"slices"
+)
+
+// GC removes positions whose physical node is no longer registered or that
+// hold no node at all, which repairs rings corrupted by older versions. It returns the number of
+// positions reclaimed.
+func (h *HashRing) GC() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	before := len(h.positions)
+	h.positions = slices.DeleteFunc(h.positions, func(p position) bool {
+		if p.node == nil {
+			return true
+		}
+		_, ok := h.hostMap.Load(p.node.GetIdentifier())
+		return !ok
+	})
+	reclaimed := before - len(h.positions)
+
+	if h.config.EnableLogs && reclaimed > 0 {
+		log.Printf("[HashRing] GC reclaimed %d orphaned positions", reclaimed)
+	}
+
+	return reclaimed
+}
+
+// Compact reallocates the position slice to exactly its length, releasing
+// capacity left behind by repeated adds and removes. Routing is unaffected.
+func (h *HashRing) Compact() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	compacted := make([]position, len(h.positions))
+	copy(compacted, h.positions)
+	h.positions = compacted
+}
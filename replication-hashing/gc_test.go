@@ -0,0 +1,101 @@
+package replicationhashing
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+)
+
+// injectOrphans places positions for a node that isn't registered, the way
+// older removal bugs left them behind.
+func injectOrphans(ring *HashRing, id string, tokens ...uint64) {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	orphans := make([]position, len(tokens))
+	for i, token := range tokens {
+		orphans[i] = position{hash: token, node: testNode(id), vnode: i}
+	}
+	ring.positions = insertPositions(ring.positions, orphans)
+}
+
+func TestGCRemovesExactlyOrphans(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b"}, SetVirtualNodes(10))
+	keys := sampleKeys(300)
+	want := owners(t, ring, keys)
+	tokens := ring.Tokens()
+
+	injectOrphans(ring, "ghost", 1, 1<<40, 1<<63)
+	if got := ring.VirtualNodeCount(); got != 23 {
+		t.Fatalf("%d positions after injecting, want 23", got)
+	}
+
+	if reclaimed := ring.GC(); reclaimed != 3 {
+		t.Fatalf("GC reclaimed %d, want 3", reclaimed)
+	}
+	if !slices.Equal(ring.Tokens(), tokens) || ring.VirtualNodeCount() != len(tokens) {
+		t.Fatal("GC touched positions of registered nodes")
+	}
+	if !maps.Equal(owners(t, ring, keys), want) {
+		t.Fatal("routing differs from before the corruption")
+	}
+	if reclaimed := ring.GC(); reclaimed != 0 {
+		t.Fatalf("second GC reclaimed %d, want 0", reclaimed)
+	}
+}
+
+func TestGCRemovesNilNodes(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b"}, SetVirtualNodes(10))
+	tokens := ring.Tokens()
+
+	// positions without a node can't be ordered by identifier, so place
+	// them by hash alone
+	ring.mu.Lock()
+	for _, token := range []uint64{7, 1 << 50} {
+		i, _ := slices.BinarySearchFunc(ring.positions, token, func(p position, h uint64) int {
+			return cmp.Compare(p.hash, h)
+		})
+		ring.positions = slices.Insert(ring.positions, i, position{hash: token})
+	}
+	ring.mu.Unlock()
+	injectOrphans(ring, "ghost", 1<<60)
+
+	if reclaimed := ring.GC(); reclaimed != 3 {
+		t.Fatalf("GC reclaimed %d, want 3", reclaimed)
+	}
+	if !slices.Equal(ring.Tokens(), tokens) {
+		t.Fatal("GC touched positions of registered nodes")
+	}
+}
+
+func TestCompact(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c", "d"}, SetVirtualNodes(50))
+	for i := range 20 {
+		id := fmt.Sprintf("tmp-%d", i)
+		if err := ring.AddServer(testNode(id)); err != nil {
+			t.Fatalf("AddServer(%s): %v", id, err)
+		}
+		if i%2 == 0 {
+			if err := ring.RemoveServerByID(id); err != nil {
+				t.Fatalf("RemoveServerByID(%s): %v", id, err)
+			}
+		}
+	}
+	keys := sampleKeys(1000)
+	before := owners(t, ring, keys)
+	layout := slices.Clone(ring.positions)
+
+	ring.Compact()
+
+	if got := ring.positions; cap(got) != len(got) {
+		t.Fatalf("capacity %d after Compact, want the length %d", cap(got), len(got))
+	}
+	if !slices.Equal(ring.positions, layout) {
+		t.Fatal("Compact changed the layout")
+	}
+	if got := owners(t, ring, keys); !maps.Equal(got, before) {
+		t.Fatal("Compact changed routing")
+	}
+}
@@ -1,21 +1,45 @@
 package replicationhashing
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
 	"hash"
 	"hash/fnv"
 	"log"
+	"maps"
 	"slices"
 	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 var (
-	ErrNoConnectedNodes = errors.New("no connected nodes available")
-	ErrNodeExists       = errors.New("node already exists")
-	ErrNodeNotFound     = errors.New("node not found")
-	ErrInHashingKey     = errors.New("error in hashing the key")
+	ErrNoConnectedNodes  = errors.New("no connected nodes available")
+	ErrNodeExists        = errors.New("node already exists")
+	ErrNodeNotFound      = errors.New("node not found")
+	ErrInHashingKey      = errors.New("error in hashing the key")
+	ErrNilPredicate      = errors.New("predicate must not be nil")
+	ErrRingPaused        = errors.New("ring is paused for reconfiguration")
+	ErrNilHashFunction   = errors.New("hash function must not be nil")
+	ErrRingNotEmpty      = errors.New("ring is not empty")
+	ErrInvalidRingFile   = errors.New("invalid ring config file")
+	ErrWouldEmptyRing    = errors.New("removal would leave the ring empty")
+	ErrTooManyVNodes     = errors.New("virtual node limit exceeded")
+	ErrInvalidWindow     = errors.New("window size must be positive")
+	ErrTargetNotReached  = errors.New("optimization target not reached")
+	ErrTokenCollision    = errors.New("could not find a free token")
+	ErrInvalidConfig     = errors.New("invalid ring configuration")
+	ErrInvalidCount      = errors.New("requested node count must be at least 1")
+	ErrInsufficientNodes = errors.New("fewer distinct nodes than requested")
+	ErrNoMatchingNodes   = errors.New("no node matches the selector")
+	ErrNoHealthyNodes    = errors.New("no healthy nodes available")
+	ErrTokenInUse        = errors.New("token already in use")
+	ErrNoTokens          = errors.New("at least one token is required")
+	ErrInvalidWeight     = errors.New("weight must be positive")
+	ErrPinnedTokens      = errors.New("node was added with explicit tokens")
+	ErrNilFactory        = errors.New("node factory must not be nil")
 )
 
 type ICacheNode interface {
@@ -23,22 +47,81 @@ type ICacheNode interface {
 }
 
 type hashRingConfig struct {
-	VirtualNodes int
-	HashFunction func() hash.Hash64
-	EnableLogs   bool
+	VirtualNodes     int
+	HashFunction     func() hash.Hash64
+	HashName         string
+	EnableLogs       bool
+	TrackSearchDepth bool
+	PausePolicy      PausePolicy
+	ExpectedNodes    int
+	LogSampling      int
+	PreventEmptyRing bool
+	OperationLog     bool
+	MaxTotalVNodes   int
+	DefaultNode      ICacheNode
+	JoinStep         int
+	JoinInterval     time.Duration
+	HashTags         bool
+	KeyNormalizer    func(string) string
+	Namespace        string
+	SeedNodes        []ICacheNode
 }
 
 type HashRingConfigFn func(*hashRingConfig)
 
+// position is a single virtual-node token on the ring. Keeping the hash and
+// its node side by side lets a lookup resolve the owner from the same
+// contiguous slice it binary-searches.
+type position struct {
+	hash  uint64
+	node  ICacheNode
+	vnode int // index of the virtual node that produced hash
+}
+
+// member is the bookkeeping kept for each physical node.
+type member struct {
+	node   ICacheNode
+	tokens []uint64 // token actually placed for each virtual node, by index
+	pinned bool     // tokens were supplied by the caller, never rederived
+	weight float64  // multiplier applied to VirtualNodes, 1 for AddServer
+	target int      // virtual nodes a gradual join is growing to, 0 once settled
+	meta   map[string]string
+}
+
+func comparePositions(a, b position) int {
+	if c := cmp.Compare(a.hash, b.hash); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.node.GetIdentifier(), b.node.GetIdentifier())
+}
+
 func SetVirtualNodes(count int) HashRingConfigFn {
 	return func(cfg *hashRingConfig) {
 		cfg.VirtualNodes = count
 	}
 }
 
+// SetExpectedNodes pre-sizes the ring for n physical nodes so bulk adds don't
+// repeatedly grow the sorted key slice.
+func SetExpectedNodes(n int) HashRingConfigFn {
+	return func(cfg *hashRingConfig) {
+		cfg.ExpectedNodes = n
+	}
+}
+
 func SetHashFunction(f func() hash.Hash64) HashRingConfigFn {
 	return func(config *hashRingConfig) {
 		config.HashFunction = f
+		config.HashName = ""
+	}
+}
+
+// SetHashFunctionNamed is SetHashFunction with a display name reported by
+// HashFunctionName and String in place of the function's symbol.
+func SetHashFunctionNamed(name string, f func() hash.Hash64) HashRingConfigFn {
+	return func(config *hashRingConfig) {
+		config.HashFunction = f
+		config.HashName = name
 	}
 }
 
@@ -48,15 +131,101 @@ func EnableVerboseLogs(enabled bool) HashRingConfigFn {
 	}
 }
 
+// SetLogSampling limits verbose per-lookup logs to one line in every oneInN
+// lookups, so EnableVerboseLogs stays usable under heavy traffic. Topology
+// changes are always logged.
+func SetLogSampling(oneInN int) HashRingConfigFn {
+	return func(config *hashRingConfig) {
+		config.LogSampling = oneInN
+	}
+}
+
+// SetMaxTotalVirtualNodes caps the number of positions on the ring; adds
+// that would exceed it fail with ErrTooManyVNodes. Each position costs a few
+// dozen bytes and lookups stay O(log n), so tens of millions of positions are
+// workable, but a typo such as SetVirtualNodes(1_000_000) on a large fleet is
+// better rejected up front. Zero means no limit.
+func SetMaxTotalVirtualNodes(max int) HashRingConfigFn {
+	return func(config *hashRingConfig) {
+		config.MaxTotalVNodes = max
+	}
+}
+
+// SetDefaultNode sets a node GetServer falls back to when the ring has
+// positions but none of them resolves to a usable node, instead of failing
+// with ErrNodeNotFound. It is not used for an empty ring.
+func SetDefaultNode(node ICacheNode) HashRingConfigFn {
+	return func(config *hashRingConfig) {
+		config.DefaultNode = node
+	}
+}
+
+// WithNodes adds nodes to the ring as it is constructed. InitHashRingE fails
+// if any of them can't be added; InitHashRing skips those.
+func WithNodes(nodes ...ICacheNode) HashRingConfigFn {
+	return func(config *hashRingConfig) {
+		config.SeedNodes = append(config.SeedNodes, nodes...)
+	}
+}
+
+// SetNamespace folds name into every virtual node's hash input, giving rings
+// that share node identifiers independent token layouts. It is fixed for the
+// ring's lifetime, since changing it would move every token.
+func SetNamespace(name string) HashRingConfigFn {
+	return func(config *hashRingConfig) {
+		config.Namespace = name
+	}
+}
+
+// SetPreventEmptyRing makes removals fail with ErrWouldEmptyRing instead of
+// removing the last node, since an empty ring fails every lookup.
+func SetPreventEmptyRing(enabled bool) HashRingConfigFn {
+	return func(config *hashRingConfig) {
+		config.PreventEmptyRing = enabled
+	}
+}
+
 type HashRing struct {
-	mu                sync.RWMutex
-	config            hashRingConfig
-	hostMap           sync.Map // nodeId -> timeAdded
-	vNodeMap          sync.Map // hash -> node
-	sortedKeysOfNodes []uint64 // sorted hash values (includes virtual nodes)
+	mu           sync.RWMutex
+	config       hashRingConfig
+	hostMap      sync.Map   // nodeId -> *member
+	positions    []position // sorted by hash (includes virtual nodes)
+	depth        searchDepthStats
+	resumed      chan struct{} // non-nil while paused, closed on Resume
+	pausedView   *RingView     // layout served while paused, if the policy asks for it
+	emptyLookups atomic.Uint64
+	lookupLogs   atomic.Uint64
+	oplog        []Operation
+	opSeq        uint64 // sequence number of the last recorded operation
+	appliedSeq   uint64 // last sequence number replayed by ApplyFrom
+	lastChange   Operation
+	load         sync.Map            // nodeId -> *atomic.Uint64, keys routed by GetServerWithLoad
+	down         map[string]struct{} // nodes skipped by lookups, see MarkDown
+	pins         map[string]string   // key -> nodeId overrides, see PinKey
+	loadTotal    atomic.Uint64
 }
 
 func InitHashRing(opts ...HashRingConfigFn) *HashRing {
+	ring := newHashRing(opts...)
+	ring.seed()
+	return ring
+}
+
+// InitHashRingE is InitHashRing but rejects nonsensical options instead of
+// building a ring that fails later. Every problem found is reported. A seed
+// node from WithNodes that can't be added fails construction as well.
+func InitHashRingE(opts ...HashRingConfigFn) (*HashRing, error) {
+	ring := newHashRing(opts...)
+	if err := ring.config.validate(); err != nil {
+		return nil, err
+	}
+	if err := ring.seed(); err != nil {
+		return nil, err
+	}
+	return ring, nil
+}
+
+func newHashRing(opts ...HashRingConfigFn) *HashRing {
 	config := &hashRingConfig{
 		HashFunction: fnv.New64a,
 		VirtualNodes: 3,
@@ -66,122 +235,656 @@ func InitHashRing(opts ...HashRingConfigFn) *HashRing {
 		opt(config)
 	}
 
+	capacity := 0
+	if config.ExpectedNodes > 0 && config.VirtualNodes > 0 {
+		capacity = config.ExpectedNodes * config.VirtualNodes
+	}
+
 	return &HashRing{
-		config:            *config,
-		sortedKeysOfNodes: make([]uint64, 0),
+		config:    *config,
+		positions: make([]position, 0, capacity),
 	}
 }
 
+// seed adds the nodes given with WithNodes in one batch.
+func (h *HashRing) seed() error {
+	nodes := h.config.SeedNodes
+	h.config.SeedNodes = nil
+	if len(nodes) == 0 {
+		return nil
+	}
+	return h.AddServers(nodes)
+}
+
+func (cfg *hashRingConfig) validate() error {
+	var errs []error
+	if cfg.VirtualNodes < 1 {
+		errs = append(errs, fmt.Errorf("%w: virtual nodes must be at least 1, got %d", ErrInvalidConfig, cfg.VirtualNodes))
+	}
+	if cfg.HashFunction == nil {
+		errs = append(errs, fmt.Errorf("%w: %w", ErrInvalidConfig, ErrNilHashFunction))
+	}
+	if cfg.ExpectedNodes < 0 {
+		errs = append(errs, fmt.Errorf("%w: expected nodes must not be negative, got %d", ErrInvalidConfig, cfg.ExpectedNodes))
+	}
+	if cfg.LogSampling < 0 {
+		errs = append(errs, fmt.Errorf("%w: log sampling must not be negative, got %d", ErrInvalidConfig, cfg.LogSampling))
+	}
+	if cfg.JoinStep > 0 && cfg.JoinInterval <= 0 {
+		errs = append(errs, fmt.Errorf("%w: gradual join interval must be positive, got %s", ErrInvalidConfig, cfg.JoinInterval))
+	}
+	if cfg.MaxTotalVNodes < 0 {
+		errs = append(errs, fmt.Errorf("%w: virtual node limit must not be negative, got %d", ErrInvalidConfig, cfg.MaxTotalVNodes))
+	} else if cfg.MaxTotalVNodes > 0 && cfg.MaxTotalVNodes < cfg.VirtualNodes {
+		errs = append(errs, fmt.Errorf("%w: virtual node limit %d is below the %d needed for one node",
+			ErrInvalidConfig, cfg.MaxTotalVNodes, cfg.VirtualNodes))
+	}
+	return errors.Join(errs...)
+}
+
 func (h *HashRing) AddServer(node ICacheNode) error {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
+	// hash every virtual node before storing anything so a failure part way
+	// through leaves the ring untouched
+	m, err := h.placeMember(node, 1, 0, h.tokenTaken)
+	if err != nil {
+		return err
+	}
+	h.commitMembers([]*member{m})
+	return nil
+}
+
+// AddServerWithTokens adds node at exactly the given tokens instead of
+// derived virtual-node hashes, e.g. to match placement coordinated by an
+// external control plane. A token repeated in the call or already on the
+// ring fails the whole add with ErrTokenInUse.
+func (h *HashRing) AddServerWithTokens(node ICacheNode, tokens []uint64) error {
+	if len(tokens) == 0 {
+		return fmt.Errorf("%w : %s", ErrNoTokens, node.GetIdentifier())
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	nodeId := node.GetIdentifier()
 	if _, exists := h.hostMap.Load(nodeId); exists {
 		return fmt.Errorf("%w : %s", ErrNodeExists, nodeId)
 	}
+	if max := h.config.MaxTotalVNodes; max > 0 && len(h.positions)+len(tokens) > max {
+		return fmt.Errorf("%w: adding %s would need %d positions, limit is %d",
+			ErrTooManyVNodes, nodeId, len(h.positions)+len(tokens), max)
+	}
 
-	virtualKeys := make([]uint64, 0, h.config.VirtualNodes)
-	for i := 0; i < h.config.VirtualNodes; i++ {
-		vNodeId := fmt.Sprintf("%s_%d", nodeId, i)
-		hash, err := h.generateHash(vNodeId)
-		if err != nil {
-			return fmt.Errorf("%w for virtual node %s", ErrInHashingKey, vNodeId)
+	seen := make(map[uint64]struct{}, len(tokens))
+	for _, token := range tokens {
+		if _, dup := seen[token]; dup {
+			return fmt.Errorf("%w: %d given twice for %s", ErrTokenInUse, token, nodeId)
 		}
-		h.vNodeMap.Store(hash, node)
-		virtualKeys = append(virtualKeys, hash)
-
-		if h.config.EnableLogs {
-			log.Printf("[HashRing] Added virtual node %s -> hash %d", vNodeId, hash)
+		if h.tokenTaken(token) {
+			return fmt.Errorf("%w: %d is already on the ring, cannot add %s", ErrTokenInUse, token, nodeId)
 		}
+		seen[token] = struct{}{}
 	}
 
-	h.hostMap.Store(nodeId, struct{}{})
-	h.sortedKeysOfNodes = append(h.sortedKeysOfNodes, virtualKeys...)
-	slices.Sort(h.sortedKeysOfNodes)
-
-	if h.config.EnableLogs {
-		log.Printf("[HashRing] Node %s added with %d virtual nodes", nodeId, h.config.VirtualNodes)
-	}
-
+	h.commitMembers([]*member{{node: node, tokens: slices.Clone(tokens), pinned: true}})
 	return nil
-
 }
 
-func (h *HashRing) RemoveServer(node ICacheNode) error {
+// AddServers adds many nodes with a single merge into the ring, which is much
+// cheaper than repeated AddServer calls when hydrating a large fleet. Nodes
+// that can't be added (duplicates, hash failures, the virtual node limit) are
+// skipped and named in the joined error; the rest are still added.
+func (h *HashRing) AddServers(nodes []ICacheNode) error {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
+	_, err := h.addMembers(nodes)
+	return err
+}
+
+// addMembers places and commits nodes as one batch, returning the
+// identifiers that were added and a joined error for the rest. Callers must
+// hold h.mu.
+func (h *HashRing) addMembers(nodes []ICacheNode) ([]string, error) {
+	var (
+		added   []*member
+		pending int
+		errs    []error
+	)
+	batchIDs := make(map[string]struct{}, len(nodes))
+	batch := make(map[uint64]struct{})
+	taken := func(hash uint64) bool {
+		_, ok := batch[hash]
+		return ok || h.tokenTaken(hash)
+	}
+	for _, node := range nodes {
+		nodeId := node.GetIdentifier()
+		if _, dup := batchIDs[nodeId]; dup {
+			errs = append(errs, fmt.Errorf("%w : %s", ErrNodeExists, nodeId))
+			continue
+		}
+		m, err := h.placeMember(node, 1, pending, taken)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		batchIDs[nodeId] = struct{}{}
+		for _, token := range m.tokens {
+			batch[token] = struct{}{}
+		}
+		pending += len(m.tokens)
+		added = append(added, m)
+	}
+
+	h.commitMembers(added)
+
+	ids := make([]string, len(added))
+	for i, m := range added {
+		ids[i] = m.node.GetIdentifier()
+	}
+	return ids, errors.Join(errs...)
+}
+
+// placeMember checks that node can join with the given weight and picks its
+// tokens without touching the ring. pending counts positions already claimed
+// by the same batch. Callers must hold h.mu.
+func (h *HashRing) placeMember(node ICacheNode, weight float64, pending int, taken func(uint64) bool) (*member, error) {
 	nodeId := node.GetIdentifier()
 	if _, exists := h.hostMap.Load(nodeId); exists {
-		return fmt.Errorf("%w : %s", ErrNodeExists, nodeId)
+		return nil, fmt.Errorf("%w : %s", ErrNodeExists, nodeId)
+	}
+
+	count := h.vnodesFor(weight)
+	total := len(h.positions) + pending + count
+	if max := h.config.MaxTotalVNodes; max > 0 && total > max {
+		return nil, fmt.Errorf("%w: adding %s would need %d positions, limit is %d",
+			ErrTooManyVNodes, nodeId, total, max)
+	}
+
+	target := 0
+	if h.gradualJoin() && count > h.config.JoinStep {
+		target, count = count, h.config.JoinStep
 	}
 
-	for i := 0; i < h.config.VirtualNodes; i++ {
-		vNodeId := fmt.Sprintf("%s_%d", nodeId, i)
-		hash, err := h.generateHash(vNodeId)
+	tokens := make([]uint64, 0, count)
+	own := make(map[uint64]struct{}, count)
+	for i := 0; i < count; i++ {
+		hash, err := h.placeVNode(nodeId, i, func(hash uint64) bool {
+			_, ok := own[hash]
+			return ok || taken(hash)
+		})
 		if err != nil {
-			return fmt.Errorf("%w for virtual node %s", ErrInHashingKey, vNodeId)
+			return nil, err
+		}
+		own[hash] = struct{}{}
+		tokens = append(tokens, hash)
+	}
+	return &member{node: node, tokens: tokens, weight: weight, target: target}, nil
+}
+
+// commitMembers stores placed members and merges their tokens into the ring
+// in one pass. Callers must hold h.mu.
+func (h *HashRing) commitMembers(members []*member) {
+	var added []position
+	for _, m := range members {
+		nodeId := m.node.GetIdentifier()
+		h.hostMap.Store(nodeId, m)
+		op := Operation{Kind: OpAdd, NodeID: nodeId, Weight: m.weight, Meta: maps.Clone(m.meta)}
+		if m.pinned {
+			op.Tokens = slices.Clone(m.tokens)
+		}
+		h.recordOp(op)
+		for i, token := range m.tokens {
+			added = append(added, position{hash: token, node: m.node, vnode: i})
+			if h.config.EnableLogs {
+				log.Printf("[HashRing] Added virtual node %s_%d -> hash %d", nodeId, i, token)
+			}
 		}
-		h.vNodeMap.Delete(hash)
 
-		index := slices.Index(h.sortedKeysOfNodes, hash)
-		if index >= 0 {
-			h.sortedKeysOfNodes = append(h.sortedKeysOfNodes[:index], h.sortedKeysOfNodes[index+1:]...)
+		if m.target > 0 {
+			go h.continueJoin(nodeId, m)
 		}
 
 		if h.config.EnableLogs {
-			log.Printf("[HashRing] Removed node: %s (hash: %d)", vNodeId, hash)
+			log.Printf("[HashRing] Node %s added with %d virtual nodes", nodeId, len(m.tokens))
 		}
+	}
+	if len(added) > 0 {
+		h.positions = insertPositions(h.positions, added)
+	}
+}
+
+func (h *HashRing) RemoveServer(node ICacheNode) error {
+	return h.RemoveServerByID(node.GetIdentifier())
+}
+
+// RemoveServerByID removes the node registered under nodeId, for callers
+// that no longer hold the node itself.
+func (h *HashRing) RemoveServerByID(nodeId string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	val, exists := h.hostMap.Load(nodeId)
+	if !exists {
+		return fmt.Errorf("%w : %s", ErrNodeNotFound, nodeId)
+	}
 
+	if h.config.PreventEmptyRing && h.memberCount() == 1 {
+		return fmt.Errorf("%w : %s", ErrWouldEmptyRing, nodeId)
 	}
 
+	// drop every position owned by the node in one pass rather than
+	// re-deriving vnode hashes, so nothing is left behind even if the
+	// positions were placed differently
+	before := len(h.positions)
+	h.positions = slices.DeleteFunc(h.positions, func(p position) bool {
+		return p.node.GetIdentifier() == nodeId
+	})
+
 	h.hostMap.Delete(nodeId)
+	h.forgetLoad(nodeId)
+	delete(h.down, nodeId)
+	h.recordOp(Operation{Kind: OpRemove, NodeID: nodeId})
+
+	if h.config.EnableLogs {
+		log.Printf("[HashRing] Removed node: %s (%d of %d virtual nodes)", nodeId, before-len(h.positions), len(val.(*member).tokens))
+	}
+
 	return nil
 }
 
-func (h *HashRing) GetServer(key string) (ICacheNode, error) {
+// RemoveServersWhere removes every node whose identifier satisfies pred,
+// rebuilding the position slice in a single pass. The removed identifiers are
+// returned in sorted order.
+func (h *HashRing) RemoveServersWhere(pred func(id string) bool) ([]string, error) {
+	if pred == nil {
+		return nil, ErrNilPredicate
+	}
+
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	hashValue, err := h.generateHash(key)
+	doomed := make(map[string]struct{})
+	h.hostMap.Range(func(key, _ any) bool {
+		if id := key.(string); pred(id) {
+			doomed[id] = struct{}{}
+		}
+		return true
+	})
+
+	removed, err := h.removeMembers(doomed)
+	if err != nil {
+		return removed, err
+	}
+
+	if h.config.EnableLogs && len(removed) > 0 {
+		log.Printf("[HashRing] Removed %d nodes matching predicate: %v", len(removed), removed)
+	}
+
+	return removed, nil
+}
+
+// RemoveServers removes many nodes with a single pass over the ring, e.g.
+// when a whole zone goes away. Nodes that aren't on the ring are named in the
+// joined ErrNodeNotFound error; the others are still removed.
+func (h *HashRing) RemoveServers(nodes []ICacheNode) error {
+	ids := make([]string, len(nodes))
+	for i, node := range nodes {
+		ids[i] = node.GetIdentifier()
+	}
+	return h.RemoveServersByID(ids)
+}
+
+// RemoveServersByID is RemoveServers for callers that only hold identifiers.
+func (h *HashRing) RemoveServersByID(ids []string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var errs []error
+	doomed := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		if _, ok := h.hostMap.Load(id); !ok {
+			errs = append(errs, fmt.Errorf("%w : %s", ErrNodeNotFound, id))
+			continue
+		}
+		doomed[id] = struct{}{}
+	}
+
+	removed, err := h.removeMembers(doomed)
+	if err != nil {
+		return err
+	}
+
+	if h.config.EnableLogs && len(removed) > 0 {
+		log.Printf("[HashRing] Removed %d nodes: %v", len(removed), removed)
+	}
+
+	return errors.Join(errs...)
+}
+
+// removeMembers drops every node in doomed from the ring in one pass and
+// returns their identifiers sorted. It refuses to empty the ring when
+// PreventEmptyRing is set. Callers must hold h.mu.
+func (h *HashRing) removeMembers(doomed map[string]struct{}) ([]string, error) {
+	removed := make([]string, 0, len(doomed))
+	if len(doomed) == 0 {
+		return removed, nil
+	}
+	if h.config.PreventEmptyRing && len(doomed) == h.memberCount() {
+		return removed, ErrWouldEmptyRing
+	}
+
+	h.positions = slices.DeleteFunc(h.positions, func(p position) bool {
+		_, drop := doomed[p.node.GetIdentifier()]
+		return drop
+	})
+
+	for id := range doomed {
+		h.hostMap.Delete(id)
+		removed = append(removed, id)
+	}
+	slices.Sort(removed)
+	for _, id := range removed {
+		h.forgetLoad(id)
+		delete(h.down, id)
+		h.recordOp(Operation{Kind: OpRemove, NodeID: id})
+	}
+	return removed, nil
+}
+
+func (h *HashRing) GetServer(key string) (ICacheNode, error) {
+	if err := h.lockForLookup(); err != nil {
+		return nil, err
+	}
+	defer h.mu.RUnlock()
+	return h.serverForKey(key)
+}
+
+// serverForKey is GetServer without the locking. Callers must hold h.mu.
+func (h *HashRing) serverForKey(key string) (ICacheNode, error) {
+	if node, ok := h.pinnedServer(key); ok {
+		return node, nil
+	}
+
+	hashValue, err := h.hashKey(key)
 	if err != nil {
 		return nil, fmt.Errorf("%w : %s", ErrInHashingKey, key)
 	}
 
-	//performs a binary search on sortedKeyOfNodes
-	index, err := h.search(hashValue)
+	node, nodeHash, err := h.serverFor(hashValue)
 	if err != nil {
+		if errors.Is(err, ErrNodeNotFound) {
+			return nil, fmt.Errorf("%w: no node found for key %s", ErrNodeNotFound, key)
+		}
 		return nil, err
 	}
 
-	nodeHash := h.sortedKeysOfNodes[index]
-	if node, ok := h.vNodeMap.Load(nodeHash); ok {
-		if h.config.EnableLogs {
-			log.Printf("[HashRing] Key '%s' (hash: %d) mapped to node (hash:%d)", key, hashValue, nodeHash)
+	if h.shouldLogLookup() {
+		log.Printf("[HashRing] Key '%s' (hash: %d) mapped to node (hash:%d)", key, hashValue, nodeHash)
+	}
+	return node, nil
+}
+
+// GetServerBytes is GetServer for keys held as bytes, hashing them without
+// converting to a string first.
+func (h *HashRing) GetServerBytes(key []byte) (ICacheNode, error) {
+	if err := h.lockForLookup(); err != nil {
+		return nil, err
+	}
+	defer h.mu.RUnlock()
+
+	if len(h.pins) > 0 {
+		if node, ok := h.pinnedServer(string(key)); ok {
+			return node, nil
+		}
+	}
+
+	hashValue, err := h.hashKeyBytes(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w : %s", ErrInHashingKey, key)
+	}
+
+	node, nodeHash, err := h.serverFor(hashValue)
+	if err != nil {
+		if errors.Is(err, ErrNodeNotFound) {
+			return nil, fmt.Errorf("%w: no node found for key %s", ErrNodeNotFound, key)
+		}
+		return nil, err
+	}
+
+	if h.shouldLogLookup() {
+		log.Printf("[HashRing] Key '%s' (hash: %d) mapped to node (hash:%d)", key, hashValue, nodeHash)
+	}
+	return node, nil
+}
+
+// GetServerByHash is GetServer for callers that already hashed the key, e.g.
+// a routing token received over the wire. hash is used as the ring position
+// as is and its owner is found by the same clockwise walk, so for an
+// unpinned key it agrees with GetServer. Callers with their own hashing
+// scheme can use it to place any uint64.
+func (h *HashRing) GetServerByHash(hash uint64) (ICacheNode, error) {
+	if err := h.lockForLookup(); err != nil {
+		return nil, err
+	}
+	defer h.mu.RUnlock()
+
+	node, _, err := h.serverFor(hash)
+	if err != nil {
+		if errors.Is(err, ErrNodeNotFound) {
+			return nil, fmt.Errorf("%w: no node found for hash %d", ErrNodeNotFound, hash)
+		}
+		return nil, err
+	}
+	return node, nil
+}
+
+// OwnerOfHash returns the physical node owning ring position hash, for
+// callers that hash keys with their own scheme and only want placement. The
+// input is a point on the ring rather than a key's digest, but the lookup is
+// the same clockwise walk, so OwnerOfHash and GetServerByHash always agree
+// and both agree with GetServer for an unpinned key hashing to that point.
+func (h *HashRing) OwnerOfHash(hash uint64) (ICacheNode, error) {
+	return h.GetServerByHash(hash)
+}
+
+// serverFor resolves a key hash the way GetServer does, skipping nodes marked
+// down, counting lookups on an empty ring and falling back to the default
+// node. Callers must hold h.mu.
+func (h *HashRing) serverFor(hashValue uint64) (ICacheNode, uint64, error) {
+	p, _, err := h.resolvePosition(hashValue)
+	if err != nil {
+		return nil, 0, err
+	}
+	return p.node, p.hash, nil
+}
+
+// resolvePosition is serverFor returning the whole matched position.
+// fallback reports that no token matched and the default node answered, in
+// which case the position holds only that node. Callers must hold h.mu.
+func (h *HashRing) resolvePosition(hashValue uint64) (p position, fallback bool, err error) {
+	healthy := h.healthyFilter()
+	p, err = h.ownerPosition(hashValue, healthy)
+	if err != nil {
+		if errors.Is(err, ErrNoConnectedNodes) {
+			h.emptyLookups.Add(1)
 		}
-		return node.(ICacheNode), nil
+		if errors.Is(err, ErrNodeNotFound) && healthy != nil {
+			return position{}, false, fmt.Errorf("%w: every node is marked down", ErrNoHealthyNodes)
+		}
+		if errors.Is(err, ErrNodeNotFound) && h.config.DefaultNode != nil {
+			return position{node: h.config.DefaultNode, vnode: -1}, true, nil
+		}
+		return position{}, false, err
 	}
+	return p, false, nil
+}
 
-	return nil, fmt.Errorf("%w: no node found for key %s", ErrNodeNotFound, key)
+// ownerOf resolves a ring position to the first node clockwise accepted by
+// accept (nil accepts any usable node) and the matched token. Callers must
+// hold h.mu.
+func (h *HashRing) ownerOf(hashValue uint64, accept func(ICacheNode) bool) (ICacheNode, uint64, error) {
+	p, err := h.ownerPosition(hashValue, accept)
+	if err != nil {
+		return nil, 0, err
+	}
+	return p.node, p.hash, nil
 }
 
-func (h *HashRing) search(key uint64) (int, error) {
-	if len(h.sortedKeysOfNodes) == 0 {
+// ownerPosition is ownerOf returning the whole matched position. Callers
+// must hold h.mu.
+func (h *HashRing) ownerPosition(hashValue uint64, accept func(ICacheNode) bool) (position, error) {
+	positions := h.lookupPositions()
+
+	//performs a binary search on positions
+	index, err := h.search(positions, hashValue)
+	if err != nil {
+		return position{}, err
+	}
+
+	// walk clockwise past positions whose node is unusable (nil or with an
+	// empty identifier) so a single bad entry can't black-hole its arc
+	for i := 0; i < len(positions); i++ {
+		p := positions[(index+i)%len(positions)]
+		if p.node == nil || p.node.GetIdentifier() == "" {
+			continue
+		}
+		if accept != nil && !accept(p.node) {
+			continue
+		}
+		return p, nil
+	}
+
+	return position{}, fmt.Errorf("%w: no node owns hash %d", ErrNodeNotFound, hashValue)
+}
+
+func (h *HashRing) search(positions []position, key uint64) (int, error) {
+	if len(positions) == 0 {
 		return -1, ErrNoConnectedNodes
 	}
 
-	index := sort.Search(len(h.sortedKeysOfNodes), func(i int) bool {
-		return h.sortedKeysOfNodes[i] >= key
+	comparisons := 0
+	index := sort.Search(len(positions), func(i int) bool {
+		comparisons++
+		return positions[i].hash >= key
 	})
 
-	if index == len(h.sortedKeysOfNodes) {
+	if h.config.TrackSearchDepth {
+		h.depth.record(comparisons)
+	}
+
+	if index == len(positions) {
 		index = 0
 	}
 
 	return index, nil
 }
 
+// insertPositions merges added into the already sorted positions. The new
+// entries are sorted on their own and merged from the back in place, which
+// keeps adds linear in the ring size instead of re-sorting everything.
+func insertPositions(positions []position, added []position) []position {
+	slices.SortFunc(added, comparePositions)
+
+	i, j := len(positions)-1, len(added)-1
+	positions = append(positions, added...)
+	for k := len(positions) - 1; j >= 0; k-- {
+		if i >= 0 && comparePositions(positions[i], added[j]) > 0 {
+			positions[k] = positions[i]
+			i--
+		} else {
+			positions[k] = added[j]
+			j--
+		}
+	}
+	return positions
+}
+
+// memberCount returns the number of physical nodes. Callers must hold h.mu.
+func (h *HashRing) memberCount() int {
+	count := 0
+	h.hostMap.Range(func(_, _ any) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// shouldLogLookup applies SetLogSampling to per-lookup verbose logs.
+func (h *HashRing) shouldLogLookup() bool {
+	if !h.config.EnableLogs {
+		return false
+	}
+	if h.config.LogSampling <= 1 {
+		return true
+	}
+	return (h.lookupLogs.Add(1)-1)%uint64(h.config.LogSampling) == 0
+}
+
+// vNodeHash hashes the i-th virtual node of nodeId.
+func (h *HashRing) vNodeHash(nodeId string, i int) (uint64, error) {
+	vNodeId := h.namespaced(fmt.Sprintf("%s_%d", nodeId, i))
+	hash, err := h.generateHash(vNodeId)
+	if err != nil {
+		return 0, fmt.Errorf("%w for virtual node %s", ErrInHashingKey, vNodeId)
+	}
+	return hash, nil
+}
+
+// maxTokenSalts bounds how many salted rehashes placeVNode tries.
+const maxTokenSalts = 64
+
+// placeVNode returns the token for nodeId's i-th virtual node. When the plain
+// hash is already taken it is rehashed with an incrementing salt, so a
+// collision never lets one physical node silently shadow another.
+func (h *HashRing) placeVNode(nodeId string, i int, taken func(uint64) bool) (uint64, error) {
+	hash, err := h.vNodeHash(nodeId, i)
+	if err != nil {
+		return 0, err
+	}
+	for salt := 1; taken(hash); salt++ {
+		if salt > maxTokenSalts {
+			return 0, fmt.Errorf("%w for virtual node %s_%d", ErrTokenCollision, nodeId, i)
+		}
+		vNodeId := h.namespaced(fmt.Sprintf("%s_%d#%d", nodeId, i, salt))
+		if hash, err = h.generateHash(vNodeId); err != nil {
+			return 0, fmt.Errorf("%w for virtual node %s", ErrInHashingKey, vNodeId)
+		}
+		if h.config.EnableLogs {
+			log.Printf("[HashRing] Token collision for %s_%d, retrying as %s", nodeId, i, vNodeId)
+		}
+	}
+	return hash, nil
+}
+
+// tokenTaken reports whether any node already sits at hash. Callers must
+// hold h.mu.
+func (h *HashRing) tokenTaken(hash uint64) bool {
+	_, found := slices.BinarySearchFunc(h.positions, hash, func(p position, t uint64) int {
+		return cmp.Compare(p.hash, t)
+	})
+	return found
+}
+
+// namespaced prefixes a virtual node's hash input with the ring's namespace.
+func (h *HashRing) namespaced(vNodeId string) string {
+	if h.config.Namespace == "" {
+		return vNodeId
+	}
+	return h.config.Namespace + "/" + vNodeId
+}
+
+func (h *HashRing) generateHashBytes(key []byte) (uint64, error) {
+	hash := h.config.HashFunction()
+	if _, err := hash.Write(key); err != nil {
+		return 0, err
+	}
+
+	return hash.Sum64(), nil
+}
+
 func (h *HashRing) generateHash(key string) (uint64, error) {
 	hash := h.config.HashFunction()
 	if _, err := hash.Write([]byte(key)); err != nil {
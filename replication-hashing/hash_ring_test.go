@@ -0,0 +1,792 @@
+package replicationhashing
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"log"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testNode string
+
+func (n testNode) GetIdentifier() string { return string(n) }
+
+// numericHash hashes decimal input to its own value, so tests can place keys
+// at exact ring positions. Anything else falls back to FNV-1a.
+type numericHash struct{ buf []byte }
+
+func newNumericHash() hash.Hash64 { return &numericHash{} }
+
+func (n *numericHash) Write(p []byte) (int, error) {
+	n.buf = append(n.buf, p...)
+	return len(p), nil
+}
+
+func (n *numericHash) Sum64() uint64 {
+	if v, err := strconv.ParseUint(string(n.buf), 10, 64); err == nil {
+		return v
+	}
+	f := fnv.New64a()
+	f.Write(n.buf)
+	return f.Sum64()
+}
+
+func (n *numericHash) Sum(b []byte) []byte {
+	return binary.BigEndian.AppendUint64(b, n.Sum64())
+}
+func (n *numericHash) Reset()         { n.buf = n.buf[:0] }
+func (n *numericHash) Size() int      { return 8 }
+func (n *numericHash) BlockSize() int { return 1 }
+
+// shaHash is a well-mixed 64-bit hash built on SHA-256, for tests whose
+// outcome depends on virtual nodes spreading evenly. FNV-1a places
+// "a_0", "a_1", ... next to each other.
+type shaHash struct{ buf []byte }
+
+func newSHAHash() hash.Hash64 { return &shaHash{} }
+
+func (s *shaHash) Write(p []byte) (int, error) {
+	s.buf = append(s.buf, p...)
+	return len(p), nil
+}
+
+func (s *shaHash) Sum64() uint64 {
+	sum := sha256.Sum256(s.buf)
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+func (s *shaHash) Sum(b []byte) []byte {
+	return binary.BigEndian.AppendUint64(b, s.Sum64())
+}
+func (s *shaHash) Reset()         { s.buf = s.buf[:0] }
+func (s *shaHash) Size() int      { return 8 }
+func (s *shaHash) BlockSize() int { return 1 }
+
+// newTokenRing builds a ring on numericHash with every node at the given
+// tokens.
+func newTokenRing(t testing.TB, tokens map[string][]uint64, opts ...HashRingConfigFn) *HashRing {
+	t.Helper()
+	ring := InitHashRing(append([]HashRingConfigFn{SetHashFunction(newNumericHash)}, opts...)...)
+	for _, id := range slices.Sorted(maps.Keys(tokens)) {
+		if err := ring.AddServerWithTokens(testNode(id), tokens[id]); err != nil {
+			t.Fatalf("AddServerWithTokens(%s): %v", id, err)
+		}
+	}
+	return ring
+}
+
+// testNodes turns identifiers into nodes.
+func testNodes(ids ...string) []ICacheNode {
+	nodes := make([]ICacheNode, len(ids))
+	for i, id := range ids {
+		nodes[i] = testNode(id)
+	}
+	return nodes
+}
+
+// ids lists the identifiers of nodes in order.
+func ids(nodes []ICacheNode) []string {
+	out := make([]string, len(nodes))
+	for i, n := range nodes {
+		out[i] = n.GetIdentifier()
+	}
+	return out
+}
+
+// newTestRing builds a ring holding the given node ids, failing the test if
+// any of them can't be added.
+func newTestRing(t testing.TB, ids []string, opts ...HashRingConfigFn) *HashRing {
+	t.Helper()
+	ring, err := InitHashRingE(append(opts, WithNodes(testNodes(ids...)...))...)
+	if err != nil {
+		t.Fatalf("InitHashRingE: %v", err)
+	}
+	return ring
+}
+
+// sampleKeys returns n distinct lookup keys.
+func sampleKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("user:%d:session", i*7919)
+	}
+	return keys
+}
+
+// owners resolves every key with GetServer.
+func owners(t testing.TB, ring *HashRing, keys []string) map[string]string {
+	t.Helper()
+	owned := make(map[string]string, len(keys))
+	for _, key := range keys {
+		node, err := ring.GetServer(key)
+		if err != nil {
+			t.Fatalf("GetServer(%q): %v", key, err)
+		}
+		owned[key] = node.GetIdentifier()
+	}
+	return owned
+}
+
+// tokenOwners lists the node id behind every position, in ring order.
+func tokenOwners(ring *HashRing) []string {
+	var ids []string
+	ring.Walk(func(_ uint64, node ICacheNode) bool {
+		ids = append(ids, node.GetIdentifier())
+		return true
+	})
+	return ids
+}
+
+func TestRemoveServersWhere(t *testing.T) {
+	ring := newTestRing(t, []string{"rack1-a", "rack1-b", "rack2-a", "rack2-b"}, SetVirtualNodes(20))
+
+	removed, err := ring.RemoveServersWhere(func(id string) bool {
+		return strings.HasPrefix(id, "rack1-")
+	})
+	if err != nil {
+		t.Fatalf("RemoveServersWhere: %v", err)
+	}
+	if want := []string{"rack1-a", "rack1-b"}; !slices.Equal(removed, want) {
+		t.Fatalf("removed %v, want %v", removed, want)
+	}
+	if got, want := ring.MemberIDs(), []string{"rack2-a", "rack2-b"}; !slices.Equal(got, want) {
+		t.Fatalf("members %v, want %v", got, want)
+	}
+	for _, id := range tokenOwners(ring) {
+		if strings.HasPrefix(id, "rack1-") {
+			t.Fatalf("token of removed node %s left on the ring", id)
+		}
+	}
+	if got := ring.VirtualNodeCount(); got != 40 {
+		t.Fatalf("%d tokens left, want 40", got)
+	}
+	for key, id := range owners(t, ring, sampleKeys(500)) {
+		if !strings.HasPrefix(id, "rack2-") {
+			t.Fatalf("key %q routed to %s after its rack was removed", key, id)
+		}
+	}
+}
+
+func TestRemoveServersWhereNoMatch(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b"})
+
+	removed, err := ring.RemoveServersWhere(func(string) bool { return false })
+	if err != nil || len(removed) != 0 {
+		t.Fatalf("got %v, %v; want nothing removed", removed, err)
+	}
+	if _, err := ring.RemoveServersWhere(nil); !errors.Is(err, ErrNilPredicate) {
+		t.Fatalf("nil predicate: got %v, want ErrNilPredicate", err)
+	}
+	if ring.NodeCount() != 2 {
+		t.Fatalf("ring changed: %v", ring.MemberIDs())
+	}
+}
+
+// failingHash is FNV-1a that refuses to hash any input containing poison.
+type failingHash struct {
+	hash.Hash64
+	poison string
+}
+
+func (f failingHash) Write(p []byte) (int, error) {
+	if strings.Contains(string(p), f.poison) {
+		return 0, errors.New("hash failed")
+	}
+	return f.Hash64.Write(p)
+}
+
+func TestAddServerRollsBackOnHashError(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b"}, SetVirtualNodes(10), SetHashFunction(func() hash.Hash64 {
+		return failingHash{Hash64: fnv.New64a(), poison: "bad_3"}
+	}))
+	keys := sampleKeys(200)
+	before := owners(t, ring, keys)
+	positions := slices.Clone(ring.positions)
+	checksum := ring.Checksum()
+
+	if err := ring.AddServer(testNode("bad")); !errors.Is(err, ErrInHashingKey) {
+		t.Fatalf("AddServer: got %v, want ErrInHashingKey", err)
+	}
+
+	if !slices.Equal(ring.positions, positions) {
+		t.Fatal("positions changed by a failed add")
+	}
+	if ring.Checksum() != checksum {
+		t.Fatal("checksum changed by a failed add")
+	}
+	if ring.HasNode("bad") {
+		t.Fatal("failed node left in the member map")
+	}
+	if got := owners(t, ring, keys); !maps.Equal(got, before) {
+		t.Fatal("lookups changed after a failed add")
+	}
+	// the ring keeps accepting members
+	if err := ring.AddServer(testNode("fine")); err != nil {
+		t.Fatalf("AddServer after rollback: %v", err)
+	}
+}
+
+// truncatedHash keeps only the low byte of FNV-1a, so virtual nodes of
+// different servers collide often.
+type truncatedHash struct{ hash.Hash64 }
+
+func newTruncatedHash() hash.Hash64 { return truncatedHash{fnv.New64a()} }
+
+func (t truncatedHash) Sum64() uint64 { return t.Hash64.Sum64() & 0xff }
+
+func TestVirtualNodeCollisionsAreSalted(t *testing.T) {
+	const vnodes = 10
+	names := []string{"a", "b", "c", "d"}
+	ring := newTestRing(t, names, SetVirtualNodes(vnodes), SetHashFunction(newTruncatedHash))
+
+	// make sure the unsalted tokens really collide across servers
+	seen := make(map[uint64]string)
+	collided := false
+	for _, id := range names {
+		for i := range vnodes {
+			h := newTruncatedHash()
+			h.Write([]byte(fmt.Sprintf("%s_%d", id, i)))
+			if other, ok := seen[h.Sum64()]; ok && other != id {
+				collided = true
+			}
+			seen[h.Sum64()] = id
+		}
+	}
+	if !collided {
+		t.Fatal("no cross-server collision in the layout; the test proves nothing")
+	}
+
+	check := func(want []string) {
+		t.Helper()
+		if got := len(ring.positions); got != len(want)*vnodes {
+			t.Fatalf("%d positions, want %d", got, len(want)*vnodes)
+		}
+		perNode := make(map[string]int)
+		for i, p := range ring.positions {
+			if i > 0 && ring.positions[i-1].hash == p.hash {
+				t.Fatalf("token %d held twice", p.hash)
+			}
+			perNode[p.node.GetIdentifier()]++
+		}
+		for _, tok := range ring.Tokens() {
+			node, err := ring.GetServerByHash(tok.Hash)
+			if err != nil || node.GetIdentifier() != tok.NodeID {
+				t.Fatalf("token %d of %s routes to %v (%v)", tok.Hash, tok.NodeID, node, err)
+			}
+		}
+		for _, id := range want {
+			if perNode[id] != vnodes {
+				t.Fatalf("%s holds %d tokens, want %d", id, perNode[id], vnodes)
+			}
+		}
+	}
+	check(names)
+
+	for i, id := range names[:len(names)-1] {
+		if err := ring.RemoveServerByID(id); err != nil {
+			t.Fatalf("RemoveServerByID(%s): %v", id, err)
+		}
+		check(names[i+1:])
+	}
+}
+
+func TestAddServersMatchesSequentialAdds(t *testing.T) {
+	names := []string{"a", "b", "c", "d", "e"}
+	sequential := newTestRing(t, nil)
+	for _, node := range testNodes(names...) {
+		if err := sequential.AddServer(node); err != nil {
+			t.Fatalf("AddServer: %v", err)
+		}
+	}
+	bulk := newTestRing(t, nil)
+	if err := bulk.AddServers(testNodes(names...)); err != nil {
+		t.Fatalf("AddServers: %v", err)
+	}
+
+	if !slices.Equal(tokenOwners(bulk), tokenOwners(sequential)) || bulk.Checksum() != sequential.Checksum() {
+		t.Fatal("bulk and sequential adds built different rings")
+	}
+	if !slices.IsSortedFunc(bulk.positions, func(a, b position) int { return cmp.Compare(a.hash, b.hash) }) {
+		t.Fatal("positions are not sorted after a bulk add")
+	}
+}
+
+func TestAddServersPartialFailure(t *testing.T) {
+	ring := newTestRing(t, []string{"a"}, SetVirtualNodes(10))
+
+	err := ring.AddServers(testNodes("b", "a", "c", "b"))
+	if !errors.Is(err, ErrNodeExists) {
+		t.Fatalf("AddServers: got %v, want ErrNodeExists", err)
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok || len(joined.Unwrap()) != 2 {
+		t.Fatalf("AddServers reported %v, want the existing and the repeated node", err)
+	}
+	for _, id := range []string{"a", "b"} {
+		if !strings.Contains(err.Error(), id) {
+			t.Fatalf("AddServers error %q does not name %s", err, id)
+		}
+	}
+
+	// the nodes that could be added were
+	if got := ring.MemberIDs(); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Fatalf("members %v, want [a b c]", got)
+	}
+	if got := ring.VirtualNodeCount(); got != 30 {
+		t.Fatalf("%d tokens, want 30", got)
+	}
+}
+
+func BenchmarkAddServersBulk(b *testing.B) {
+	nodes := make([]ICacheNode, 500)
+	for i := range nodes {
+		nodes[i] = testNode(fmt.Sprintf("node-%d", i))
+	}
+	b.Run("sequential", func(b *testing.B) {
+		for b.Loop() {
+			ring := InitHashRing(SetVirtualNodes(200))
+			for _, node := range nodes {
+				if err := ring.AddServer(node); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("bulk", func(b *testing.B) {
+		for b.Loop() {
+			ring := InitHashRing(SetVirtualNodes(200))
+			if err := ring.AddServers(nodes); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestRemoveServers(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c", "d"}, SetVirtualNodes(10))
+	before := tokenOwners(ring)
+
+	err := ring.RemoveServers(testNodes("b", "x", "d"))
+	if !errors.Is(err, ErrNodeNotFound) || !strings.Contains(err.Error(), "x") {
+		t.Fatalf("RemoveServers: got %v, want ErrNodeNotFound naming x", err)
+	}
+	want := slices.DeleteFunc(slices.Clone(before), func(owner string) bool {
+		return owner == "b" || owner == "d"
+	})
+	if got := tokenOwners(ring); !slices.Equal(got, want) {
+		t.Fatalf("tokens left %v, want %v", got, want)
+	}
+	if got := ring.MemberIDs(); !slices.Equal(got, []string{"a", "c"}) {
+		t.Fatalf("members %v, want [a c]", got)
+	}
+
+	if err := ring.RemoveServersByID([]string{"a", "c"}); err != nil {
+		t.Fatalf("RemoveServersByID: %v", err)
+	}
+	if ring.NodeCount() != 0 || ring.VirtualNodeCount() != 0 {
+		t.Fatalf("%d nodes and %d tokens left, want none", ring.NodeCount(), ring.VirtualNodeCount())
+	}
+}
+
+func BenchmarkRemoveServers(b *testing.B) {
+	nodes := make([]ICacheNode, 100)
+	for i := range nodes {
+		nodes[i] = testNode(fmt.Sprintf("node-%d", i))
+	}
+	// fill builds a fresh ring outside the timed section
+	fill := func(b *testing.B) *HashRing {
+		b.StopTimer()
+		defer b.StartTimer()
+		ring := InitHashRing(SetVirtualNodes(100))
+		if err := ring.AddServers(nodes); err != nil {
+			b.Fatal(err)
+		}
+		return ring
+	}
+	b.Run("sequential", func(b *testing.B) {
+		for b.Loop() {
+			ring := fill(b)
+			for _, node := range nodes {
+				if err := ring.RemoveServer(node); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("bulk", func(b *testing.B) {
+		for b.Loop() {
+			ring := fill(b)
+			if err := ring.RemoveServers(nodes); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestAddServerWithTokens(t *testing.T) {
+	ring := newTokenRing(t, map[string][]uint64{"a": {100, 300}})
+
+	if err := ring.AddServerWithTokens(testNode("b"), []uint64{200, 400}); err != nil {
+		t.Fatalf("AddServerWithTokens: %v", err)
+	}
+	if got := tokenOwners(ring); !slices.Equal(got, []string{"a", "b", "a", "b"}) {
+		t.Fatalf("token owners %v, want [a b a b]", got)
+	}
+	for key, want := range map[string]string{"150": "b", "200": "b", "350": "b", "450": "a"} {
+		if node, err := ring.GetServer(key); err != nil || node.GetIdentifier() != want {
+			t.Fatalf("GetServer(%s) = %v, %v; want %s", key, node, err, want)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		tokens []uint64
+		want   error
+	}{
+		{"no tokens", nil, ErrNoTokens},
+		{"repeated in the call", []uint64{500, 600, 500}, ErrTokenInUse},
+		{"already on the ring", []uint64{500, 300}, ErrTokenInUse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := tokenOwners(ring)
+			checksum := ring.Checksum()
+			if err := ring.AddServerWithTokens(testNode("c"), tt.tokens); !errors.Is(err, tt.want) {
+				t.Fatalf("AddServerWithTokens(%v): got %v, want %v", tt.tokens, err, tt.want)
+			}
+			if ring.HasNode("c") || ring.Checksum() != checksum || !slices.Equal(tokenOwners(ring), before) {
+				t.Fatal("a failed add changed the ring")
+			}
+		})
+	}
+
+	// removal drops exactly the given tokens
+	if err := ring.RemoveServer(testNode("b")); err != nil {
+		t.Fatalf("RemoveServer: %v", err)
+	}
+	if got := ring.Tokens(); len(got) != 2 || got[0].Hash != 100 || got[1].Hash != 300 {
+		t.Fatalf("tokens after removing b: %v, want a's 100 and 300", got)
+	}
+}
+
+// captureLogs redirects the standard logger for the rest of the test.
+func captureLogs(t *testing.T) *strings.Builder {
+	t.Helper()
+	var buf strings.Builder
+	out, flags := log.Writer(), log.Flags()
+	log.SetOutput(&buf)
+	t.Cleanup(func() {
+		log.SetOutput(out)
+		log.SetFlags(flags)
+	})
+	return &buf
+}
+
+func TestLogSampling(t *testing.T) {
+	tests := []struct {
+		sampling, lookups, want int
+	}{
+		{0, 50, 50},
+		{1, 50, 50},
+		{10, 1000, 100},
+		{10, 5, 1},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("1 in %d", tt.sampling), func(t *testing.T) {
+			logs := captureLogs(t)
+			ring := newTestRing(t, []string{"a", "b"}, EnableVerboseLogs(true), SetLogSampling(tt.sampling))
+
+			for _, key := range sampleKeys(tt.lookups) {
+				if _, err := ring.GetServer(key); err != nil {
+					t.Fatalf("GetServer(%q): %v", key, err)
+				}
+			}
+			if got := strings.Count(logs.String(), "mapped to node"); got != tt.want {
+				t.Fatalf("%d lookups logged %d lines, want %d", tt.lookups, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreventEmptyRing(t *testing.T) {
+	tests := []struct {
+		name    string
+		prevent bool
+		want    error
+		left    int
+	}{
+		{"blocked", true, ErrWouldEmptyRing, 1},
+		{"allowed", false, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring := newTestRing(t, []string{"a", "b"}, SetPreventEmptyRing(tt.prevent))
+			if err := ring.RemoveServerByID("a"); err != nil {
+				t.Fatalf("removing a with b left: %v", err)
+			}
+			if err := ring.RemoveServer(testNode("b")); !errors.Is(err, tt.want) {
+				t.Fatalf("removing the last node: got %v, want %v", err, tt.want)
+			}
+			if got := ring.NodeCount(); got != tt.left {
+				t.Fatalf("%d nodes left, want %d", got, tt.left)
+			}
+		})
+	}
+}
+
+func TestLargeVirtualNodeCount(t *testing.T) {
+	const vnodes = 20000
+	ids := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	ring := newTestRing(t, nil, SetVirtualNodes(vnodes), SetMaxTotalVirtualNodes(len(ids)*vnodes))
+
+	start := time.Now()
+	for _, id := range ids {
+		if err := ring.AddServer(testNode(id)); err != nil {
+			t.Fatalf("AddServer(%s): %v", id, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("adding %d positions took %v", len(ids)*vnodes, elapsed)
+	}
+
+	if got := ring.VirtualNodeCount(); got != len(ids)*vnodes {
+		t.Fatalf("%d positions, want %d", got, len(ids)*vnodes)
+	}
+	if !slices.IsSortedFunc(ring.positions, comparePositions) {
+		t.Fatal("positions are not sorted")
+	}
+	if got := ring.Tokens(); len(got) != len(ids)*vnodes {
+		t.Fatalf("Tokens reports %d positions", len(got))
+	}
+
+	// the limit is full, so one more node is rejected without touching the ring
+	if err := ring.AddServer(testNode("extra")); !errors.Is(err, ErrTooManyVNodes) {
+		t.Fatalf("AddServer past the limit: got %v, want ErrTooManyVNodes", err)
+	}
+	if ring.HasNode("extra") || ring.VirtualNodeCount() != len(ids)*vnodes {
+		t.Fatal("rejected add changed the ring")
+	}
+}
+
+func TestRemoveServerByID(t *testing.T) {
+	tests := []struct {
+		name    string
+		members []string
+		remove  []string // removed in order, the last one is checked
+		want    error
+	}{
+		{"known node", []string{"a", "b", "c"}, []string{"b"}, nil},
+		{"missing id", []string{"a", "b"}, []string{"x"}, ErrNodeNotFound},
+		{"double remove", []string{"a", "b"}, []string{"a", "a"}, ErrNodeNotFound},
+		{"remove before add", nil, []string{"a"}, ErrNodeNotFound},
+		{"last node", []string{"a"}, []string{"a"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring := newTestRing(t, tt.members, SetVirtualNodes(25))
+			for _, id := range tt.remove[:len(tt.remove)-1] {
+				if err := ring.RemoveServerByID(id); err != nil {
+					t.Fatalf("RemoveServerByID(%s): %v", id, err)
+				}
+			}
+			id := tt.remove[len(tt.remove)-1]
+			before := tokenOwners(ring)
+
+			err := ring.RemoveServerByID(id)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("RemoveServerByID(%s): got %v, want %v", id, err, tt.want)
+			}
+
+			// exactly the node's own tokens go, everyone else's stay
+			want := slices.DeleteFunc(slices.Clone(before), func(owner string) bool {
+				return tt.want == nil && owner == id
+			})
+			if got := tokenOwners(ring); !slices.Equal(got, want) {
+				t.Fatalf("tokens left %v, want %v", got, want)
+			}
+			if ring.HasNode(id) {
+				t.Fatalf("%s is still a member", id)
+			}
+			if ring.NodeCount() == 0 {
+				if _, err := ring.GetServer("k"); !errors.Is(err, ErrNoConnectedNodes) {
+					t.Fatalf("GetServer on the emptied ring: got %v, want ErrNoConnectedNodes", err)
+				}
+				return
+			}
+			for key, owner := range owners(t, ring, sampleKeys(300)) {
+				if owner == id {
+					t.Fatalf("key %q routed to removed node %s", key, id)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveServerAndByIDAgree(t *testing.T) {
+	byNode := newTestRing(t, []string{"a", "b", "c"})
+	byID := newTestRing(t, []string{"a", "b", "c"})
+
+	if err := byNode.RemoveServer(testNode("b")); err != nil {
+		t.Fatalf("RemoveServer: %v", err)
+	}
+	if err := byID.RemoveServerByID("b"); err != nil {
+		t.Fatalf("RemoveServerByID: %v", err)
+	}
+	if !slices.Equal(tokenOwners(byNode), tokenOwners(byID)) || byNode.Checksum() != byID.Checksum() {
+		t.Fatal("rings differ after the same removal")
+	}
+
+	// either method sees the other's removal
+	if err := byNode.RemoveServerByID("b"); !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("RemoveServerByID after RemoveServer: got %v, want ErrNodeNotFound", err)
+	}
+	if err := byID.RemoveServer(testNode("b")); !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("RemoveServer after RemoveServerByID: got %v, want ErrNodeNotFound", err)
+	}
+	keys := sampleKeys(200)
+	if !maps.Equal(owners(t, byNode, keys), owners(t, byID, keys)) {
+		t.Fatal("rings route keys differently after the same removal")
+	}
+}
+
+func TestAddRemoveAdd(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b"}, SetVirtualNodes(25))
+	keys := sampleKeys(300)
+	before := owners(t, ring, keys)
+
+	if err := ring.AddServer(testNode("c")); err != nil {
+		t.Fatalf("AddServer: %v", err)
+	}
+	withC := owners(t, ring, keys)
+	if err := ring.RemoveServer(testNode("c")); err != nil {
+		t.Fatalf("RemoveServer: %v", err)
+	}
+	if got := owners(t, ring, keys); !maps.Equal(got, before) {
+		t.Fatal("routing differs after adding and removing c")
+	}
+	if err := ring.AddServer(testNode("c")); err != nil {
+		t.Fatalf("re-adding c: %v", err)
+	}
+	if got := owners(t, ring, keys); !maps.Equal(got, withC) {
+		t.Fatal("re-adding c placed it differently")
+	}
+}
+
+func TestInitHashRingEValidates(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []HashRingConfigFn
+		want int // number of problems reported
+	}{
+		{"defaults", nil, 0},
+		{"zero virtual nodes", []HashRingConfigFn{SetVirtualNodes(0)}, 1},
+		{"negative virtual nodes", []HashRingConfigFn{SetVirtualNodes(-5)}, 1},
+		{"nil hash function", []HashRingConfigFn{SetHashFunction(nil)}, 1},
+		{"negative expected nodes", []HashRingConfigFn{SetExpectedNodes(-1)}, 1},
+		{"negative log sampling", []HashRingConfigFn{SetLogSampling(-1)}, 1},
+		{"join without interval", []HashRingConfigFn{EnableGradualJoin(2, 0)}, 1},
+		{"negative vnode limit", []HashRingConfigFn{SetMaxTotalVirtualNodes(-1)}, 1},
+		{"vnode limit below one node", []HashRingConfigFn{SetVirtualNodes(10), SetMaxTotalVirtualNodes(5)}, 1},
+		{"every problem at once", []HashRingConfigFn{SetVirtualNodes(0), SetHashFunction(nil), SetLogSampling(-1)}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring, err := InitHashRingE(tt.opts...)
+			if tt.want == 0 {
+				if err != nil || ring == nil {
+					t.Fatalf("InitHashRingE: got %v, want a ring", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidConfig) || ring != nil {
+				t.Fatalf("InitHashRingE: got %v, want ErrInvalidConfig and no ring", err)
+			}
+			joined, ok := err.(interface{ Unwrap() []error })
+			if !ok || len(joined.Unwrap()) != tt.want {
+				t.Fatalf("InitHashRingE reported %v, want %d problems", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetNamespace(t *testing.T) {
+	fleet := []string{"a", "b", "c", "d"}
+	keys := sampleKeys(2000)
+	sessions := owners(t, newTestRing(t, fleet, SetVirtualNodes(20), SetNamespace("sessions")), keys)
+	profiles := owners(t, newTestRing(t, fleet, SetVirtualNodes(20), SetNamespace("profiles")), keys)
+	plain := owners(t, newTestRing(t, fleet, SetVirtualNodes(20)), keys)
+
+	differ := func(x, y map[string]string) int {
+		n := 0
+		for key, owner := range x {
+			if y[key] != owner {
+				n++
+			}
+		}
+		return n
+	}
+	// with independent layouts about three quarters of the keys change owner
+	// between any two rings of four nodes
+	if n := differ(sessions, profiles); n < len(keys)/2 {
+		t.Errorf("rings in different namespaces disagree on only %d of %d keys", n, len(keys))
+	}
+	if n := differ(sessions, plain); n < len(keys)/2 {
+		t.Errorf("a namespaced ring disagrees with an unnamespaced one on only %d of %d keys", n, len(keys))
+	}
+
+	again := owners(t, newTestRing(t, fleet, SetVirtualNodes(20), SetNamespace("sessions")), keys)
+	if !maps.Equal(sessions, again) {
+		t.Error("rings in the same namespace place keys differently")
+	}
+	empty := owners(t, newTestRing(t, fleet, SetVirtualNodes(20), SetNamespace("")), keys)
+	if !maps.Equal(plain, empty) {
+		t.Error("an empty namespace changed the token layout")
+	}
+}
+
+func TestWithNodes(t *testing.T) {
+	manual := InitHashRing(SetVirtualNodes(10))
+	for _, node := range testNodes("a", "b", "c") {
+		if err := manual.AddServer(node); err != nil {
+			t.Fatalf("AddServer: %v", err)
+		}
+	}
+	seeded, err := InitHashRingE(SetVirtualNodes(10), WithNodes(testNodes("a", "b")...), WithNodes(testNode("c")))
+	if err != nil {
+		t.Fatalf("InitHashRingE: %v", err)
+	}
+	if !slices.Equal(seeded.Tokens(), manual.Tokens()) {
+		t.Fatal("a seeded ring has different tokens from one built with AddServer")
+	}
+	keys := sampleKeys(500)
+	if !maps.Equal(owners(t, seeded, keys), owners(t, manual, keys)) {
+		t.Fatal("a seeded ring places keys differently from one built with AddServer")
+	}
+}
+
+func TestWithNodesDuplicate(t *testing.T) {
+	seeds := testNodes("a", "b", "a")
+	ring, err := InitHashRingE(WithNodes(seeds...))
+	if !errors.Is(err, ErrNodeExists) {
+		t.Fatalf("InitHashRingE with a duplicate seed = %v, want ErrNodeExists", err)
+	}
+	if ring != nil {
+		t.Fatal("InitHashRingE returned a ring alongside the error")
+	}
+
+	// InitHashRing keeps the seeds that could be added
+	partial := InitHashRing(WithNodes(seeds...))
+	if got := slices.Sorted(slices.Values(ids(partial.Members()))); !slices.Equal(got, []string{"a", "b"}) {
+		t.Fatalf("InitHashRing kept %v, want [a b]", got)
+	}
+}
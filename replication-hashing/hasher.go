@@ -0,0 +1,64 @@
+package replicationhashing
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+)
+
+// IHasher is a stateless 64-bit hash over a whole input, the shape of most
+// non-standard-library hashes (xxhash, wyhash and the like).
+type IHasher interface {
+	Sum64(data []byte) uint64
+}
+
+// SetHasher makes the ring hash keys and virtual nodes with hasher. It is
+// SetHashFunction with hasher adapted by HashFunctionOf, so ChangeHashFunction
+// and pause views treat it like any other hash function. The hasher's type
+// is reported as the hash function name.
+func SetHasher(hasher IHasher) HashRingConfigFn {
+	return SetHashFunctionNamed(fmt.Sprintf("%T", hasher), HashFunctionOf(hasher))
+}
+
+// HashFunctionOf adapts hasher to the hash.Hash64 constructor SetHashFunction
+// takes. Writes are buffered and hashed in one call on Sum64.
+func HashFunctionOf(hasher IHasher) func() hash.Hash64 {
+	return func() hash.Hash64 {
+		return &hasherHash{hasher: hasher}
+	}
+}
+
+type hasherHash struct {
+	hasher IHasher
+	buf    []byte
+}
+
+func (h *hasherHash) Write(p []byte) (int, error) {
+	h.buf = append(h.buf, p...)
+	return len(p), nil
+}
+
+func (h *hasherHash) Sum64() uint64 { return h.hasher.Sum64(h.buf) }
+
+func (h *hasherHash) Sum(b []byte) []byte {
+	return binary.BigEndian.AppendUint64(b, h.Sum64())
+}
+
+func (h *hasherHash) Reset()         { h.buf = h.buf[:0] }
+func (h *hasherHash) Size() int      { return 8 }
+func (h *hasherHash) BlockSize() int { return 1 }
+
+// Hasher32 adapts a 32-bit hash constructor such as crc32.NewIEEE to
+// IHasher. A 32-bit sum used as is would put every token in the lowest
+// 2^-32 of the ring, so it is spread over 64 bits with the SplitMix64
+// finalizer, which is a bijection and keeps distinct sums distinct.
+type Hasher32 func() hash.Hash32
+
+func (f Hasher32) Sum64(data []byte) uint64 {
+	h := f()
+	h.Write(data)
+	z := uint64(h.Sum32()) * 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
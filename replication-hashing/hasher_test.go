@@ -0,0 +1,90 @@
+package replicationhashing
+
+import (
+	"hash/crc32"
+	"math/bits"
+	"testing"
+)
+
+// mix64 is a stateless xxhash-style IHasher: FNV-1a over the input followed
+// by a SplitMix64 finalizer.
+type mix64 struct{}
+
+func (mix64) Sum64(data []byte) uint64 {
+	z := uint64(14695981039346656037)
+	for _, c := range data {
+		z = (z ^ uint64(c)) * 1099511628211
+	}
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+func TestSetHasher(t *testing.T) {
+	tests := []struct {
+		name   string
+		hasher IHasher
+	}{
+		{"stateless", mix64{}},
+		{"32-bit", Hasher32(crc32.NewIEEE)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring := newTestRing(t, []string{"a", "b", "c"}, SetVirtualNodes(20), SetHasher(tt.hasher))
+
+			// every key lands where the hasher puts it
+			for _, key := range sampleKeys(300) {
+				hashValue, err := ring.hashKey(key)
+				if err != nil {
+					t.Fatalf("hashKey(%q): %v", key, err)
+				}
+				if want := tt.hasher.Sum64([]byte(key)); hashValue != want {
+					t.Fatalf("key %q hashed to %d, the hasher gives %d", key, hashValue, want)
+				}
+				byHash, err := ring.GetServerByHash(hashValue)
+				if err != nil {
+					t.Fatalf("GetServerByHash: %v", err)
+				}
+				if got := owners(t, ring, []string{key})[key]; got != byHash.GetIdentifier() {
+					t.Fatalf("key %q: GetServer says %s, its hash belongs to %s", key, got, byHash.GetIdentifier())
+				}
+			}
+			if name := ring.HashFunctionName(); name == "" {
+				t.Fatal("no hash function name reported")
+			}
+		})
+	}
+}
+
+func TestHashFunctionOf(t *testing.T) {
+	h := HashFunctionOf(mix64{})()
+	h.Write([]byte("user:"))
+	h.Write([]byte("42"))
+	if got, want := h.Sum64(), (mix64{}).Sum64([]byte("user:42")); got != want {
+		t.Fatalf("split writes hash to %d, want %d", got, want)
+	}
+	if got := h.Sum([]byte("x")); len(got) != 1+h.Size() {
+		t.Fatalf("Sum appended %d bytes, want %d", len(got)-1, h.Size())
+	}
+	h.Reset()
+	h.Write([]byte("42"))
+	if got, want := h.Sum64(), (mix64{}).Sum64([]byte("42")); got != want {
+		t.Fatalf("hash after Reset is %d, want %d", got, want)
+	}
+}
+
+func TestHasher32SpreadsOverTheRing(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c", "d"}, SetVirtualNodes(20), SetHasher(Hasher32(crc32.NewIEEE)))
+
+	// raw 32-bit sums would leave the top half of every token zero
+	tokens := ring.Tokens()
+	high := 0
+	for _, token := range tokens {
+		if bits.LeadingZeros64(token.Hash) < 32 {
+			high++
+		}
+	}
+	if high < len(tokens)*9/10 {
+		t.Fatalf("only %d of %d tokens use the upper 32 bits", high, len(tokens))
+	}
+}
@@ -0,0 +1,61 @@
+package replicationhashing
+
+import (
+	"fmt"
+	"log"
+)
+
+// MarkDown makes lookups skip nodeID's tokens, falling through to the next
+// healthy node clockwise, while the tokens stay on the ring. Unlike removing
+// and re-adding a flapping node, keys return to it after MarkUp without any
+// other key moving.
+func (h *HashRing) MarkDown(nodeID string) error {
+	return h.setDown(nodeID, true)
+}
+
+// MarkUp reverses MarkDown.
+func (h *HashRing) MarkUp(nodeID string) error {
+	return h.setDown(nodeID, false)
+}
+
+// IsDown reports whether nodeID is marked down.
+func (h *HashRing) IsDown(nodeID string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	_, down := h.down[nodeID]
+	return down
+}
+
+func (h *HashRing) setDown(nodeID string, down bool) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.hostMap.Load(nodeID); !ok {
+		return fmt.Errorf("%w : %s", ErrNodeNotFound, nodeID)
+	}
+	if down {
+		if h.down == nil {
+			h.down = make(map[string]struct{})
+		}
+		h.down[nodeID] = struct{}{}
+	} else {
+		delete(h.down, nodeID)
+	}
+
+	if h.config.EnableLogs {
+		log.Printf("[HashRing] Node %s marked down=%t", nodeID, down)
+	}
+	return nil
+}
+
+// healthyFilter returns an accept function skipping down nodes, or nil when
+// no node is down. Callers must hold h.mu.
+func (h *HashRing) healthyFilter() func(ICacheNode) bool {
+	if len(h.down) == 0 {
+		return nil
+	}
+	return func(node ICacheNode) bool {
+		_, down := h.down[node.GetIdentifier()]
+		return !down
+	}
+}
@@ -0,0 +1,101 @@
+package replicationhashing
+
+import (
+	"errors"
+	"maps"
+	"testing"
+)
+
+func TestMarkDownAndUp(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c"}, SetVirtualNodes(20))
+	keys := sampleKeys(2000)
+	before := owners(t, ring, keys)
+
+	if err := ring.MarkDown("b"); err != nil {
+		t.Fatalf("MarkDown: %v", err)
+	}
+	if !ring.IsDown("b") || ring.IsDown("a") {
+		t.Fatalf("IsDown(b) = %t, IsDown(a) = %t; want true, false", ring.IsDown("b"), ring.IsDown("a"))
+	}
+
+	// b's keys fall through to a healthy node, nobody else's move
+	moved := 0
+	for key, owner := range owners(t, ring, keys) {
+		switch was := before[key]; {
+		case owner == "b":
+			t.Fatalf("key %q still routed to down node b", key)
+		case was == "b":
+			moved++
+		case owner != was:
+			t.Fatalf("key %q moved from healthy %s to %s", key, was, owner)
+		}
+	}
+	if moved == 0 {
+		t.Fatal("b owned no keys, so the test proves nothing")
+	}
+	if !ring.HasNode("b") {
+		t.Fatal("a down node left the ring")
+	}
+
+	if err := ring.MarkUp("b"); err != nil {
+		t.Fatalf("MarkUp: %v", err)
+	}
+	if ring.IsDown("b") {
+		t.Fatal("IsDown(b) after MarkUp")
+	}
+	if after := owners(t, ring, keys); !maps.Equal(after, before) {
+		t.Fatal("keys did not return to their original owners after MarkUp")
+	}
+}
+
+func TestMarkDownEveryNode(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b"})
+	for _, id := range []string{"a", "b"} {
+		if err := ring.MarkDown(id); err != nil {
+			t.Fatalf("MarkDown(%s): %v", id, err)
+		}
+	}
+	if _, err := ring.GetServer("key"); !errors.Is(err, ErrNoHealthyNodes) {
+		t.Fatalf("GetServer with every node down = %v, want ErrNoHealthyNodes", err)
+	}
+
+	if err := ring.MarkUp("a"); err != nil {
+		t.Fatalf("MarkUp: %v", err)
+	}
+	node, err := ring.GetServer("key")
+	if err != nil {
+		t.Fatalf("GetServer: %v", err)
+	}
+	if node.GetIdentifier() != "a" {
+		t.Fatalf("GetServer returned %s, want the only healthy node a", node.GetIdentifier())
+	}
+}
+
+func TestMarkDownUnknownNode(t *testing.T) {
+	ring := newTestRing(t, []string{"a"})
+	if err := ring.MarkDown("ghost"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("MarkDown(ghost) = %v, want ErrNodeNotFound", err)
+	}
+	if err := ring.MarkUp("ghost"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("MarkUp(ghost) = %v, want ErrNodeNotFound", err)
+	}
+	if ring.IsDown("ghost") {
+		t.Error("IsDown(ghost) = true")
+	}
+}
+
+func TestRemovedNodeForgetsDownState(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b"})
+	if err := ring.MarkDown("b"); err != nil {
+		t.Fatalf("MarkDown: %v", err)
+	}
+	if err := ring.RemoveServerByID("b"); err != nil {
+		t.Fatalf("RemoveServerByID: %v", err)
+	}
+	if err := ring.AddServer(testNode("b")); err != nil {
+		t.Fatalf("AddServer: %v", err)
+	}
+	if ring.IsDown("b") {
+		t.Fatal("a re-added node is still marked down")
+	}
+}
@@ -0,0 +1,45 @@
+package replicationhashing
+
+import "fmt"
+
+// HierarchicalRing routes a key in two steps: first to a data center using a
+// ring whose nodes are data centers, then to a node within that data
+// center's own ring. Changes inside one data center never affect which data
+// center a key is routed to.
+type HierarchicalRing struct {
+	dcRing *HashRing
+	perDC  map[string]*HashRing
+}
+
+// NewHierarchicalRing builds a two-level router. The identifiers of the nodes
+// on dcRing must match the keys of perDC.
+func NewHierarchicalRing(dcRing *HashRing, perDC map[string]*HashRing) *HierarchicalRing {
+	rings := make(map[string]*HashRing, len(perDC))
+	for dc, ring := range perDC {
+		rings[dc] = ring
+	}
+	return &HierarchicalRing{
+		dcRing: dcRing,
+		perDC:  rings,
+	}
+}
+
+// Route returns the data center and node responsible for key.
+func (r *HierarchicalRing) Route(key string) (string, ICacheNode, error) {
+	dcNode, err := r.dcRing.GetServer(key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	dc := dcNode.GetIdentifier()
+	ring, ok := r.perDC[dc]
+	if !ok {
+		return dc, nil, fmt.Errorf("%w: no ring for data center %s", ErrNodeNotFound, dc)
+	}
+
+	node, err := ring.GetServer(key)
+	if err != nil {
+		return dc, nil, err
+	}
+	return dc, node, nil
+}
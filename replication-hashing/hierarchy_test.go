@@ -0,0 +1,66 @@
+package replicationhashing
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHierarchicalRing(t *testing.T) {
+	perDC := map[string]*HashRing{
+		"eu": newTestRing(t, []string{"eu-1", "eu-2", "eu-3"}),
+		"us": newTestRing(t, []string{"us-1", "us-2", "us-3"}),
+	}
+	router := NewHierarchicalRing(newTestRing(t, []string{"eu", "us"}), perDC)
+
+	type route struct{ dc, node string }
+	keys := sampleKeys(500)
+	routes := make(map[string]route, len(keys))
+	for _, key := range keys {
+		dc, node, err := router.Route(key)
+		if err != nil {
+			t.Fatalf("Route(%q): %v", key, err)
+		}
+		if !strings.HasPrefix(node.GetIdentifier(), dc+"-") {
+			t.Fatalf("key %q: routed to %s outside data center %s", key, node.GetIdentifier(), dc)
+		}
+		routes[key] = route{dc, node.GetIdentifier()}
+	}
+	for _, key := range keys[:50] {
+		dc, node, _ := router.Route(key)
+		if (route{dc, node.GetIdentifier()}) != routes[key] {
+			t.Fatalf("key %q: route changed between calls", key)
+		}
+	}
+
+	if err := perDC["eu"].AddServer(testNode("eu-4")); err != nil {
+		t.Fatalf("AddServer: %v", err)
+	}
+	movedNode := 0
+	for _, key := range keys {
+		dc, node, err := router.Route(key)
+		if err != nil {
+			t.Fatalf("Route(%q): %v", key, err)
+		}
+		if dc != routes[key].dc {
+			t.Fatalf("key %q: data center moved from %s to %s after a node joined eu", key, routes[key].dc, dc)
+		}
+		if node.GetIdentifier() != routes[key].node {
+			if dc != "eu" {
+				t.Fatalf("key %q: node in %s moved after a node joined eu", key, dc)
+			}
+			movedNode++
+		}
+	}
+	if movedNode == 0 {
+		t.Fatal("no key moved to the new node, so the test proves nothing")
+	}
+}
+
+func TestHierarchicalRingMissingDC(t *testing.T) {
+	router := NewHierarchicalRing(newTestRing(t, []string{"eu"}), map[string]*HashRing{})
+	dc, _, err := router.Route("k")
+	if dc != "eu" || !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("got %q, %v; want eu with ErrNodeNotFound", dc, err)
+	}
+}
@@ -0,0 +1,37 @@
+package replicationhashing
+
+import "sync/atomic"
+
+// RingHolder publishes a complete HashRing to readers through an atomic
+// pointer. Reconfiguration builds a fresh ring off to the side and swaps it
+// in with Store, so readers calling Load never see a partially built ring
+// and the swap itself takes no locks.
+type RingHolder struct {
+	ring atomic.Pointer[HashRing]
+}
+
+// NewRingHolder returns a holder publishing ring, which may be nil.
+func NewRingHolder(ring *HashRing) *RingHolder {
+	holder := &RingHolder{}
+	holder.ring.Store(ring)
+	return holder
+}
+
+// Load returns the currently published ring.
+func (r *RingHolder) Load() *HashRing {
+	return r.ring.Load()
+}
+
+// Store publishes ring. It should be fully populated before being stored.
+func (r *RingHolder) Store(ring *HashRing) {
+	r.ring.Store(ring)
+}
+
+// GetServer routes key on the currently published ring.
+func (r *RingHolder) GetServer(key string) (ICacheNode, error) {
+	ring := r.ring.Load()
+	if ring == nil {
+		return nil, ErrNoConnectedNodes
+	}
+	return ring.GetServer(key)
+}
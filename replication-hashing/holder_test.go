@@ -0,0 +1,78 @@
+package replicationhashing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRingHolderSwap(t *testing.T) {
+	const nodesPerRing, generations = 8, 50
+	build := func(gen int) *HashRing {
+		ids := make([]string, nodesPerRing)
+		for i := range ids {
+			ids[i] = fmt.Sprintf("gen%d-node%d", gen, i)
+		}
+		return newTestRing(t, ids, SetVirtualNodes(10))
+	}
+	holder := NewRingHolder(build(0))
+
+	stop := make(chan struct{})
+	errs := make(chan error, 4)
+	var wg sync.WaitGroup
+	for r := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			keys := sampleKeys(64)
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					errs <- nil
+					return
+				default:
+				}
+				ring := holder.Load()
+				if n := ring.NodeCount(); n != nodesPerRing {
+					errs <- fmt.Errorf("reader %d saw a ring with %d nodes", r, n)
+					return
+				}
+				node, err := ring.GetServer(keys[i%len(keys)])
+				if err != nil {
+					errs <- fmt.Errorf("reader %d: %v", r, err)
+					return
+				}
+				gen, _, _ := strings.Cut(ring.MemberIDs()[0], "-")
+				if !strings.HasPrefix(node.GetIdentifier(), gen+"-") {
+					errs <- fmt.Errorf("reader %d: %s routed to %s of another ring", r, gen, node.GetIdentifier())
+					return
+				}
+			}
+		}()
+	}
+
+	for gen := 1; gen <= generations; gen++ {
+		holder.Store(build(gen))
+	}
+	close(stop)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := holder.Load().MemberIDs()[0]; !strings.HasPrefix(got, fmt.Sprintf("gen%d-", generations)) {
+		t.Fatalf("holder serves %s after the last swap", got)
+	}
+}
+
+func TestRingHolderNil(t *testing.T) {
+	holder := NewRingHolder(nil)
+	if _, err := holder.GetServer("k"); !errors.Is(err, ErrNoConnectedNodes) {
+		t.Fatalf("got %v, want ErrNoConnectedNodes", err)
+	}
+}
@@ -0,0 +1,81 @@
+package replicationhashing
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// IndexRing routes keys to positions in a fixed-size slice of backends, for
+// callers that keep their backends in an array rather than by identifier.
+// Build one per backend list and rebuild it when the list changes.
+type IndexRing struct {
+	ring      *HashRing
+	positions map[string]int // nodeId -> index into backends
+}
+
+// NewIndexRing builds a ring over backends with the given options.
+func NewIndexRing(backends []ICacheNode, opts ...HashRingConfigFn) (*IndexRing, error) {
+	ring, err := InitHashRingE(opts...)
+	if err != nil {
+		return nil, err
+	}
+	positions := make(map[string]int, len(backends))
+	for i, b := range backends {
+		if err := ring.AddServer(b); err != nil {
+			return nil, err
+		}
+		positions[b.GetIdentifier()] = i
+	}
+	return &IndexRing{ring: ring, positions: positions}, nil
+}
+
+// GetServerIndex returns the index into the backends the IndexRing was built
+// from of the node owning key. A key landing on a node that isn't one of the
+// backends, such as a DefaultNode or a WithNodes seed, gives -1 and
+// ErrNodeNotFound.
+func (r *IndexRing) GetServerIndex(key string) (int, error) {
+	node, err := r.ring.GetServer(key)
+	if err != nil {
+		return -1, err
+	}
+	i, ok := r.positions[node.GetIdentifier()]
+	if !ok {
+		return -1, fmt.Errorf("%w : %s is not a backend", ErrNodeNotFound, node.GetIdentifier())
+	}
+	return i, nil
+}
+
+// lastIndex caches the IndexRing for the backend list GetServerIndex saw
+// last, so callers routing against one fixed array build its ring once.
+var lastIndex struct {
+	mu    sync.Mutex
+	ids   []string
+	index *IndexRing
+}
+
+// GetServerIndex returns the index into backends of the node owning key on a
+// ring built from backends with the default options. The ring is cached for
+// the most recent backend list, compared by identifier in order, and rebuilt
+// when a different list is passed; callers alternating between lists or
+// needing options should hold their own IndexRing from NewIndexRing.
+func GetServerIndex(key string, backends []ICacheNode) (int, error) {
+	ids := make([]string, len(backends))
+	for i, b := range backends {
+		ids[i] = b.GetIdentifier()
+	}
+
+	lastIndex.mu.Lock()
+	if lastIndex.index == nil || !slices.Equal(lastIndex.ids, ids) {
+		index, err := NewIndexRing(backends)
+		if err != nil {
+			lastIndex.mu.Unlock()
+			return -1, err
+		}
+		lastIndex.ids, lastIndex.index = ids, index
+	}
+	index := lastIndex.index
+	lastIndex.mu.Unlock()
+
+	return index.GetServerIndex(key)
+}
@@ -0,0 +1,125 @@
+package replicationhashing
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIndexRing(t *testing.T) {
+	backends := testNodes("cache-0", "cache-1", "cache-2", "cache-3")
+	index, err := NewIndexRing(backends, SetVirtualNodes(20))
+	if err != nil {
+		t.Fatalf("NewIndexRing: %v", err)
+	}
+	ring := newTestRing(t, []string{"cache-0", "cache-1", "cache-2", "cache-3"}, SetVirtualNodes(20))
+
+	for _, key := range sampleKeys(500) {
+		i, err := index.GetServerIndex(key)
+		if err != nil {
+			t.Fatalf("GetServerIndex(%q): %v", key, err)
+		}
+		want, err := ring.GetServer(key)
+		if err != nil {
+			t.Fatalf("GetServer(%q): %v", key, err)
+		}
+		if backends[i].GetIdentifier() != want.GetIdentifier() {
+			t.Fatalf("key %q: index %d is %s, GetServer says %s", key, i, backends[i].GetIdentifier(), want.GetIdentifier())
+		}
+	}
+}
+
+func TestIndexRingErrors(t *testing.T) {
+	if _, err := NewIndexRing(testNodes("a", "a")); !errors.Is(err, ErrNodeExists) {
+		t.Fatalf("duplicate backend: got %v, want ErrNodeExists", err)
+	}
+	index, err := NewIndexRing(nil)
+	if err != nil {
+		t.Fatalf("NewIndexRing: %v", err)
+	}
+	if i, err := index.GetServerIndex("k"); i != -1 || !errors.Is(err, ErrNoConnectedNodes) {
+		t.Fatalf("empty: got %d, %v; want -1 with ErrNoConnectedNodes", i, err)
+	}
+}
+
+func TestIndexRingNonBackendOwner(t *testing.T) {
+	// the default node answers once the only backend loses its identifier
+	id := "x"
+	vanishing := []ICacheNode{vanishingNode{&id}}
+
+	tests := []struct {
+		name     string
+		backends []ICacheNode
+		opt      HashRingConfigFn
+		after    func()
+	}{
+		{"default node", vanishing, SetDefaultNode(testNode("fallback")), func() { id = "" }},
+		{"seed node", testNodes("a"), WithNodes(testNode("seed")), func() {}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index, err := NewIndexRing(tt.backends, SetVirtualNodes(20), tt.opt)
+			if err != nil {
+				t.Fatalf("NewIndexRing: %v", err)
+			}
+			tt.after()
+
+			outside := 0
+			for _, key := range sampleKeys(200) {
+				i, err := index.GetServerIndex(key)
+				if err != nil {
+					if i != -1 || !errors.Is(err, ErrNodeNotFound) {
+						t.Fatalf("GetServerIndex(%q) = %d, %v; want -1 with ErrNodeNotFound", key, i, err)
+					}
+					outside++
+					continue
+				}
+				if i < 0 || i >= len(tt.backends) {
+					t.Fatalf("GetServerIndex(%q) = %d, outside the %d backends", key, i, len(tt.backends))
+				}
+			}
+			if outside == 0 {
+				t.Fatal("no key landed outside the backends; the layout proves nothing")
+			}
+		})
+	}
+}
+
+func TestGetServerIndex(t *testing.T) {
+	backends := testNodes("cache-0", "cache-1", "cache-2")
+	ring := newTestRing(t, []string{"cache-0", "cache-1", "cache-2"})
+
+	for _, key := range sampleKeys(300) {
+		i, err := GetServerIndex(key, backends)
+		if err != nil {
+			t.Fatalf("GetServerIndex(%q): %v", key, err)
+		}
+		want, err := ring.GetServer(key)
+		if err != nil {
+			t.Fatalf("GetServer(%q): %v", key, err)
+		}
+		if backends[i].GetIdentifier() != want.GetIdentifier() {
+			t.Fatalf("key %q: index %d is %s, GetServer says %s", key, i, backends[i].GetIdentifier(), want.GetIdentifier())
+		}
+	}
+
+	// a different list rebuilds the cached ring
+	cached := lastIndex.index
+	reordered := testNodes("cache-2", "cache-0", "cache-1")
+	for _, key := range sampleKeys(100) {
+		i, err := GetServerIndex(key, reordered)
+		if err != nil {
+			t.Fatalf("GetServerIndex(%q): %v", key, err)
+		}
+		want, _ := ring.GetServer(key)
+		if reordered[i].GetIdentifier() != want.GetIdentifier() {
+			t.Fatalf("key %q: index %d into the reordered list is %s, GetServer says %s", key, i, reordered[i].GetIdentifier(), want.GetIdentifier())
+		}
+	}
+	if lastIndex.index == cached {
+		t.Fatal("GetServerIndex kept the ring cached for another backend list")
+	}
+
+	if i, err := GetServerIndex("k", testNodes("a", "a")); i != -1 || !errors.Is(err, ErrNodeExists) {
+		t.Fatalf("duplicate backend: got %d, %v; want -1 with ErrNodeExists", i, err)
+	}
+}
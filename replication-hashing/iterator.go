@@ -0,0 +1,63 @@
+package replicationhashing
+
+import "fmt"
+
+// NodeIterator yields the distinct physical nodes for a key in ring order,
+// owner first. The order is fixed when the iterator is created, so topology
+// changes made while iterating cause neither duplicates nor skips.
+type NodeIterator struct {
+	nodes []ICacheNode
+	next  int
+}
+
+// Iter returns an iterator over key's fallback candidates, e.g. for
+// retrying a request on the next node after the owner fails.
+func (h *HashRing) Iter(key string) (*NodeIterator, error) {
+	if err := h.lockForLookup(); err != nil {
+		return nil, err
+	}
+	defer h.mu.RUnlock()
+
+	hashValue, err := h.hashKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w : %s", ErrInHashingKey, key)
+	}
+
+	positions := h.lookupPositions()
+	index, err := h.search(positions, hashValue)
+	if err != nil {
+		return nil, err
+	}
+	return &NodeIterator{nodes: successors(positions, index, len(positions))}, nil
+}
+
+// Next returns the next candidate, or false once every node was produced.
+func (it *NodeIterator) Next() (ICacheNode, bool) {
+	if it.next >= len(it.nodes) {
+		return nil, false
+	}
+	node := it.nodes[it.next]
+	it.next++
+	return node, true
+}
+
+// GetServerWithFallback returns the first node clockwise from key for which
+// healthy returns true, trying every physical node once before failing with
+// ErrNoHealthyNodes. healthy runs against a snapshot without holding the
+// ring's lock, so a slow check never blocks AddServer.
+func (h *HashRing) GetServerWithFallback(key string, healthy func(ICacheNode) bool) (ICacheNode, error) {
+	if healthy == nil {
+		return nil, ErrNilPredicate
+	}
+
+	it, err := h.Iter(key)
+	if err != nil {
+		return nil, err
+	}
+	for node, ok := it.Next(); ok; node, ok = it.Next() {
+		if healthy(node) {
+			return node, nil
+		}
+	}
+	return nil, fmt.Errorf("%w for key %s", ErrNoHealthyNodes, key)
+}
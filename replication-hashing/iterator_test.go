@@ -0,0 +1,116 @@
+package replicationhashing
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// drain collects what is left of it.
+func drain(it *NodeIterator) []string {
+	var out []string
+	for node, ok := it.Next(); ok; node, ok = it.Next() {
+		out = append(out, node.GetIdentifier())
+	}
+	return out
+}
+
+func TestIter(t *testing.T) {
+	ring := newTokenRing(t, map[string][]uint64{"a": {100, 120}, "b": {200}, "c": {300}})
+
+	it, err := ring.Iter("150")
+	if err != nil {
+		t.Fatalf("Iter: %v", err)
+	}
+	if got := drain(it); !slices.Equal(got, []string{"b", "c", "a"}) {
+		t.Fatalf("Iter(150) yielded %v, want [b c a]", got)
+	}
+	if _, ok := it.Next(); ok {
+		t.Fatal("exhausted iterator yielded another node")
+	}
+
+	if _, err := newTestRing(t, nil).Iter("k"); !errors.Is(err, ErrNoConnectedNodes) {
+		t.Fatalf("Iter on an empty ring: got %v, want ErrNoConnectedNodes", err)
+	}
+}
+
+func TestIterIgnoresLaterChanges(t *testing.T) {
+	ring := newTokenRing(t, map[string][]uint64{"a": {100, 120}, "b": {200}, "c": {300}})
+
+	it, err := ring.Iter("150")
+	if err != nil {
+		t.Fatalf("Iter: %v", err)
+	}
+	first, _ := it.Next()
+
+	// c leaves and d joins right behind b while the caller is iterating
+	if err := ring.RemoveServerByID("c"); err != nil {
+		t.Fatalf("RemoveServerByID: %v", err)
+	}
+	if err := ring.AddServerWithTokens(testNode("d"), []uint64{250}); err != nil {
+		t.Fatalf("AddServerWithTokens: %v", err)
+	}
+
+	got := append([]string{first.GetIdentifier()}, drain(it)...)
+	if !slices.Equal(got, []string{"b", "c", "a"}) {
+		t.Fatalf("iteration across changes yielded %v, want the snapshot [b c a]", got)
+	}
+}
+
+func TestGetServerWithFallback(t *testing.T) {
+	ring := newTokenRing(t, map[string][]uint64{"a": {100, 120}, "b": {200}, "c": {300}})
+	down := map[string]bool{"b": true}
+	healthy := func(n ICacheNode) bool { return !down[n.GetIdentifier()] }
+
+	node, err := ring.GetServerWithFallback("150", healthy)
+	if err != nil || node.GetIdentifier() != "c" {
+		t.Fatalf("GetServerWithFallback(150) = %v, %v; want c", node, err)
+	}
+
+	// the walk wraps past the last token
+	down["c"] = true
+	node, err = ring.GetServerWithFallback("150", healthy)
+	if err != nil || node.GetIdentifier() != "a" {
+		t.Fatalf("GetServerWithFallback(150) = %v, %v; want a", node, err)
+	}
+
+	down["a"] = true
+	if _, err := ring.GetServerWithFallback("150", healthy); !errors.Is(err, ErrNoHealthyNodes) {
+		t.Fatalf("every node down: got %v, want ErrNoHealthyNodes", err)
+	}
+	if _, err := ring.GetServerWithFallback("150", nil); !errors.Is(err, ErrNilPredicate) {
+		t.Fatalf("nil predicate: got %v, want ErrNilPredicate", err)
+	}
+}
+
+func TestGetServerWithFallbackDoesNotHoldLock(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c"})
+
+	calls := 0
+	healthy := func(ICacheNode) bool {
+		calls++
+		if calls > 1 {
+			return true
+		}
+		// a slow health check must not keep writers out
+		done := make(chan error, 1)
+		go func() { done <- ring.AddServer(testNode("d")) }()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("AddServer: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("AddServer blocked while the health check ran")
+		}
+		return false
+	}
+
+	if _, err := ring.GetServerWithFallback("k", healthy); err != nil {
+		t.Fatalf("GetServerWithFallback: %v", err)
+	}
+	if !ring.HasNode("d") {
+		t.Fatal("the add made during the health check is missing")
+	}
+}
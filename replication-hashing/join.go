@@ -0,0 +1,99 @@
+package replicationhashing
+
+import (
+	"log"
+	"time"
+)
+
+// EnableGradualJoin makes new nodes join the ring step virtual nodes at a
+// time: adding a node places only its first step tokens and the rest follow
+// in batches of step every interval, so keys shift to it gradually instead
+// of causing a burst of cache misses. Removing the node mid-join stops the
+// warm-up and drops whatever tokens were placed. A step below 1 disables it.
+func EnableGradualJoin(step int, interval time.Duration) HashRingConfigFn {
+	return func(config *hashRingConfig) {
+		config.JoinStep = step
+		config.JoinInterval = interval
+	}
+}
+
+// JoinProgress reports how many virtual nodes nodeID has placed and how many
+// it is growing to. Both are equal once the node has fully joined; both are
+// zero for unknown nodes.
+func (h *HashRing) JoinProgress(nodeID string) (current, target int) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	val, ok := h.hostMap.Load(nodeID)
+	if !ok {
+		return 0, 0
+	}
+	m := val.(*member)
+	if m.target == 0 {
+		return len(m.tokens), len(m.tokens)
+	}
+	return len(m.tokens), m.target
+}
+
+// gradualJoin reports whether adds should place tokens in batches.
+func (h *HashRing) gradualJoin() bool {
+	return h.config.JoinStep > 0 && h.config.JoinInterval > 0
+}
+
+// continueJoin adds the rest of m's tokens in the background until it
+// reaches its target or leaves the ring.
+func (h *HashRing) continueJoin(nodeId string, m *member) {
+	ticker := time.NewTicker(h.config.JoinInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !h.joinStep(nodeId, m) {
+			return
+		}
+	}
+}
+
+// joinStep places the next batch of m's tokens and reports whether more are
+// still to come.
+func (h *HashRing) joinStep(nodeId string, m *member) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// the node may have been removed, or removed and re-added, meanwhile
+	if val, ok := h.hostMap.Load(nodeId); !ok || val.(*member) != m || m.target == 0 {
+		return false
+	}
+
+	next := min(len(m.tokens)+h.config.JoinStep, m.target)
+	added := make([]position, 0, next-len(m.tokens))
+	own := make(map[uint64]struct{}, next-len(m.tokens))
+	for i := len(m.tokens); i < next; i++ {
+		hash, err := h.placeVNode(nodeId, i, func(hash uint64) bool {
+			_, ok := own[hash]
+			return ok || h.tokenTaken(hash)
+		})
+		if err != nil {
+			if h.config.EnableLogs {
+				log.Printf("[HashRing] Gradual join of %s stopped at %d virtual nodes: %v", nodeId, len(m.tokens), err)
+			}
+			m.target = 0
+			return false
+		}
+		own[hash] = struct{}{}
+		added = append(added, position{hash: hash, node: m.node, vnode: i})
+	}
+	for _, p := range added {
+		m.tokens = append(m.tokens, p.hash)
+	}
+	h.positions = insertPositions(h.positions, added)
+
+	if h.config.EnableLogs {
+		log.Printf("[HashRing] Node %s joining, %d of %d virtual nodes", nodeId, len(m.tokens), m.target)
+	}
+
+	if len(m.tokens) >= m.target {
+		m.target = 0
+		return false
+	}
+	return true
+}
@@ -0,0 +1,108 @@
+package replicationhashing
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// joiningMember returns the member record of a node still warming up.
+func joiningMember(t *testing.T, ring *HashRing, nodeID string) *member {
+	t.Helper()
+	val, ok := ring.hostMap.Load(nodeID)
+	if !ok {
+		t.Fatalf("%s is not a member", nodeID)
+	}
+	return val.(*member)
+}
+
+func TestGradualJoinSteps(t *testing.T) {
+	// an hour-long interval keeps the background warm-up out of the way so
+	// the test can drive each step itself
+	ring := newTestRing(t, []string{"a", "b"}, SetVirtualNodes(10), EnableGradualJoin(3, time.Hour))
+	seeded := len(ring.Tokens())
+	if err := ring.AddServer(testNode("n")); err != nil {
+		t.Fatalf("AddServer: %v", err)
+	}
+	m := joiningMember(t, ring, "n")
+
+	for _, want := range []int{3, 6, 9, 10} {
+		if current, target := ring.JoinProgress("n"); current != want || target != 10 {
+			t.Fatalf("JoinProgress = %d, %d; want %d, 10", current, target, want)
+		}
+		if got := len(ring.Tokens()); got != seeded+want {
+			t.Fatalf("ring has %d tokens with n at %d, want %d", got, want, seeded+want)
+		}
+		more := ring.joinStep("n", m)
+		if more != (want < 9) {
+			t.Fatalf("joinStep at %d reports more = %t", want, more)
+		}
+	}
+	if current, target := ring.JoinProgress("n"); current != 10 || target != 10 {
+		t.Fatalf("JoinProgress after the last step = %d, %d; want 10, 10", current, target)
+	}
+	if ring.joinStep("n", m) {
+		t.Fatal("joinStep kept going after the node fully joined")
+	}
+
+	// the finished node sits exactly where a plain add puts it
+	plain := newTestRing(t, []string{"a", "b", "n"}, SetVirtualNodes(10))
+	if !slices.Equal(memberTokens(t, ring, "n"), memberTokens(t, plain, "n")) {
+		t.Fatal("gradually joined tokens differ from a plain add")
+	}
+}
+
+func TestGradualJoinCompletesInBackground(t *testing.T) {
+	ring := newTestRing(t, []string{"a"}, SetVirtualNodes(12), EnableGradualJoin(2, time.Millisecond))
+	if err := ring.AddServer(testNode("n")); err != nil {
+		t.Fatalf("AddServer: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		current, target := ring.JoinProgress("n")
+		if current == 12 && target == 12 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("join stalled at %d of %d virtual nodes", current, target)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestGradualJoinCancelledByRemove(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b"}, SetVirtualNodes(10), EnableGradualJoin(3, time.Hour))
+	before := ring.Tokens()
+
+	if err := ring.AddServer(testNode("n")); err != nil {
+		t.Fatalf("AddServer: %v", err)
+	}
+	m := joiningMember(t, ring, "n")
+	ring.joinStep("n", m)
+	if current, _ := ring.JoinProgress("n"); current != 6 {
+		t.Fatalf("n placed %d virtual nodes, want 6 before the remove", current)
+	}
+
+	if err := ring.RemoveServerByID("n"); err != nil {
+		t.Fatalf("RemoveServerByID: %v", err)
+	}
+	if !slices.Equal(ring.Tokens(), before) {
+		t.Fatal("tokens from the cancelled join are still on the ring")
+	}
+	if current, target := ring.JoinProgress("n"); current != 0 || target != 0 {
+		t.Fatalf("JoinProgress after remove = %d, %d; want 0, 0", current, target)
+	}
+	if ring.joinStep("n", m) {
+		t.Fatal("the cancelled warm-up kept going")
+	}
+
+	// a warm-up left over from the first add must not advance a re-added node
+	if err := ring.AddServer(testNode("n")); err != nil {
+		t.Fatalf("AddServer again: %v", err)
+	}
+	ring.joinStep("n", m)
+	if current, target := ring.JoinProgress("n"); current != 3 || target != 10 {
+		t.Fatalf("re-added node at %d, %d; want 3, 10", current, target)
+	}
+}
@@ -0,0 +1,99 @@
+package replicationhashing
+
+import (
+	"bytes"
+	"strings"
+)
+
+// EnableHashTags makes lookups hash only the part of a key between the first
+// '{' and the next '}', as Redis Cluster does, so keys sharing a tag such as
+// order:{cust42}:items and order:{cust42}:total land on the same node. Keys
+// without a tag, or with an empty one, are hashed whole.
+func EnableHashTags(enabled bool) HashRingConfigFn {
+	return func(config *hashRingConfig) {
+		config.HashTags = enabled
+	}
+}
+
+// SetKeyNormalizer rewrites every lookup key with normalize before it is
+// hashed, e.g. DefaultKeyNormalizer so "User:1001 " and "user:1001" share a
+// node. Node identifiers are never normalized. nil disables normalization.
+func SetKeyNormalizer(normalize func(string) string) HashRingConfigFn {
+	return func(config *hashRingConfig) {
+		config.KeyNormalizer = normalize
+	}
+}
+
+// DefaultKeyNormalizer trims surrounding white space and lowercases ASCII
+// letters.
+func DefaultKeyNormalizer(key string) string {
+	key = strings.TrimSpace(key)
+	for i := 0; i < len(key); i++ {
+		if c := key[i]; 'A' <= c && c <= 'Z' {
+			lowered := []byte(key)
+			for j := i; j < len(lowered); j++ {
+				if c := lowered[j]; 'A' <= c && c <= 'Z' {
+					lowered[j] = c + ('a' - 'A')
+				}
+			}
+			return string(lowered)
+		}
+	}
+	return key
+}
+
+// normalizeKey applies the key normalizer, if any, to a lookup key.
+func (h *HashRing) normalizeKey(key string) string {
+	if h.config.KeyNormalizer != nil {
+		return h.config.KeyNormalizer(key)
+	}
+	return key
+}
+
+// hashKey hashes a lookup key, applying the key normalizer and hash tags
+// when configured. While a paused view is being served the key is hashed the
+// way the view was, so it lands on the layout it is looked up in. Node
+// identifiers are hashed with generateHash directly. Callers must hold h.mu.
+func (h *HashRing) hashKey(key string) (uint64, error) {
+	if h.pausedView != nil {
+		return h.pausedView.hashKey(key)
+	}
+	key = h.normalizeKey(key)
+	if h.config.HashTags {
+		key = hashTag(key)
+	}
+	return h.generateHash(key)
+}
+
+func (h *HashRing) hashKeyBytes(key []byte) (uint64, error) {
+	if h.pausedView != nil {
+		return h.pausedView.hashKey(string(key))
+	}
+	if h.config.KeyNormalizer != nil {
+		key = []byte(h.config.KeyNormalizer(string(key)))
+	}
+	if h.config.HashTags {
+		key = hashTagBytes(key)
+	}
+	return h.generateHashBytes(key)
+}
+
+// hashTag returns the non-empty substring between the first '{' and the
+// next '}', or key itself.
+func hashTag(key string) string {
+	if open := strings.IndexByte(key, '{'); open >= 0 {
+		if end := strings.IndexByte(key[open+1:], '}'); end > 0 {
+			return key[open+1 : open+1+end]
+		}
+	}
+	return key
+}
+
+func hashTagBytes(key []byte) []byte {
+	if open := bytes.IndexByte(key, '{'); open >= 0 {
+		if end := bytes.IndexByte(key[open+1:], '}'); end > 0 {
+			return key[open+1 : open+1+end]
+		}
+	}
+	return key
+}
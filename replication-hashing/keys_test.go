@@ -0,0 +1,173 @@
+package replicationhashing
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var hashTagCases = []struct{ key, want string }{
+	{"order:{cust42}:items", "cust42"},
+	{"{cust42}", "cust42"},
+	{"plain-key", "plain-key"},
+	{"empty:{}:tag", "empty:{}:tag"},
+	{"{}{cust42}", "{}{cust42}"},
+	{"nested:{{cust42}}", "{cust42"},
+	{"open:{cust42", "open:{cust42"},
+	{"close:}cust42{", "close:}cust42{"},
+	{"first:{a}:then:{b}", "a"},
+}
+
+func TestHashTag(t *testing.T) {
+	for _, tt := range hashTagCases {
+		if got := hashTag(tt.key); got != tt.want {
+			t.Errorf("hashTag(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+		if got := string(hashTagBytes([]byte(tt.key))); got != tt.want {
+			t.Errorf("hashTagBytes(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestEnableHashTags(t *testing.T) {
+	fleet := []string{"a", "b", "c", "d", "e"}
+	tagged := newTestRing(t, fleet, SetVirtualNodes(20), EnableHashTags(true))
+	untagged := newTestRing(t, fleet, SetVirtualNodes(20))
+	view := tagged.FrozenView()
+
+	lookup := func(r *HashRing, key string) string {
+		t.Helper()
+		node, err := r.GetServer(key)
+		if err != nil {
+			t.Fatalf("GetServer(%q): %v", key, err)
+		}
+		return node.GetIdentifier()
+	}
+
+	spread := make(map[string]bool)
+	for i := range 50 {
+		tag := fmt.Sprintf("cust%d", i)
+		want := lookup(tagged, tag)
+		for _, key := range []string{"order:{" + tag + "}:items", "order:{" + tag + "}:total"} {
+			if got := lookup(tagged, key); got != want {
+				t.Errorf("GetServer(%q) = %s, want %s like its tag", key, got, want)
+			}
+			node, err := tagged.GetServerBytes([]byte(key))
+			if err != nil {
+				t.Fatalf("GetServerBytes(%q): %v", key, err)
+			}
+			if node.GetIdentifier() != want {
+				t.Errorf("GetServerBytes(%q) = %s, want %s", key, node.GetIdentifier(), want)
+			}
+			node, err = view.Get(key)
+			if err != nil {
+				t.Fatalf("RingView.Get(%q): %v", key, err)
+			}
+			if node.GetIdentifier() != want {
+				t.Errorf("RingView.Get(%q) = %s, want %s", key, node.GetIdentifier(), want)
+			}
+		}
+
+		// keys without a usable tag are hashed whole, as without the option
+		for _, key := range []string{tag, tag + ":{}", tag + ":{open"} {
+			if got, want := lookup(tagged, key), lookup(untagged, key); got != want {
+				t.Errorf("GetServer(%q) = %s with hash tags, %s without", key, got, want)
+			}
+		}
+		spread[lookup(untagged, fmt.Sprintf("user:{cust42}:%d", i))] = true
+	}
+	if len(spread) < 2 {
+		t.Errorf("keys sharing a tag all land on %v without hash tags", spread)
+	}
+}
+
+func TestDefaultKeyNormalizer(t *testing.T) {
+	tests := []struct{ key, want string }{
+		{"User:1001 ", "user:1001"},
+		{"\tSESSION:Abc\n", "session:abc"},
+		{"already:lower", "already:lower"},
+		{"Ünïcode:KEY", "Ünïcode:key"},
+		{"   ", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := DefaultKeyNormalizer(tt.key); got != tt.want {
+			t.Errorf("DefaultKeyNormalizer(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestSetKeyNormalizer(t *testing.T) {
+	fleet := []string{"Cache-A", "Cache-B", "Cache-C", "Cache-D"}
+	normalized := newTestRing(t, fleet, SetVirtualNodes(20), SetKeyNormalizer(DefaultKeyNormalizer))
+	plain := newTestRing(t, fleet, SetVirtualNodes(20))
+	lookup := func(r *HashRing, key string) string {
+		t.Helper()
+		node, err := r.GetServer(key)
+		if err != nil {
+			t.Fatalf("GetServer(%q): %v", key, err)
+		}
+		return node.GetIdentifier()
+	}
+
+	if a, b := lookup(normalized, "User:1001 "), lookup(normalized, "user:1001"); a != b {
+		t.Errorf("with the normalizer %q is on %s and %q on %s", "User:1001 ", a, "user:1001", b)
+	}
+	if a, b := lookup(plain, "User:1001 "), lookup(plain, "user:1001"); a == b {
+		t.Errorf("without the normalizer both spellings land on %s", a)
+	}
+
+	for _, key := range sampleKeys(200) {
+		messy := " " + strings.ToUpper(key) + "\t"
+		if got, want := lookup(normalized, messy), lookup(plain, key); got != want {
+			t.Fatalf("GetServer(%q) = %s, want %s like %q", messy, got, want, key)
+		}
+		node, err := normalized.GetServerBytes([]byte(messy))
+		if err != nil {
+			t.Fatalf("GetServerBytes(%q): %v", messy, err)
+		}
+		if got := node.GetIdentifier(); got != lookup(plain, key) {
+			t.Fatalf("GetServerBytes(%q) = %s, want %s", messy, got, lookup(plain, key))
+		}
+	}
+
+	// node identifiers keep their case and their tokens
+	if !normalized.HasNode("Cache-A") || normalized.HasNode("cache-a") {
+		t.Error("the normalizer was applied to node identifiers")
+	}
+	if !slices.Equal(normalized.Tokens(), plain.Tokens()) {
+		t.Error("the normalizer moved node tokens")
+	}
+}
+
+func TestSetKeyNormalizerNil(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c"}, SetKeyNormalizer(nil))
+	plain := newTestRing(t, []string{"a", "b", "c"})
+	if !maps.Equal(owners(t, ring, sampleKeys(200)), owners(t, plain, sampleKeys(200))) {
+		t.Fatal("a nil normalizer changed placement")
+	}
+}
+
+func TestPinKeyIsNormalized(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c"}, SetVirtualNodes(10), SetKeyNormalizer(DefaultKeyNormalizer))
+	key, _ := pinTarget(t, ring, "a")
+
+	if err := ring.PinKey(" "+strings.ToUpper(key), "a"); err != nil {
+		t.Fatalf("PinKey: %v", err)
+	}
+	for _, spelling := range []string{key, strings.ToUpper(key), key + " "} {
+		if got := owners(t, ring, []string{spelling})[spelling]; got != "a" {
+			t.Errorf("GetServer(%q) = %s, want pinned node a", spelling, got)
+		}
+	}
+	if pins := ring.Pins(); !maps.Equal(pins, map[string]string{key: "a"}) {
+		t.Errorf("Pins() = %v, want the normalized key", pins)
+	}
+
+	ring.UnpinKey(strings.ToUpper(key))
+	if len(ring.Pins()) != 0 {
+		t.Errorf("Pins() = %v after unpinning another spelling", ring.Pins())
+	}
+}
@@ -0,0 +1,44 @@
+package replicationhashing
+
+import "sync/atomic"
+
+// GetServerWithLoad routes key like GetServer, counts the lookup against the
+// chosen node and returns that node's share of all keys routed this way.
+// Callers can use the share for client-side load shedding. Counters of
+// removed nodes are dropped, so shares always sum to 1 across current nodes.
+// A node that isn't a member, such as a DefaultNode answering, is returned
+// uncounted with a share of 0.
+func (h *HashRing) GetServerWithLoad(key string) (ICacheNode, float64, error) {
+	if err := h.lockForLookup(); err != nil {
+		return nil, 0, err
+	}
+	defer h.mu.RUnlock()
+
+	node, err := h.serverForKey(key)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// counting under the read lock keeps a concurrent removal from
+	// dropping the counter between the lookup and the increment
+	id := node.GetIdentifier()
+	if _, member := h.hostMap.Load(id); !member {
+		return node, 0, nil
+	}
+	count := h.loadOf(id).Add(1)
+	total := h.loadTotal.Add(1)
+	return node, float64(count) / float64(total), nil
+}
+
+func (h *HashRing) loadOf(id string) *atomic.Uint64 {
+	counter, _ := h.load.LoadOrStore(id, new(atomic.Uint64))
+	return counter.(*atomic.Uint64)
+}
+
+// forgetLoad drops a removed node's counter from the totals. Callers must
+// hold h.mu for writing.
+func (h *HashRing) forgetLoad(id string) {
+	if counter, ok := h.load.LoadAndDelete(id); ok {
+		h.loadTotal.Add(-counter.(*atomic.Uint64).Load())
+	}
+}
@@ -0,0 +1,123 @@
+package replicationhashing
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// loadShares reads every node's current share of routed keys.
+func loadShares(ring *HashRing) map[string]float64 {
+	shares := make(map[string]float64)
+	total := float64(ring.loadTotal.Load())
+	ring.load.Range(func(key, val any) bool {
+		shares[key.(string)] = float64(val.(*atomic.Uint64).Load()) / total
+		return true
+	})
+	return shares
+}
+
+func TestGetServerWithLoad(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c"}, SetVirtualNodes(20))
+
+	counts := make(map[string]int)
+	keys := sampleKeys(900)
+	for i, key := range keys {
+		node, share, err := ring.GetServerWithLoad(key)
+		if err != nil {
+			t.Fatalf("GetServerWithLoad(%q): %v", key, err)
+		}
+		id := node.GetIdentifier()
+		counts[id]++
+		if want := float64(counts[id]) / float64(i+1); share != want {
+			t.Fatalf("key %d: %s has share %.4f, want %.4f", i, id, share, want)
+		}
+	}
+
+	sum := 0.0
+	for id, share := range loadShares(ring) {
+		if want := float64(counts[id]) / float64(len(keys)); share != want {
+			t.Errorf("%s: share %.4f, want %.4f", id, share, want)
+		}
+		sum += share
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Fatalf("shares sum to %f", sum)
+	}
+
+	// a removed node's keys leave the totals with it
+	if err := ring.RemoveServerByID("a"); err != nil {
+		t.Fatalf("RemoveServerByID: %v", err)
+	}
+	sum = 0
+	for id, share := range loadShares(ring) {
+		if id == "a" {
+			t.Fatal("removed node still has a load counter")
+		}
+		sum += share
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Fatalf("shares sum to %f after a removal", sum)
+	}
+}
+
+func TestGetServerWithLoadSkipsNonMembers(t *testing.T) {
+	id := "x"
+	ring := newTestRing(t, nil, SetDefaultNode(testNode("fallback")))
+	if err := ring.AddServer(vanishingNode{&id}); err != nil {
+		t.Fatalf("AddServer: %v", err)
+	}
+	id = ""
+
+	node, share, err := ring.GetServerWithLoad("key")
+	if err != nil {
+		t.Fatalf("GetServerWithLoad: %v", err)
+	}
+	if node.GetIdentifier() != "fallback" || share != 0 {
+		t.Fatalf("GetServerWithLoad = %s with share %.2f, want the default node with share 0", node.GetIdentifier(), share)
+	}
+	if total := ring.loadTotal.Load(); total != 0 {
+		t.Fatalf("default node lookups counted %d times", total)
+	}
+}
+
+func TestGetServerWithLoadDuringRemoval(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c"}, SetVirtualNodes(20))
+	keys := sampleKeys(200)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 50 {
+			if err := ring.RemoveServerByID("a"); err != nil {
+				t.Errorf("RemoveServerByID: %v", err)
+				return
+			}
+			if err := ring.AddServer(testNode("a")); err != nil {
+				t.Errorf("AddServer: %v", err)
+				return
+			}
+		}
+	}()
+	for range 20 {
+		for _, key := range keys {
+			if _, _, err := ring.GetServerWithLoad(key); err != nil {
+				t.Fatalf("GetServerWithLoad(%q): %v", key, err)
+			}
+		}
+	}
+	wg.Wait()
+
+	// a count landing after its node's counter was dropped would leave the
+	// totals out of step with the counters
+	sum := uint64(0)
+	ring.load.Range(func(_, val any) bool {
+		sum += val.(*atomic.Uint64).Load()
+		return true
+	})
+	if total := ring.loadTotal.Load(); sum != total {
+		t.Fatalf("counters sum to %d, total is %d", sum, total)
+	}
+}
@@ -0,0 +1,332 @@
+package replicationhashing
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// vanishingNode loses its identifier once cleared, like a wrapper whose
+// underlying connection was released.
+type vanishingNode struct{ id *string }
+
+func (n vanishingNode) GetIdentifier() string { return *n.id }
+
+func TestGetServerSkipsNodesWithoutIdentifier(t *testing.T) {
+	ring := newTokenRing(t, map[string][]uint64{"a": {200}, "b": {300}})
+	id := "x"
+	if err := ring.AddServerWithTokens(vanishingNode{&id}, []uint64{100}); err != nil {
+		t.Fatalf("AddServerWithTokens: %v", err)
+	}
+	id = ""
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"50", "a"},  // x's arc falls through to the next valid node
+		{"100", "a"}, // x's own token
+		{"150", "a"},
+		{"250", "b"},
+		{"400", "a"}, // wraps past x
+	}
+	for _, tt := range tests {
+		node, err := ring.GetServer(tt.key)
+		if err != nil {
+			t.Fatalf("GetServer(%s): %v", tt.key, err)
+		}
+		if node.GetIdentifier() != tt.want {
+			t.Errorf("GetServer(%s) = %q, want %s", tt.key, node.GetIdentifier(), tt.want)
+		}
+	}
+}
+
+func TestGetServerByHash(t *testing.T) {
+	ring := newTokenRing(t, map[string][]uint64{"a": {100, 300}, "b": {200}})
+
+	tests := []struct {
+		hash uint64
+		want string
+	}{
+		{0, "a"},
+		{100, "a"}, // a token owns its own position
+		{101, "b"},
+		{200, "b"},
+		{250, "a"},
+		{301, "a"}, // past the last token wraps to the first
+		{^uint64(0), "a"},
+	}
+	for _, tt := range tests {
+		node, err := ring.GetServerByHash(tt.hash)
+		if err != nil {
+			t.Fatalf("GetServerByHash(%d): %v", tt.hash, err)
+		}
+		if node.GetIdentifier() != tt.want {
+			t.Errorf("GetServerByHash(%d) = %s, want %s", tt.hash, node.GetIdentifier(), tt.want)
+		}
+		// numericHash makes the key's hash its own value
+		viaKey, err := ring.GetServer(strconv.FormatUint(tt.hash, 10))
+		if err != nil {
+			t.Fatalf("GetServer: %v", err)
+		}
+		if viaKey.GetIdentifier() != node.GetIdentifier() {
+			t.Errorf("GetServer and GetServerByHash disagree at %d: %s vs %s", tt.hash, viaKey.GetIdentifier(), node.GetIdentifier())
+		}
+	}
+}
+
+func TestOwnerOfHash(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c", "d"}, SetVirtualNodes(20))
+
+	for _, key := range sampleKeys(500) {
+		hashValue, err := ring.hashKey(key)
+		if err != nil {
+			t.Fatalf("hashKey(%q): %v", key, err)
+		}
+		owner, err := ring.OwnerOfHash(hashValue)
+		if err != nil {
+			t.Fatalf("OwnerOfHash(%d): %v", hashValue, err)
+		}
+		byHash, err := ring.GetServerByHash(hashValue)
+		if err != nil {
+			t.Fatalf("GetServerByHash(%d): %v", hashValue, err)
+		}
+		byKey, err := ring.GetServer(key)
+		if err != nil {
+			t.Fatalf("GetServer(%q): %v", key, err)
+		}
+		if owner.GetIdentifier() != byHash.GetIdentifier() || owner.GetIdentifier() != byKey.GetIdentifier() {
+			t.Fatalf("key %q at %d: OwnerOfHash = %s, GetServerByHash = %s, GetServer = %s",
+				key, hashValue, owner.GetIdentifier(), byHash.GetIdentifier(), byKey.GetIdentifier())
+		}
+	}
+
+	if _, err := InitHashRing().OwnerOfHash(42); !errors.Is(err, ErrNoConnectedNodes) {
+		t.Fatalf("OwnerOfHash on an empty ring = %v, want ErrNoConnectedNodes", err)
+	}
+}
+
+func TestGetServerByHashEmptyRing(t *testing.T) {
+	if _, err := InitHashRing().GetServerByHash(42); !errors.Is(err, ErrNoConnectedNodes) {
+		t.Fatalf("got %v, want ErrNoConnectedNodes", err)
+	}
+}
+
+func TestGetServerMatchesLinearScan(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c", "d", "e"}, SetVirtualNodes(40))
+
+	for _, key := range sampleKeys(2000) {
+		hashValue, err := ring.hashKey(key)
+		if err != nil {
+			t.Fatalf("hashKey(%q): %v", key, err)
+		}
+		// the owner is the lowest token at or after the hash, wrapping to
+		// the lowest token overall
+		want := ring.positions[0].node
+		for _, p := range ring.positions {
+			if p.hash >= hashValue {
+				want = p.node
+				break
+			}
+		}
+		got, err := ring.GetServer(key)
+		if err != nil {
+			t.Fatalf("GetServer(%q): %v", key, err)
+		}
+		if got.GetIdentifier() != want.GetIdentifier() {
+			t.Fatalf("key %q: GetServer says %s, the layout says %s", key, got.GetIdentifier(), want.GetIdentifier())
+		}
+	}
+}
+
+func BenchmarkGetServer(b *testing.B) {
+	ring := newTestRing(b, []string{"a", "b", "c", "d", "e"})
+	keys := sampleKeys(1024)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ring.GetServer(keys[i%len(keys)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetServerParallel(b *testing.B) {
+	ring := newTestRing(b, []string{"a", "b", "c", "d", "e"})
+	keys := sampleKeys(1024)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			if _, err := ring.GetServer(keys[i%len(keys)]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestGetServerConcurrentWithWriter(t *testing.T) {
+	policies := map[string]PausePolicy{"block": BlockUntilResume, "last view": ServeLastConsistentView}
+	for name, policy := range policies {
+		t.Run(name, func(t *testing.T) {
+			ring := newTestRing(t, []string{"a", "b", "c"}, SetVirtualNodes(20),
+				EnableGradualJoin(4, time.Millisecond), SetPausePolicy(policy))
+			keys := sampleKeys(256)
+			stop := make(chan struct{})
+
+			var wg sync.WaitGroup
+			for range 8 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; ; i++ {
+						select {
+						case <-stop:
+							return
+						default:
+						}
+						if _, err := ring.GetServer(keys[i%len(keys)]); err != nil {
+							t.Errorf("GetServer: %v", err)
+							return
+						}
+					}
+				}()
+			}
+
+			// joining nodes warm up in the background while the writer keeps
+			// changing membership, some of it inside a pause
+			for i := range 50 {
+				id := fmt.Sprintf("n%d", i)
+				if i%5 == 0 {
+					ring.Pause()
+				}
+				if err := ring.AddServer(testNode(id)); err != nil {
+					t.Fatalf("AddServer(%s): %v", id, err)
+				}
+				time.Sleep(time.Millisecond)
+				if i%2 == 0 {
+					if err := ring.RemoveServerByID(id); err != nil {
+						t.Fatalf("RemoveServerByID(%s): %v", id, err)
+					}
+				}
+				if i%5 == 4 {
+					ring.Resume()
+				}
+			}
+			close(stop)
+			wg.Wait()
+		})
+	}
+}
+
+func BenchmarkGetServerHashFunctions(b *testing.B) {
+	hashes := []struct {
+		name string
+		opt  HashRingConfigFn
+	}{
+		{"fnv64a", SetHashFunction(fnv.New64a)},
+		{"crc32", SetHasher(Hasher32(crc32.NewIEEE))},
+		{"mix64", SetHasher(mix64{})},
+	}
+	keys := sampleKeys(1024)
+
+	for _, hf := range hashes {
+		b.Run(hf.name, func(b *testing.B) {
+			ring := newTestRing(b, []string{"a", "b", "c", "d", "e"}, hf.opt)
+			stddev, _, _ := ring.SelfTest(10000)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := ring.GetServer(keys[i%len(keys)]); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(stddev, "share-stddev")
+		})
+	}
+}
+
+func TestGetServerBytes(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c", "d"})
+
+	for _, key := range sampleKeys(500) {
+		viaString, err := ring.GetServer(key)
+		if err != nil {
+			t.Fatalf("GetServer(%q): %v", key, err)
+		}
+		viaBytes, err := ring.GetServerBytes([]byte(key))
+		if err != nil {
+			t.Fatalf("GetServerBytes(%q): %v", key, err)
+		}
+		if viaString.GetIdentifier() != viaBytes.GetIdentifier() {
+			t.Fatalf("key %q: GetServer %s, GetServerBytes %s", key, viaString.GetIdentifier(), viaBytes.GetIdentifier())
+		}
+	}
+
+	// the byte path saves the conversions a string lookup needs
+	key := []byte("user:42")
+	viaBytes := testing.AllocsPerRun(100, func() { ring.GetServerBytes(key) })
+	viaString := testing.AllocsPerRun(100, func() { ring.GetServer(string(key)) })
+	if viaBytes >= viaString {
+		t.Fatalf("GetServerBytes allocates %v times per lookup, GetServer(string(key)) %v", viaBytes, viaString)
+	}
+}
+
+func BenchmarkGetServerBytes(b *testing.B) {
+	ring := newTestRing(b, []string{"a", "b", "c", "d", "e"})
+	key := []byte("user:42")
+
+	b.Run("string", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := ring.GetServer(string(key)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("bytes", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := ring.GetServerBytes(key); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestDefaultNode(t *testing.T) {
+	// every position belongs to a node that lost its identifier, so the
+	// walk finds no owner for a position that exists
+	ring := newTestRing(t, nil, SetHashFunction(newNumericHash), SetDefaultNode(testNode("fallback")))
+	plain := newTestRing(t, nil, SetHashFunction(newNumericHash))
+	for _, r := range []*HashRing{ring, plain} {
+		id := "x"
+		if err := r.AddServerWithTokens(vanishingNode{&id}, []uint64{100, 200}); err != nil {
+			t.Fatalf("AddServerWithTokens: %v", err)
+		}
+		id = ""
+	}
+
+	if _, err := plain.GetServer("150"); !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("without a default: got %v, want ErrNodeNotFound", err)
+	}
+	node, err := ring.GetServer("150")
+	if err != nil || node.GetIdentifier() != "fallback" {
+		t.Fatalf("GetServer = %v, %v; want the default node", node, err)
+	}
+	node, err = ring.GetServerBytes([]byte("150"))
+	if err != nil || node.GetIdentifier() != "fallback" {
+		t.Fatalf("GetServerBytes = %v, %v; want the default node", node, err)
+	}
+
+	// an empty ring still reports that nothing is connected
+	empty := newTestRing(t, nil, SetDefaultNode(testNode("fallback")))
+	if _, err := empty.GetServer("k"); !errors.Is(err, ErrNoConnectedNodes) {
+		t.Fatalf("empty ring: got %v, want ErrNoConnectedNodes", err)
+	}
+}
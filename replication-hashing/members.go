@@ -0,0 +1,88 @@
+package replicationhashing
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Members returns a snapshot of the ring's physical nodes sorted by
+// identifier. Virtual nodes are not listed.
+func (h *HashRing) Members() []ICacheNode {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.members()
+}
+
+// members is Members without the locking. Callers must hold h.mu.
+func (h *HashRing) members() []ICacheNode {
+	var members []ICacheNode
+	h.hostMap.Range(func(_, val any) bool {
+		members = append(members, val.(*member).node)
+		return true
+	})
+	slices.SortFunc(members, func(a, b ICacheNode) int {
+		return strings.Compare(a.GetIdentifier(), b.GetIdentifier())
+	})
+	return members
+}
+
+// MemberIDs is Members reduced to the sorted identifiers.
+func (h *HashRing) MemberIDs() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.memberIDs()
+}
+
+// memberIDs is MemberIDs without the locking. Callers must hold h.mu.
+func (h *HashRing) memberIDs() []string {
+	members := h.members()
+	ids := make([]string, len(members))
+	for i, node := range members {
+		ids[i] = node.GetIdentifier()
+	}
+	return ids
+}
+
+// NodeCount returns the number of physical nodes on the ring.
+func (h *HashRing) NodeCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.memberCount()
+}
+
+// VirtualNodeCount returns the number of tokens on the ring.
+func (h *HashRing) VirtualNodeCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.positions)
+}
+
+// HasNode reports whether a node with identifier id is on the ring.
+func (h *HashRing) HasNode(id string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	_, ok := h.hostMap.Load(id)
+	return ok
+}
+
+// UpdateServer replaces the stored object for node's identifier, e.g. after
+// a reconnect hands out a new object for the same host. Its tokens stay
+// where they are, so no keys move.
+func (h *HashRing) UpdateServer(node ICacheNode) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	nodeId := node.GetIdentifier()
+	val, ok := h.hostMap.Load(nodeId)
+	if !ok {
+		return fmt.Errorf("%w : %s", ErrNodeNotFound, nodeId)
+	}
+	val.(*member).node = node
+	for i := range h.positions {
+		if h.positions[i].node.GetIdentifier() == nodeId {
+			h.positions[i].node = node
+		}
+	}
+	return nil
+}
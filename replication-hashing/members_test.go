@@ -0,0 +1,229 @@
+package replicationhashing
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestMembersUnderConcurrentChanges(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b"}, SetVirtualNodes(5))
+	base := []string{"a", "b"}
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			id := fmt.Sprintf("tmp%d", i)
+			if err := ring.AddServer(testNode(id)); err != nil {
+				t.Errorf("AddServer(%s): %v", id, err)
+				return
+			}
+			if err := ring.RemoveServerByID(id); err != nil {
+				t.Errorf("RemoveServerByID(%s): %v", id, err)
+				return
+			}
+		}
+	}()
+
+	for range 500 {
+		members := ring.Members()
+		got := ids(members)
+		if !slices.IsSorted(got) || len(slices.Compact(slices.Clone(got))) != len(got) {
+			t.Fatalf("Members returned %v, want sorted and distinct", got)
+		}
+		for _, id := range base {
+			if !slices.Contains(got, id) {
+				t.Fatalf("Members returned %v without %s", got, id)
+			}
+		}
+		if memberIDs := ring.MemberIDs(); !slices.IsSorted(memberIDs) {
+			t.Fatalf("MemberIDs returned %v, want sorted", memberIDs)
+		}
+		// the snapshot is the caller's to keep
+		members[0] = nil
+	}
+	close(stop)
+	wg.Wait()
+
+	if got := ring.MemberIDs(); !slices.Equal(got, base) {
+		t.Fatalf("MemberIDs = %v, want %v", got, base)
+	}
+	if got := ids(ring.Members()); !slices.Equal(got, base) {
+		t.Fatalf("Members = %v, want %v", got, base)
+	}
+}
+
+func TestNodeCounts(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b"}, SetVirtualNodes(5))
+	check := func(step string, nodes int) {
+		t.Helper()
+		if got := ring.NodeCount(); got != nodes {
+			t.Fatalf("%s: NodeCount = %d, want %d", step, got, nodes)
+		}
+		if got := ring.VirtualNodeCount(); got != nodes*5 || got != len(ring.positions) {
+			t.Fatalf("%s: VirtualNodeCount = %d, want %d", step, got, nodes*5)
+		}
+		if got := len(ring.positions); got != nodes*5 {
+			t.Fatalf("%s: %d tokens, want %d", step, got, nodes*5)
+		}
+	}
+	check("initial", 2)
+
+	id := "tmp"
+	if err := ring.AddServer(testNode(id)); err != nil {
+		t.Fatalf("AddServer: %v", err)
+	}
+	check("after add", 3)
+
+	if err := ring.AddServer(testNode(id)); !errors.Is(err, ErrNodeExists) {
+		t.Fatalf("duplicate add: got %v, want ErrNodeExists", err)
+	}
+	check("after duplicate add", 3)
+
+	if err := ring.RemoveServerByID(id); err != nil {
+		t.Fatalf("RemoveServerByID: %v", err)
+	}
+	check("after remove", 2)
+
+	if err := ring.RemoveServerByID(id); !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("second remove: got %v, want ErrNodeNotFound", err)
+	}
+	check("after failed remove", 2)
+}
+
+func TestHasNode(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b"}, SetVirtualNodes(5))
+	id := "tmp"
+
+	if ring.HasNode(id) {
+		t.Fatalf("HasNode(%s) before it was added", id)
+	}
+	if err := ring.AddServer(testNode(id)); err != nil {
+		t.Fatalf("AddServer: %v", err)
+	}
+	if !ring.HasNode(id) {
+		t.Fatalf("HasNode(%s) false right after the add", id)
+	}
+	if err := ring.RemoveServerByID(id); err != nil {
+		t.Fatalf("RemoveServerByID: %v", err)
+	}
+	if ring.HasNode(id) {
+		t.Fatalf("HasNode(%s) true after the remove", id)
+	}
+}
+
+func TestHasNodeUnderConcurrentChanges(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b"}, SetVirtualNodes(5))
+	base := []string{"a", "b"}
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				for _, id := range base {
+					if !ring.HasNode(id) {
+						t.Errorf("HasNode(%s) false while it was never removed", id)
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	// every add and remove is visible to the writer straight away
+	for i := range 200 {
+		id := fmt.Sprintf("tmp%d", i)
+		if err := ring.AddServer(testNode(id)); err != nil {
+			t.Fatalf("AddServer(%s): %v", id, err)
+		}
+		if !ring.HasNode(id) {
+			t.Fatalf("HasNode(%s) false right after the add", id)
+		}
+		if err := ring.RemoveServerByID(id); err != nil {
+			t.Fatalf("RemoveServerByID(%s): %v", id, err)
+		}
+		if ring.HasNode(id) {
+			t.Fatalf("HasNode(%s) true after the remove", id)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
+
+// genNode is a node object that carries a generation, like a connection
+// wrapper recreated on reconnect.
+type genNode struct {
+	id  string
+	gen int
+}
+
+func (n genNode) GetIdentifier() string { return n.id }
+
+func TestUpdateServerConcurrentLookups(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c"})
+	checksum := ring.Checksum()
+	keys := sampleKeys(64)
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				node, err := ring.GetServer(keys[i%len(keys)])
+				if err != nil || node == nil {
+					t.Errorf("lookup during update: %v, %v", node, err)
+					return
+				}
+			}
+		}()
+	}
+
+	for gen := range 500 {
+		if err := ring.UpdateServer(genNode{"a", gen}); err != nil {
+			t.Fatalf("UpdateServer: %v", err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	if ring.Checksum() != checksum {
+		t.Fatal("UpdateServer moved tokens")
+	}
+	found := false
+	for _, node := range ring.Members() {
+		if node.GetIdentifier() == "a" {
+			found = node == genNode{"a", 499}
+		}
+	}
+	if !found {
+		t.Fatal("the latest object is not the one stored")
+	}
+	if err := ring.UpdateServer(testNode("missing")); !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("unknown node: got %v, want ErrNodeNotFound", err)
+	}
+}
@@ -0,0 +1,59 @@
+package replicationhashing
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"unsafe"
+)
+
+func ringOfSize(t *testing.T, nodes, vnodes int) *HashRing {
+	t.Helper()
+	ids := make([]string, nodes)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("node-%04d", i)
+	}
+	return newTestRing(t, ids, SetVirtualNodes(vnodes))
+}
+
+func TestEstimatedMemoryBytesGrowsLinearly(t *testing.T) {
+	base := InitHashRing().EstimatedMemoryBytes()
+	cost := func(nodes, vnodes int) float64 {
+		return float64(ringOfSize(t, nodes, vnodes).EstimatedMemoryBytes() - base)
+	}
+
+	tests := []struct {
+		name        string
+		small, big  float64
+		scaleFactor float64
+	}{
+		{"nodes", cost(50, 100), cost(200, 100), 4},
+		{"virtual nodes", cost(50, 100), cost(50, 400), 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ratio := tt.big / tt.small
+			// slice growth rounds capacity up, so allow some slack
+			if math.Abs(ratio-tt.scaleFactor)/tt.scaleFactor > 0.3 {
+				t.Fatalf("scaling %s by %v grew the estimate %.2fx", tt.name, tt.scaleFactor, ratio)
+			}
+		})
+	}
+}
+
+func TestEstimatedMemoryBytesEmptyRing(t *testing.T) {
+	if got := InitHashRing().EstimatedMemoryBytes(); got <= 0 {
+		t.Fatalf("empty ring estimate %d, want a positive base cost", got)
+	}
+}
+
+func TestEstimatedMemoryBytesCountsPositionsAndTokens(t *testing.T) {
+	const vnodes = 100
+	base := InitHashRing().EstimatedMemoryBytes()
+	got := newTestRing(t, []string{"a"}, SetVirtualNodes(vnodes)).EstimatedMemoryBytes() - base
+
+	// every virtual node costs a full position plus its recorded token
+	if want := vnodes * int(unsafe.Sizeof(position{})+unsafe.Sizeof(uint64(0))); got < want {
+		t.Fatalf("one node with %d virtual nodes adds %d bytes, want at least %d", vnodes, got, want)
+	}
+}
@@ -0,0 +1,53 @@
+package replicationhashing
+
+import (
+	"errors"
+	"fmt"
+)
+
+// AddServerWithMeta adds node like AddServer and attaches labels to it, such
+// as tier=ssd or region=eu, for GetServerMatching.
+func (h *HashRing) AddServerWithMeta(node ICacheNode, meta map[string]string) error {
+	return h.addServer(node, 1, meta)
+}
+
+// GetServerMatching returns the first node clockwise from key whose labels
+// include every pair in selector, failing with ErrNoMatchingNodes if none
+// does. An empty selector behaves exactly like GetServer.
+func (h *HashRing) GetServerMatching(key string, selector map[string]string) (ICacheNode, error) {
+	if len(selector) == 0 {
+		return h.GetServer(key)
+	}
+
+	if err := h.lockForLookup(); err != nil {
+		return nil, err
+	}
+	defer h.mu.RUnlock()
+
+	hashValue, err := h.hashKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w : %s", ErrInHashingKey, key)
+	}
+
+	healthy := h.healthyFilter()
+	node, _, err := h.ownerOf(hashValue, func(node ICacheNode) bool {
+		if healthy != nil && !healthy(node) {
+			return false
+		}
+		val, ok := h.hostMap.Load(node.GetIdentifier())
+		return ok && matchesSelector(val.(*member).meta, selector)
+	})
+	if errors.Is(err, ErrNodeNotFound) {
+		return nil, fmt.Errorf("%w: selector %v, key %s", ErrNoMatchingNodes, selector, key)
+	}
+	return node, err
+}
+
+func matchesSelector(meta, selector map[string]string) bool {
+	for k, v := range selector {
+		if got, ok := meta[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package replicationhashing
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+)
+
+// newMetaRing builds a ring mixing labelled and unlabelled nodes.
+func newMetaRing(t *testing.T, labels map[string]map[string]string, plain ...string) *HashRing {
+	t.Helper()
+	ring := newTestRing(t, plain, SetVirtualNodes(20))
+	for _, id := range slices.Sorted(maps.Keys(labels)) {
+		if err := ring.AddServerWithMeta(testNode(id), labels[id]); err != nil {
+			t.Fatalf("AddServerWithMeta(%s): %v", id, err)
+		}
+	}
+	return ring
+}
+
+var nodeLabels = map[string]map[string]string{
+	"ssd-eu": {"tier": "ssd", "region": "eu"},
+	"ssd-us": {"tier": "ssd", "region": "us"},
+	"hdd-eu": {"tier": "hdd", "region": "eu"},
+}
+
+func TestGetServerMatching(t *testing.T) {
+	ring := newMetaRing(t, nodeLabels, "plain-1", "plain-2")
+	keys := sampleKeys(1000)
+
+	tests := []struct {
+		selector map[string]string
+		matching []string
+	}{
+		{map[string]string{"tier": "ssd"}, []string{"ssd-eu", "ssd-us"}},
+		{map[string]string{"region": "eu"}, []string{"ssd-eu", "hdd-eu"}},
+		{map[string]string{"tier": "ssd", "region": "eu"}, []string{"ssd-eu"}},
+	}
+	for _, tt := range tests {
+		// walking clockwise to the first match gives the owner on a ring
+		// holding only the matching nodes
+		only := newTestRing(t, tt.matching, SetVirtualNodes(20))
+		want := owners(t, only, keys)
+		for _, key := range keys {
+			node, err := ring.GetServerMatching(key, tt.selector)
+			if err != nil {
+				t.Fatalf("GetServerMatching(%q, %v): %v", key, tt.selector, err)
+			}
+			if got := node.GetIdentifier(); got != want[key] {
+				t.Fatalf("GetServerMatching(%q, %v) = %s, want %s", key, tt.selector, got, want[key])
+			}
+		}
+	}
+}
+
+func TestGetServerMatchingEmptySelector(t *testing.T) {
+	ring := newMetaRing(t, nodeLabels, "plain-1", "plain-2")
+	want := owners(t, ring, sampleKeys(500))
+	for _, selector := range []map[string]string{nil, {}} {
+		for key, owner := range want {
+			node, err := ring.GetServerMatching(key, selector)
+			if err != nil {
+				t.Fatalf("GetServerMatching(%q, %v): %v", key, selector, err)
+			}
+			if node.GetIdentifier() != owner {
+				t.Fatalf("GetServerMatching(%q, %v) = %s, GetServer gives %s", key, selector, node.GetIdentifier(), owner)
+			}
+		}
+	}
+}
+
+func TestGetServerMatchingNoMatch(t *testing.T) {
+	ring := newMetaRing(t, nodeLabels, "plain-1")
+	for _, selector := range []map[string]string{
+		{"tier": "nvme"},
+		{"tier": "hdd", "region": "us"},
+		{"rack": "r1"},
+	} {
+		if _, err := ring.GetServerMatching("key", selector); !errors.Is(err, ErrNoMatchingNodes) {
+			t.Errorf("GetServerMatching(%v) = %v, want ErrNoMatchingNodes", selector, err)
+		}
+	}
+
+	// a matching node that is down doesn't count
+	if err := ring.MarkDown("hdd-eu"); err != nil {
+		t.Fatalf("MarkDown: %v", err)
+	}
+	if _, err := ring.GetServerMatching("key", map[string]string{"tier": "hdd"}); !errors.Is(err, ErrNoMatchingNodes) {
+		t.Errorf("GetServerMatching with the only match down = %v, want ErrNoMatchingNodes", err)
+	}
+}
+
+func TestAddServerWithMetaCopiesLabels(t *testing.T) {
+	meta := map[string]string{"tier": "ssd"}
+	ring := newTestRing(t, []string{"plain"})
+	if err := ring.AddServerWithMeta(testNode("ssd"), meta); err != nil {
+		t.Fatalf("AddServerWithMeta: %v", err)
+	}
+	meta["tier"] = "hdd"
+
+	node, err := ring.GetServerMatching("key", map[string]string{"tier": "ssd"})
+	if err != nil {
+		t.Fatalf("GetServerMatching: %v", err)
+	}
+	if node.GetIdentifier() != "ssd" {
+		t.Fatalf("GetServerMatching = %s, want ssd", node.GetIdentifier())
+	}
+	if err := ring.AddServerWithMeta(testNode("ssd"), nil); !errors.Is(err, ErrNodeExists) {
+		t.Fatalf("duplicate AddServerWithMeta = %v, want ErrNodeExists", err)
+	}
+}
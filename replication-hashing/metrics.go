@@ -0,0 +1,177 @@
+package replicationhashing
+
+import (
+	"cmp"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"unsafe"
+)
+
+// CapacityMetrics is a point-in-time summary of the ring meant to be polled by
+// autoscalers.
+type CapacityMetrics struct {
+	Nodes        int
+	VirtualNodes int
+	// ImbalanceRatio is the largest per-node keyspace share divided by the
+	// ideal share (1/Nodes). A perfectly balanced ring reports 1.
+	ImbalanceRatio float64
+	// CollisionRate is the fraction of derived virtual-node tokens that had to
+	// be salted because their plain hash was already taken. Tokens placed with
+	// AddServerWithTokens are not counted.
+	CollisionRate float64
+}
+
+// CapacityMetrics gathers node counts, balance and collision figures under a
+// single read of the ring.
+func (h *HashRing) CapacityMetrics() CapacityMetrics {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	metrics := CapacityMetrics{
+		Nodes:        h.memberCount(),
+		VirtualNodes: len(h.positions),
+	}
+	if metrics.Nodes == 0 {
+		return metrics
+	}
+
+	maxShare := 0.0
+	for _, share := range h.ownershipShares() {
+		maxShare = math.Max(maxShare, share)
+	}
+	metrics.ImbalanceRatio = maxShare * float64(metrics.Nodes)
+
+	derived, salted := 0, 0
+	h.hostMap.Range(func(key, val any) bool {
+		m := val.(*member)
+		if m.pinned {
+			return true
+		}
+		for i, token := range m.tokens {
+			if plain, err := h.vNodeHash(key.(string), i); err == nil && plain != token {
+				salted++
+			}
+			derived++
+		}
+		return true
+	})
+	if derived > 0 {
+		metrics.CollisionRate = float64(salted) / float64(derived)
+	}
+
+	return metrics
+}
+
+// ownershipShares returns the fraction of the hash space each physical node
+// owns. A position owns the arc from its predecessor (exclusive) up to itself
+// (inclusive); the first position owns the wrap-around arc. Callers must hold
+// h.mu.
+func (h *HashRing) ownershipShares() map[string]float64 {
+	shares := make(map[string]float64)
+	n := len(h.positions)
+	if n == 0 {
+		return shares
+	}
+
+	const ringSize = float64(math.MaxUint64) + 1
+	for i, p := range h.positions {
+		id := p.node.GetIdentifier()
+		if n == 1 {
+			shares[id] = 1
+			break
+		}
+		// unsigned subtraction wraps naturally for the first position
+		arc := p.hash - h.positions[(i+n-1)%n].hash
+		shares[id] += float64(arc) / ringSize
+	}
+
+	return shares
+}
+
+// Rough per-entry costs used by EstimatedMemoryBytes. sync.Map entries box
+// their key in an interface and add an indirection per entry.
+const (
+	positionBytes     = int(unsafe.Sizeof(position{})) // hash, interface node and vnode index in positions
+	memberBytes       = int(unsafe.Sizeof(member{}))   // bookkeeping behind each hostMap entry
+	tokenBytes        = 8                              // one uint64 per virtual node in member.tokens
+	hostEntryBytes    = 64                             // boxed string key, pointer value, entry and bucket overhead
+	hashRingBaseBytes = 256
+)
+
+// EstimatedMemoryBytes approximates the memory held by the ring's own data
+// structures (not the nodes themselves). It is meant for capacity planning of
+// very large virtual-node configurations, not exact accounting.
+func (h *HashRing) EstimatedMemoryBytes() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	total := hashRingBaseBytes + cap(h.positions)*positionBytes
+	h.hostMap.Range(func(key, val any) bool {
+		total += hostEntryBytes + len(key.(string)) + memberBytes + cap(val.(*member).tokens)*tokenBytes
+		return true
+	})
+
+	return total
+}
+
+// RingGaps returns the clockwise distance between every pair of consecutive
+// positions, including the wrap-around arc from the last position to the
+// first, largest first. The gaps add up to 2^64, so their uint64 sum wraps to
+// zero; a ring with a single position reports one gap of 0 for the full ring.
+func (h *HashRing) RingGaps() []uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	n := len(h.positions)
+	gaps := make([]uint64, n)
+	for i, p := range h.positions {
+		gaps[i] = p.hash - h.positions[(i+n-1)%n].hash
+	}
+	slices.SortFunc(gaps, func(a, b uint64) int {
+		return cmp.Compare(b, a)
+	})
+
+	return gaps
+}
+
+// selfTestMaxRelativeStddev is the quality bar used by SelfTest: the standard
+// deviation of per-node key shares may be at most this fraction of the ideal
+// equal share.
+const selfTestMaxRelativeStddev = 0.25
+
+// SelfTest routes sampleSize random keys and reports the standard deviation
+// of the per-node key shares, the largest share any node received, and
+// whether the spread meets the built-in quality bar (a standard deviation of
+// at most 25% of the ideal share). It is a quick sanity check after changing
+// the node set, virtual-node count or hash function.
+func (h *HashRing) SelfTest(sampleSize int) (stddev float64, maxShare float64, ok bool) {
+	view := h.FrozenView()
+	nodes := h.CapacityMetrics().Nodes
+	if nodes == 0 || sampleSize <= 0 {
+		return 0, 0, false
+	}
+
+	counts := make(map[string]int, nodes)
+	for i := 0; i < sampleSize; i++ {
+		node, err := view.Get(strconv.FormatUint(rand.Uint64(), 36))
+		if err != nil {
+			return 0, 0, false
+		}
+		counts[node.GetIdentifier()]++
+	}
+
+	ideal := 1 / float64(nodes)
+	variance := 0.0
+	for _, c := range counts {
+		share := float64(c) / float64(sampleSize)
+		maxShare = math.Max(maxShare, share)
+		variance += (share - ideal) * (share - ideal)
+	}
+	// nodes that received nothing still count towards the spread
+	variance += float64(nodes-len(counts)) * ideal * ideal
+
+	stddev = math.Sqrt(variance / float64(nodes))
+	return stddev, maxShare, stddev <= selfTestMaxRelativeStddev*ideal
+}
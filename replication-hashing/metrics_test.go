@@ -0,0 +1,165 @@
+package replicationhashing
+
+import (
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// vnodeIndexHash ignores the node name in virtual-node ids ("a_0" and "b_0"
+// hash alike) so that every node after the first collides on every plain
+// token and has to be salted.
+type vnodeIndexHash struct{ hash.Hash64 }
+
+func newVNodeIndexHash() hash.Hash64 { return vnodeIndexHash{fnv.New64a()} }
+
+func (h vnodeIndexHash) Write(p []byte) (int, error) {
+	s := string(p)
+	if i := strings.IndexByte(s, '_'); i >= 0 {
+		s = s[i:]
+	}
+	h.Hash64.Write([]byte(s))
+	return len(p), nil
+}
+
+func TestCapacityMetrics(t *testing.T) {
+	ring := newTokenRing(t, map[string][]uint64{
+		"a": {1 << 62, 1 << 63},
+		"b": {3 << 62},
+	})
+
+	got := ring.CapacityMetrics()
+	want := CapacityMetrics{Nodes: 2, VirtualNodes: 3, ImbalanceRatio: 1.5, CollisionRate: 0}
+	if got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestCapacityMetricsEmptyRing(t *testing.T) {
+	if got := InitHashRing().CapacityMetrics(); got != (CapacityMetrics{}) {
+		t.Fatalf("got %+v on an empty ring, want zero metrics", got)
+	}
+}
+
+func TestCapacityMetricsCollisionRate(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(t *testing.T) *HashRing
+		want  float64
+	}{
+		{
+			name: "plain layout",
+			build: func(t *testing.T) *HashRing {
+				return newTestRing(t, []string{"a", "b", "c"}, SetVirtualNodes(10))
+			},
+			want: 0,
+		},
+		{
+			name: "weighted node",
+			build: func(t *testing.T) *HashRing {
+				ring := newTestRing(t, []string{"a"}, SetVirtualNodes(10))
+				if err := ring.AddServerWithWeight(testNode("b"), 0.5); err != nil {
+					t.Fatalf("AddServerWithWeight: %v", err)
+				}
+				return ring
+			},
+			want: 0,
+		},
+		{
+			name: "gradual join in progress",
+			build: func(t *testing.T) *HashRing {
+				return newTestRing(t, []string{"a", "b"}, SetVirtualNodes(10), EnableGradualJoin(1, time.Hour))
+			},
+			want: 0,
+		},
+		{
+			name: "resized",
+			build: func(t *testing.T) *HashRing {
+				ring := newTestRing(t, []string{"a", "b"}, SetVirtualNodes(10))
+				if err := ring.ResizeVirtualNodes(4); err != nil {
+					t.Fatalf("ResizeVirtualNodes: %v", err)
+				}
+				return ring
+			},
+			want: 0,
+		},
+		{
+			name: "second node fully salted",
+			build: func(t *testing.T) *HashRing {
+				return newTestRing(t, []string{"a", "b"}, SetVirtualNodes(4), SetHashFunction(newVNodeIndexHash))
+			},
+			want: 0.5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.build(t).CapacityMetrics().CollisionRate; got != tt.want {
+				t.Fatalf("CollisionRate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRingGaps(t *testing.T) {
+	ring := newTokenRing(t, map[string][]uint64{"a": {100, 1000}, "b": {400}})
+
+	gaps := ring.RingGaps()
+	want := []uint64{math.MaxUint64 - 900 + 1, 600, 300}
+	if !slices.Equal(gaps, want) {
+		t.Fatalf("RingGaps() = %v, want %v", gaps, want)
+	}
+
+	// the arcs cover the ring exactly once, i.e. they sum to 2^64
+	var sum uint64
+	for _, g := range gaps {
+		sum += g
+	}
+	if sum != 0 {
+		t.Fatalf("gaps sum to %d mod 2^64, want 0", sum)
+	}
+
+	if gaps := InitHashRing().RingGaps(); len(gaps) != 0 {
+		t.Fatalf("empty ring: %v", gaps)
+	}
+	single := newTokenRing(t, map[string][]uint64{"a": {5}})
+	if gaps := single.RingGaps(); !slices.Equal(gaps, []uint64{0}) {
+		t.Fatalf("single position: %v, want one gap of 0 for the full ring", gaps)
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+	tests := []struct {
+		name   string
+		ids    int
+		vnodes int
+		want   bool
+	}{
+		{"well configured", 4, 200, true},
+		{"one virtual node each", 32, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := make([]string, tt.ids)
+			for i := range ids {
+				ids[i] = fmt.Sprintf("node-%d", i)
+			}
+			ring := newTestRing(t, ids, SetVirtualNodes(tt.vnodes), SetHashFunction(newSHAHash))
+			stddev, maxShare, ok := ring.SelfTest(20000)
+			if ok != tt.want {
+				t.Fatalf("SelfTest: stddev %.4f, max share %.4f, ok %v; want ok %v", stddev, maxShare, ok, tt.want)
+			}
+			if maxShare < 1/float64(tt.ids) || maxShare > 1 {
+				t.Fatalf("max share %.4f out of range", maxShare)
+			}
+		})
+	}
+
+	if _, _, ok := InitHashRing().SelfTest(100); ok {
+		t.Fatal("an empty ring passed")
+	}
+}
@@ -0,0 +1,138 @@
+package replicationhashing
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+type OperationKind string
+
+const (
+	OpAdd       OperationKind = "add"
+	OpRemove    OperationKind = "remove"
+	OpSetWeight OperationKind = "set_weight"
+)
+
+// Operation is one entry of the ring's topology log. Adds carry everything
+// needed to place the node again: its weight and labels, or the exact tokens
+// for a node added with AddServerWithTokens. Reweights carry the new weight.
+type Operation struct {
+	Seq    uint64
+	Kind   OperationKind
+	NodeID string
+	Weight float64
+	Tokens []uint64
+	Meta   map[string]string
+	At     time.Time
+}
+
+// EnableOperationLog keeps an in-memory, append-only log of every successful
+// add, remove and reweight, which another ring can replay with ApplyFrom.
+func EnableOperationLog(enabled bool) HashRingConfigFn {
+	return func(config *hashRingConfig) {
+		config.OperationLog = enabled
+	}
+}
+
+// OperationLog returns a copy of the recorded topology operations in order.
+func (h *HashRing) OperationLog() []Operation {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	ops := slices.Clone(h.oplog)
+	for i := range ops {
+		ops[i].Tokens = slices.Clone(ops[i].Tokens)
+		ops[i].Meta = maps.Clone(ops[i].Meta)
+	}
+	return ops
+}
+
+// ApplyFrom replays another ring's operations onto this one, using factory
+// to materialise nodes for adds. Operations with a sequence number at or
+// below the last one applied are skipped, so replaying an overlapping or
+// repeated log is idempotent.
+func (h *HashRing) ApplyFrom(ops []Operation, factory func(id string) ICacheNode) error {
+	if factory == nil {
+		return ErrNilFactory
+	}
+	for _, op := range ops {
+		h.mu.RLock()
+		skip := op.Seq <= h.appliedSeq
+		h.mu.RUnlock()
+		if skip {
+			continue
+		}
+
+		switch op.Kind {
+		case OpAdd:
+			node := factory(op.NodeID)
+			if node == nil {
+				return fmt.Errorf("replaying op %d: factory returned no node for %s", op.Seq, op.NodeID)
+			}
+			var err error
+			if len(op.Tokens) > 0 {
+				err = h.AddServerWithTokens(node, op.Tokens)
+			} else if err = checkWeight(op.Weight, op.NodeID); err == nil {
+				err = h.addServer(node, op.Weight, op.Meta)
+			}
+			if err != nil {
+				return fmt.Errorf("replaying op %d: %w", op.Seq, err)
+			}
+		case OpSetWeight:
+			if err := h.SetWeight(op.NodeID, op.Weight); err != nil {
+				return fmt.Errorf("replaying op %d: %w", op.Seq, err)
+			}
+		case OpRemove:
+			removed, err := h.RemoveServersWhere(func(id string) bool { return id == op.NodeID })
+			if err != nil {
+				return fmt.Errorf("replaying op %d: %w", op.Seq, err)
+			}
+			if len(removed) == 0 {
+				return fmt.Errorf("replaying op %d: %w : %s", op.Seq, ErrNodeNotFound, op.NodeID)
+			}
+		default:
+			return fmt.Errorf("replaying op %d: unknown operation %q", op.Seq, op.Kind)
+		}
+
+		h.mu.Lock()
+		h.appliedSeq = op.Seq
+		h.mu.Unlock()
+	}
+	return nil
+}
+
+// Equal reports whether both rings place every position identically, i.e.
+// they hold the same tokens owned by the same node identifiers.
+func (h *HashRing) Equal(other *HashRing) bool {
+	if h == other {
+		return true
+	}
+	a, b := h.FrozenView(), other.FrozenView()
+	return slices.EqualFunc(a.positions, b.positions, func(x, y position) bool {
+		return x.hash == y.hash && x.node.GetIdentifier() == y.node.GetIdentifier()
+	})
+}
+
+// LastChange returns the most recent successful add, remove or reweight. op
+// is empty if the topology has never changed.
+func (h *HashRing) LastChange() (op string, id string, at time.Time) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return string(h.lastChange.Kind), h.lastChange.NodeID, h.lastChange.At
+}
+
+// recordOp stamps a topology change with the next sequence number, remembers
+// it and appends it to the operation log when enabled. Callers must hold
+// h.mu for writing.
+func (h *HashRing) recordOp(op Operation) {
+	h.opSeq++
+	op.Seq = h.opSeq
+	op.At = time.Now()
+	h.lastChange = op
+	if h.config.OperationLog {
+		h.oplog = append(h.oplog, op)
+	}
+}
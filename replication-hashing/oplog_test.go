@@ -0,0 +1,143 @@
+package replicationhashing
+
+import (
+	"errors"
+	"maps"
+	"testing"
+	"time"
+)
+
+// memberState is what a replayed member must agree on besides its tokens.
+func memberState(t *testing.T, ring *HashRing, id string) (float64, map[string]string) {
+	t.Helper()
+	val, ok := ring.hostMap.Load(id)
+	if !ok {
+		t.Fatalf("%s is not a member", id)
+	}
+	m := val.(*member)
+	return m.weight, m.meta
+}
+
+func TestApplyFromReplaysFullState(t *testing.T) {
+	source := newTestRing(t, nil, SetVirtualNodes(20), EnableOperationLog(true))
+	steps := []func() error{
+		func() error { return source.AddServer(testNode("a")) },
+		func() error { return source.AddServerWithWeight(testNode("heavy"), 2.5) },
+		func() error { return source.AddServerWithMeta(testNode("eu"), map[string]string{"region": "eu"}) },
+		func() error { return source.AddServerWithTokens(testNode("fixed"), []uint64{7, 1 << 40, 1 << 62}) },
+		func() error { return source.AddServer(testNode("gone")) },
+		func() error { return source.SetWeight("a", 0.5) },
+		func() error { return source.RemoveServerByID("gone") },
+		func() error { return source.SetWeight("heavy", 3) },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+	}
+
+	ops := source.OperationLog()
+	if len(ops) != len(steps) {
+		t.Fatalf("%d operations logged for %d changes", len(ops), len(steps))
+	}
+	for i, op := range ops {
+		if op.Seq != uint64(i+1) {
+			t.Fatalf("op %d has sequence number %d", i, op.Seq)
+		}
+	}
+
+	replica := newTestRing(t, nil, SetVirtualNodes(20))
+	factory := func(id string) ICacheNode { return testNode(id) }
+	if err := replica.ApplyFrom(ops, factory); err != nil {
+		t.Fatalf("ApplyFrom: %v", err)
+	}
+	if !replica.Equal(source) {
+		t.Fatal("replayed ring places positions differently")
+	}
+	for _, id := range source.MemberIDs() {
+		wantWeight, wantMeta := memberState(t, source, id)
+		gotWeight, gotMeta := memberState(t, replica, id)
+		if gotWeight != wantWeight || !maps.Equal(gotMeta, wantMeta) {
+			t.Fatalf("%s: replayed weight %g meta %v, want %g %v", id, gotWeight, gotMeta, wantWeight, wantMeta)
+		}
+	}
+
+	// replaying the same log again is a no-op
+	if err := replica.ApplyFrom(ops, factory); err != nil {
+		t.Fatalf("second ApplyFrom: %v", err)
+	}
+	if !replica.Equal(source) {
+		t.Fatal("replaying twice changed the ring")
+	}
+}
+
+func TestOperationSeqIsMonotonic(t *testing.T) {
+	ring := newTestRing(t, []string{"a"})
+
+	// with the log disabled the counter still advances
+	if err := ring.AddServer(testNode("b")); err != nil {
+		t.Fatalf("AddServer: %v", err)
+	}
+	if err := ring.RemoveServerByID("b"); err != nil {
+		t.Fatalf("RemoveServerByID: %v", err)
+	}
+	if ring.lastChange.Seq != 3 {
+		t.Fatalf("third change has sequence number %d", ring.lastChange.Seq)
+	}
+	if len(ring.OperationLog()) != 0 {
+		t.Fatal("operations logged while the log is disabled")
+	}
+}
+
+func TestApplyFromNilFactory(t *testing.T) {
+	ring := newTestRing(t, nil)
+	ops := []Operation{{Seq: 1, Kind: OpAdd, NodeID: "a", Weight: 1}}
+	if err := ring.ApplyFrom(ops, nil); !errors.Is(err, ErrNilFactory) {
+		t.Fatalf("nil factory: got %v, want ErrNilFactory", err)
+	}
+	if err := ring.ApplyFrom(ops, func(string) ICacheNode { return nil }); err == nil {
+		t.Fatal("factory returning nil: got no error")
+	}
+	if ring.NodeCount() != 0 {
+		t.Fatalf("ring changed: %v", ring.MemberIDs())
+	}
+}
+
+func TestLastChange(t *testing.T) {
+	ring := newTestRing(t, nil)
+	if op, id, at := ring.LastChange(); op != "" || id != "" || !at.IsZero() {
+		t.Fatalf("fresh ring: got %q %q %v, want nothing", op, id, at)
+	}
+
+	steps := []struct {
+		apply  func() error
+		op, id string
+	}{
+		{func() error { return ring.AddServer(testNode("a")) }, "add", "a"},
+		{func() error { return ring.AddServer(testNode("b")) }, "add", "b"},
+		{func() error { return ring.RemoveServerByID("a") }, "remove", "a"},
+		{func() error { return ring.SetWeight("b", 2) }, "set_weight", "b"},
+	}
+	var last time.Time
+	for i, s := range steps {
+		if err := s.apply(); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		op, id, at := ring.LastChange()
+		if op != s.op || id != s.id {
+			t.Fatalf("step %d: LastChange = %s %s, want %s %s", i, op, id, s.op, s.id)
+		}
+		if at.Before(last) {
+			t.Fatalf("step %d: timestamp went backwards", i)
+		}
+		last = at
+	}
+
+	// a failed change leaves it alone
+	if err := ring.RemoveServerByID("missing"); err == nil {
+		t.Fatal("removing an unknown node succeeded")
+	}
+	if op, id, _ := ring.LastChange(); op != "set_weight" || id != "b" {
+		t.Fatalf("after a failed removal: LastChange = %s %s", op, id)
+	}
+}
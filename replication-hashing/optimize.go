@@ -0,0 +1,141 @@
+package replicationhashing
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"slices"
+)
+
+// maxOptimizeIterations bounds the work OptimizeVirtualNodes does per call.
+const maxOptimizeIterations = 1000
+
+// OptimizeVirtualNodes nudges per-node virtual-node counts until the standard
+// deviation of the nodes' keyspace shares drops to targetStddev or below.
+// Each iteration gives the node with the smallest share one more virtual
+// node and takes the highest-index virtual node away from the node with the
+// largest share (never its last one). The resulting share per node is
+// returned; if the target isn't reached within the iteration budget the
+// adjusted layout is kept and ErrTargetNotReached is returned. How far this
+// can go depends on the hash function spreading a node's virtual nodes.
+// Nodes added with AddServerWithTokens keep their tokens.
+func (h *HashRing) OptimizeVirtualNodes(targetStddev float64) (map[string]float64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.memberCount() == 0 {
+		return map[string]float64{}, ErrNoConnectedNodes
+	}
+
+	shares := h.ownershipShares()
+	for i := 0; i < maxOptimizeIterations && shareStddev(shares) > targetStddev; i++ {
+		minId, maxId, ok := h.extremeShares(shares)
+		if !ok {
+			// every node is pinned, nothing can be adjusted
+			break
+		}
+		// shrink first so a ring at SetMaxTotalVirtualNodes has room to grow
+		if minId != maxId {
+			h.shrinkMember(maxId)
+		}
+		if err := h.growMember(minId); err != nil {
+			return h.ownershipShares(), err
+		}
+		shares = h.ownershipShares()
+	}
+
+	stddev := shareStddev(shares)
+	if h.config.EnableLogs {
+		log.Printf("[HashRing] Optimized virtual nodes, share stddev %.4f", stddev)
+	}
+	if stddev > targetStddev {
+		return shares, fmt.Errorf("%w: stddev %.4f, target %.4f", ErrTargetNotReached, stddev, targetStddev)
+	}
+	return shares, nil
+}
+
+// growMember places the next virtual node for nodeId unless that would exceed
+// SetMaxTotalVirtualNodes. Callers must hold h.mu.
+func (h *HashRing) growMember(nodeId string) error {
+	val, ok := h.hostMap.Load(nodeId)
+	if !ok {
+		return fmt.Errorf("%w : %s", ErrNodeNotFound, nodeId)
+	}
+	m := val.(*member)
+	if m.pinned {
+		return nil
+	}
+	if max := h.config.MaxTotalVNodes; max > 0 && len(h.positions)+1 > max {
+		return fmt.Errorf("%w: growing %s would need %d positions, limit is %d",
+			ErrTooManyVNodes, nodeId, len(h.positions)+1, max)
+	}
+
+	hash, err := h.placeVNode(nodeId, len(m.tokens), h.tokenTaken)
+	if err != nil {
+		return err
+	}
+	h.positions = insertPositions(h.positions, []position{{hash: hash, node: m.node, vnode: len(m.tokens)}})
+	m.tokens = append(m.tokens, hash)
+	return nil
+}
+
+// shrinkMember removes nodeId's highest-index virtual node, keeping at least
+// one. Callers must hold h.mu.
+func (h *HashRing) shrinkMember(nodeId string) {
+	val, ok := h.hostMap.Load(nodeId)
+	if !ok {
+		return
+	}
+	m := val.(*member)
+	if m.pinned || len(m.tokens) <= 1 {
+		return
+	}
+
+	hash := m.tokens[len(m.tokens)-1]
+	if index := slices.IndexFunc(h.positions, func(p position) bool {
+		return p.hash == hash && p.node.GetIdentifier() == nodeId
+	}); index >= 0 {
+		h.positions = slices.Delete(h.positions, index, index+1)
+	}
+	m.tokens = m.tokens[:len(m.tokens)-1]
+}
+
+func shareStddev(shares map[string]float64) float64 {
+	if len(shares) == 0 {
+		return 0
+	}
+	mean := 1 / float64(len(shares))
+	variance := 0.0
+	for _, s := range shares {
+		variance += (s - mean) * (s - mean)
+	}
+	return math.Sqrt(variance / float64(len(shares)))
+}
+
+// extremeShares returns the nodes with the smallest and largest share among
+// those whose virtual nodes can be adjusted, ties broken by identifier so
+// runs are reproducible. Nodes added with AddServerWithTokens are skipped; ok
+// is false when no node is left. Callers must hold h.mu.
+func (h *HashRing) extremeShares(shares map[string]float64) (minId, maxId string, ok bool) {
+	ids := make([]string, 0, len(shares))
+	for id := range shares {
+		if val, found := h.hostMap.Load(id); found && !val.(*member).pinned {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return "", "", false
+	}
+	slices.Sort(ids)
+
+	minId, maxId = ids[0], ids[0]
+	for _, id := range ids[1:] {
+		if shares[id] < shares[minId] {
+			minId = id
+		}
+		if shares[id] > shares[maxId] {
+			maxId = id
+		}
+	}
+	return minId, maxId, true
+}
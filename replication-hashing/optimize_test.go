@@ -0,0 +1,108 @@
+package replicationhashing
+
+import (
+	"errors"
+	"testing"
+)
+
+// newSkewedRing builds a ring whose heaviest node owns far more than its
+// equal share.
+func newSkewedRing(t *testing.T, opts ...HashRingConfigFn) *HashRing {
+	t.Helper()
+	ring := newTestRing(t, []string{"a", "b", "c", "d"}, append([]HashRingConfigFn{SetVirtualNodes(16), SetHashFunction(newSHAHash)}, opts...)...)
+	if err := ring.AddServerWithWeight(testNode("e"), 3); err != nil {
+		t.Fatalf("AddServerWithWeight: %v", err)
+	}
+	return ring
+}
+
+func TestOptimizeVirtualNodes(t *testing.T) {
+	ring := newSkewedRing(t)
+	before := shareStddev(ring.ownershipShares())
+	const target = 0.03
+	if before <= target {
+		t.Fatalf("starting stddev %.4f is already below the target, so the test proves nothing", before)
+	}
+
+	shares, err := ring.OptimizeVirtualNodes(target)
+	if err != nil {
+		t.Fatalf("OptimizeVirtualNodes: %v", err)
+	}
+	if got := shareStddev(shares); got > target {
+		t.Fatalf("stddev %.4f after optimizing, want at most %.4f", got, target)
+	}
+	if got := shareStddev(ring.ownershipShares()); got != shareStddev(shares) {
+		t.Fatalf("reported stddev %.4f, ring has %.4f", shareStddev(shares), got)
+	}
+}
+
+func TestOptimizeVirtualNodesRespectsLimit(t *testing.T) {
+	// 4×16 plus 48 for the weighted node fills the limit exactly
+	ring := newSkewedRing(t, SetMaxTotalVirtualNodes(112))
+
+	// shrinking before growing lets a full ring keep optimizing
+	if _, err := ring.OptimizeVirtualNodes(0.03); err != nil {
+		t.Fatalf("OptimizeVirtualNodes: %v", err)
+	}
+	if got := ring.VirtualNodeCount(); got > 112 {
+		t.Fatalf("%d positions, limit is 112", got)
+	}
+
+	// with one token per node nothing can shrink, so growing must fail
+	single := newTestRing(t, []string{"a", "b", "c"}, SetVirtualNodes(1), SetMaxTotalVirtualNodes(3))
+	if _, err := single.OptimizeVirtualNodes(0); !errors.Is(err, ErrTooManyVNodes) {
+		t.Fatalf("got %v, want ErrTooManyVNodes", err)
+	}
+	if got := single.VirtualNodeCount(); got != 3 {
+		t.Fatalf("%d positions, want 3", got)
+	}
+}
+
+func TestOptimizeVirtualNodesSkipsPinnedNodes(t *testing.T) {
+	ring := newSkewedRing(t)
+	// one caller-chosen token leaves p far below its share
+	if err := ring.AddServerWithTokens(testNode("p"), []uint64{1 << 62}); err != nil {
+		t.Fatalf("AddServerWithTokens: %v", err)
+	}
+	before := ring.ownershipShares()
+	counts := make(map[string]int)
+	for id := range before {
+		counts[id] = len(memberTokens(t, ring, id))
+	}
+	if minId := minShare(before); minId != "p" {
+		t.Fatalf("%s has the smallest share, not the pinned node; the layout proves nothing", minId)
+	}
+
+	// the pinned node can't reach the target, but the others still improve
+	shares, err := ring.OptimizeVirtualNodes(0.03)
+	if err != nil && !errors.Is(err, ErrTargetNotReached) {
+		t.Fatalf("OptimizeVirtualNodes: %v", err)
+	}
+	if tokens := memberTokens(t, ring, "p"); len(tokens) != 1 || tokens[0] != 1<<62 {
+		t.Fatalf("pinned node's tokens changed to %v", tokens)
+	}
+	// the smallest unpinned share grows instead of the pinned one
+	grown := false
+	for id, n := range counts {
+		grown = grown || len(memberTokens(t, ring, id)) > n
+	}
+	if !grown {
+		t.Fatal("no node gained a virtual node")
+	}
+	delete(before, "p")
+	delete(shares, "p")
+	if got, was := shareStddev(shares), shareStddev(before); got >= was {
+		t.Fatalf("stddev of the other nodes went from %.4f to %.4f, want it lower", was, got)
+	}
+}
+
+// minShare returns the node with the smallest share.
+func minShare(shares map[string]float64) string {
+	minId := ""
+	for id, s := range shares {
+		if minId == "" || s < shares[minId] {
+			minId = id
+		}
+	}
+	return minId
+}
@@ -0,0 +1,109 @@
+package replicationhashing
+
+import "log"
+
+// PausePolicy decides what lookups do while the ring is paused.
+type PausePolicy int
+
+const (
+	// ReturnError fails lookups with ErrRingPaused until Resume.
+	ReturnError PausePolicy = iota
+	// BlockUntilResume makes lookups wait for Resume.
+	BlockUntilResume
+	// ServeLastConsistentView keeps answering lookups from the layout
+	// captured when Pause was called.
+	ServeLastConsistentView
+)
+
+// SetPausePolicy selects how lookups behave while the ring is paused. The
+// default is ReturnError.
+func SetPausePolicy(policy PausePolicy) HashRingConfigFn {
+	return func(config *hashRingConfig) {
+		config.PausePolicy = policy
+	}
+}
+
+// SetBlockWhilePaused controls how GetServer behaves while the ring is
+// paused: block until Resume is called (true) or fail fast with
+// ErrRingPaused (false, the default). It is shorthand for SetPausePolicy
+// with BlockUntilResume or ReturnError.
+func SetBlockWhilePaused(block bool) HashRingConfigFn {
+	if block {
+		return SetPausePolicy(BlockUntilResume)
+	}
+	return SetPausePolicy(ReturnError)
+}
+
+// Pause hides topology changes from lookups until Resume is called, so a batch
+// of adds and removes becomes visible atomically. Pausing an already paused
+// ring is a no-op.
+func (h *HashRing) Pause() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.resumed != nil {
+		return
+	}
+	h.resumed = make(chan struct{})
+	if h.config.PausePolicy == ServeLastConsistentView {
+		view := h.snapshot()
+		h.pausedView = &view
+	}
+
+	if h.config.EnableLogs {
+		log.Printf("[HashRing] Paused lookups")
+	}
+}
+
+// Resume makes the current topology visible and releases blocked lookups.
+func (h *HashRing) Resume() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.resumed == nil {
+		return
+	}
+	close(h.resumed)
+	h.resumed = nil
+	h.pausedView = nil
+
+	if h.config.EnableLogs {
+		log.Printf("[HashRing] Resumed lookups")
+	}
+}
+
+// Paused reports whether lookups are currently held back by Pause.
+func (h *HashRing) Paused() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.resumed != nil
+}
+
+// lockForLookup read-locks h.mu for a lookup, honouring an in-progress pause.
+// On success the caller is responsible for calling h.mu.RUnlock.
+func (h *HashRing) lockForLookup() error {
+	for {
+		h.mu.RLock()
+		resumed := h.resumed
+		if resumed == nil || h.config.PausePolicy == ServeLastConsistentView {
+			return nil
+		}
+		h.mu.RUnlock()
+
+		if h.config.PausePolicy != BlockUntilResume {
+			return ErrRingPaused
+		}
+		<-resumed
+	}
+}
+
+// lookupPositions returns the layout lookups should observe: the one captured
+// at Pause under ServeLastConsistentView, otherwise the live one. Callers
+// must hold h.mu.
+func (h *HashRing) lookupPositions() []position {
+	if h.pausedView != nil {
+		return h.pausedView.positions
+	}
+	return h.positions
+}
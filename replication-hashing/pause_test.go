@@ -0,0 +1,148 @@
+package replicationhashing
+
+import (
+	"errors"
+	"hash/fnv"
+	"maps"
+	"testing"
+	"time"
+)
+
+// reconfigure replaces a and b by c and d.
+func reconfigure(t *testing.T, ring *HashRing) {
+	t.Helper()
+	if err := ring.AddServers(testNodes("c", "d")); err != nil {
+		t.Fatalf("AddServers: %v", err)
+	}
+	if err := ring.RemoveServersByID([]string{"a", "b"}); err != nil {
+		t.Fatalf("RemoveServersByID: %v", err)
+	}
+}
+
+func TestPauseReturnError(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b"}, SetVirtualNodes(10))
+	keys := sampleKeys(200)
+
+	ring.Pause()
+	if !ring.Paused() {
+		t.Fatal("Paused() = false after Pause")
+	}
+	reconfigure(t, ring)
+	if _, err := ring.GetServer(keys[0]); !errors.Is(err, ErrRingPaused) {
+		t.Fatalf("GetServer while paused: got %v, want ErrRingPaused", err)
+	}
+
+	ring.Resume()
+	if ring.Paused() {
+		t.Fatal("Paused() = true after Resume")
+	}
+	for key, id := range owners(t, ring, keys) {
+		if id != "c" && id != "d" {
+			t.Fatalf("key %q routed to %s after Resume", key, id)
+		}
+	}
+}
+
+func TestPauseBlockUntilResume(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b"}, SetVirtualNodes(10), SetBlockWhilePaused(true))
+
+	ring.Pause()
+	got := make(chan string)
+	go func() {
+		node, err := ring.GetServer("blocked-key")
+		if err != nil {
+			got <- "error: " + err.Error()
+			return
+		}
+		got <- node.GetIdentifier()
+	}()
+
+	reconfigure(t, ring)
+	select {
+	case id := <-got:
+		t.Fatalf("lookup returned %s while paused", id)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	ring.Resume()
+	select {
+	case id := <-got:
+		if id != "c" && id != "d" {
+			t.Fatalf("blocked lookup resolved to %s, want the post-resume topology", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("lookup still blocked after Resume")
+	}
+}
+
+func TestPauseServeLastConsistentView(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b"}, SetVirtualNodes(10), SetPausePolicy(ServeLastConsistentView))
+	keys := sampleKeys(200)
+	before := owners(t, ring, keys)
+
+	ring.Pause()
+	reconfigure(t, ring)
+	if during := owners(t, ring, keys); !maps.Equal(during, before) {
+		t.Fatal("lookups observed changes made while paused")
+	}
+
+	ring.Resume()
+	for key, id := range owners(t, ring, keys) {
+		if id != "c" && id != "d" {
+			t.Fatalf("key %q routed to %s after Resume", key, id)
+		}
+	}
+}
+
+func TestPausedViewKeepsItsHashFunction(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c"}, SetVirtualNodes(10), SetPausePolicy(ServeLastConsistentView))
+	keys := sampleKeys(200)
+	before := owners(t, ring, keys)
+	view := ring.FrozenView()
+
+	ring.Pause()
+	if _, err := ring.ChangeHashFunction(fnv.New64, true); err != nil {
+		t.Fatalf("ChangeHashFunction: %v", err)
+	}
+	if during := owners(t, ring, keys); !maps.Equal(during, before) {
+		t.Fatal("lookups on the paused view hashed keys with the new function")
+	}
+	for _, key := range keys {
+		node, err := ring.GetServerBytes([]byte(key))
+		if err != nil {
+			t.Fatalf("GetServerBytes(%q): %v", key, err)
+		}
+		if node.GetIdentifier() != before[key] {
+			t.Fatalf("key %q: GetServerBytes says %s while paused, %s before", key, node.GetIdentifier(), before[key])
+		}
+	}
+
+	ring.Resume()
+	moved := 0
+	for key, id := range owners(t, ring, keys) {
+		want, err := ring.FrozenView().Get(key)
+		if err != nil {
+			t.Fatalf("Get(%q): %v", key, err)
+		}
+		if id != want.GetIdentifier() {
+			t.Fatalf("key %q: GetServer says %s after Resume, the live layout %s", key, id, want.GetIdentifier())
+		}
+		if old, _ := view.Get(key); old.GetIdentifier() != id {
+			moved++
+		}
+	}
+	if moved == 0 {
+		t.Fatal("no key moved with the new hash function, so the test proves nothing")
+	}
+}
+
+func TestPauseIsIdempotent(t *testing.T) {
+	ring := newTestRing(t, []string{"a"})
+	ring.Resume()
+	ring.Pause()
+	ring.Pause()
+	ring.Resume()
+	if _, err := ring.GetServer("key"); err != nil {
+		t.Fatalf("GetServer after a double Pause and one Resume: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package replicationhashing
+
+import (
+	"fmt"
+	"log"
+	"maps"
+)
+
+// PinKey makes GetServer return nodeID for key regardless of the hash, e.g.
+// for keys that must live on a particular host. If the node later leaves
+// the ring or is marked down, lookups fall back to normal hashing until it
+// is back or the pin is removed. Keys are pinned after the key normalizer,
+// so a pin covers every spelling that normalizes to the same key.
+func (h *HashRing) PinKey(key, nodeID string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.hostMap.Load(nodeID); !ok {
+		return fmt.Errorf("%w : %s", ErrNodeNotFound, nodeID)
+	}
+	if h.pins == nil {
+		h.pins = make(map[string]string)
+	}
+	h.pins[h.normalizeKey(key)] = nodeID
+	return nil
+}
+
+// UnpinKey removes key's override, if any.
+func (h *HashRing) UnpinKey(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.pins, h.normalizeKey(key))
+}
+
+// Pins returns a copy of the current key to node overrides, keyed by the
+// normalized key.
+func (h *HashRing) Pins() map[string]string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return maps.Clone(h.pins)
+}
+
+// pinnedServer returns the node key is pinned to while it is a member that
+// isn't marked down. Callers must hold h.mu.
+func (h *HashRing) pinnedServer(key string) (ICacheNode, bool) {
+	if len(h.pins) == 0 {
+		return nil, false
+	}
+	nodeID, ok := h.pins[h.normalizeKey(key)]
+	if !ok {
+		return nil, false
+	}
+	val, ok := h.hostMap.Load(nodeID)
+	if !ok {
+		if h.config.EnableLogs {
+			log.Printf("[HashRing] Key '%s' is pinned to removed node %s, hashing instead", key, nodeID)
+		}
+		return nil, false
+	}
+	if _, down := h.down[nodeID]; down {
+		if h.config.EnableLogs {
+			log.Printf("[HashRing] Key '%s' is pinned to down node %s, hashing instead", key, nodeID)
+		}
+		return nil, false
+	}
+	return val.(*member).node, true
+}
@@ -0,0 +1,121 @@
+package replicationhashing
+
+import (
+	"errors"
+	"maps"
+	"testing"
+)
+
+// pinTarget finds a key not owned by nodeID, so pinning it there proves the
+// pin overrides the hash.
+func pinTarget(t *testing.T, ring *HashRing, nodeID string) (key, owner string) {
+	t.Helper()
+	for key, owner := range owners(t, ring, sampleKeys(100)) {
+		if owner != nodeID {
+			return key, owner
+		}
+	}
+	t.Fatalf("%s owns every sample key", nodeID)
+	return "", ""
+}
+
+func TestPinKey(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c"}, SetVirtualNodes(10))
+	key, hashed := pinTarget(t, ring, "a")
+
+	if err := ring.PinKey(key, "a"); err != nil {
+		t.Fatalf("PinKey: %v", err)
+	}
+	if got := owners(t, ring, []string{key})[key]; got != "a" {
+		t.Fatalf("GetServer(%q) = %s, want pinned node a", key, got)
+	}
+	node, err := ring.GetServerBytes([]byte(key))
+	if err != nil {
+		t.Fatalf("GetServerBytes: %v", err)
+	}
+	if node.GetIdentifier() != "a" {
+		t.Fatalf("GetServerBytes(%q) = %s, want pinned node a", key, node.GetIdentifier())
+	}
+	if pins := ring.Pins(); !maps.Equal(pins, map[string]string{key: "a"}) {
+		t.Fatalf("Pins() = %v", pins)
+	}
+
+	// Pins hands out a copy
+	ring.Pins()[key] = "b"
+	if got := ring.Pins()[key]; got != "a" {
+		t.Fatalf("editing the result of Pins moved the pin to %s", got)
+	}
+
+	ring.UnpinKey(key)
+	if got := owners(t, ring, []string{key})[key]; got != hashed {
+		t.Fatalf("GetServer(%q) = %s after UnpinKey, want hashed owner %s", key, got, hashed)
+	}
+	if len(ring.Pins()) != 0 {
+		t.Fatalf("Pins() = %v after UnpinKey", ring.Pins())
+	}
+	ring.UnpinKey("never-pinned")
+}
+
+func TestPinKeyUnknownNode(t *testing.T) {
+	ring := newTestRing(t, []string{"a"})
+	if err := ring.PinKey("key", "ghost"); !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("PinKey to unknown node = %v, want ErrNodeNotFound", err)
+	}
+	if len(ring.Pins()) != 0 {
+		t.Fatalf("rejected pin recorded: %v", ring.Pins())
+	}
+}
+
+func TestPinnedNodeRemoved(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c"}, SetVirtualNodes(10))
+	key, _ := pinTarget(t, ring, "a")
+	if err := ring.PinKey(key, "a"); err != nil {
+		t.Fatalf("PinKey: %v", err)
+	}
+
+	if err := ring.RemoveServerByID("a"); err != nil {
+		t.Fatalf("RemoveServerByID: %v", err)
+	}
+	want := owners(t, newTestRing(t, []string{"b", "c"}, SetVirtualNodes(10)), []string{key})[key]
+	if got := owners(t, ring, []string{key})[key]; got != want {
+		t.Fatalf("GetServer(%q) = %s with the pinned node gone, want hashed owner %s", key, got, want)
+	}
+
+	// the pin applies again once the node is back
+	if err := ring.AddServer(testNode("a")); err != nil {
+		t.Fatalf("AddServer: %v", err)
+	}
+	if got := owners(t, ring, []string{key})[key]; got != "a" {
+		t.Fatalf("GetServer(%q) = %s after re-adding a, want a", key, got)
+	}
+}
+
+func TestPinnedNodeDown(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c"}, SetVirtualNodes(10))
+	key, _ := pinTarget(t, ring, "a")
+	if err := ring.PinKey(key, "a"); err != nil {
+		t.Fatalf("PinKey: %v", err)
+	}
+
+	if err := ring.MarkDown("a"); err != nil {
+		t.Fatalf("MarkDown: %v", err)
+	}
+	got := owners(t, ring, []string{key})[key]
+	if got == "a" {
+		t.Fatalf("GetServer(%q) returned pinned node a while it is down", key)
+	}
+	result, err := ring.GetServerDetailed(key)
+	if err != nil {
+		t.Fatalf("GetServerDetailed: %v", err)
+	}
+	if result.Pinned || result.Node.GetIdentifier() != got {
+		t.Fatalf("GetServerDetailed(%q) = %s pinned=%t, want hashed owner %s", key, result.Node.GetIdentifier(), result.Pinned, got)
+	}
+
+	if err := ring.MarkUp("a"); err != nil {
+		t.Fatalf("MarkUp: %v", err)
+	}
+	if got := owners(t, ring, []string{key})[key]; got != "a" {
+		t.Fatalf("GetServer(%q) = %s after MarkUp, want a", key, got)
+	}
+}
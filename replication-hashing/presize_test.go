@@ -0,0 +1,51 @@
+package replicationhashing
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestSetExpectedNodesPresizes(t *testing.T) {
+	ring := InitHashRing(SetVirtualNodes(10), SetExpectedNodes(8))
+	if got := cap(ring.positions); got != 80 {
+		t.Fatalf("capacity %d, want 80", got)
+	}
+}
+
+func TestSetExpectedNodesKeepsPlacement(t *testing.T) {
+	ids := []string{"a", "b", "c", "d", "e"}
+	plain := newTestRing(t, ids, SetVirtualNodes(10))
+	sized := newTestRing(t, ids, SetVirtualNodes(10), SetExpectedNodes(len(ids)))
+
+	if !slices.Equal(plain.Tokens(), sized.Tokens()) {
+		t.Fatal("pre-sizing changed the token layout")
+	}
+	keys := sampleKeys(500)
+	if !maps.Equal(owners(t, plain, keys), owners(t, sized, keys)) {
+		t.Fatal("pre-sizing changed key placement")
+	}
+}
+
+func benchmarkAddServers(b *testing.B, opts ...HashRingConfigFn) {
+	const nodes = 200
+	ids := make([]ICacheNode, nodes)
+	for i := range ids {
+		ids[i] = testNode(fmt.Sprintf("node-%d", i))
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		ring := InitHashRing(append([]HashRingConfigFn{SetVirtualNodes(100)}, opts...)...)
+		for _, node := range ids {
+			if err := ring.AddServer(node); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkAddServers(b *testing.B) {
+	b.Run("growing", func(b *testing.B) { benchmarkAddServers(b) })
+	b.Run("presized", func(b *testing.B) { benchmarkAddServers(b, SetExpectedNodes(200)) })
+}
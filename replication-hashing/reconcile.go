@@ -0,0 +1,55 @@
+package replicationhashing
+
+import (
+	"errors"
+	"log"
+	"slices"
+)
+
+// Reconcile makes the ring's membership match target by identifier: nodes
+// missing from the ring are added and nodes absent from target are removed,
+// all under one write lock so readers never see a half-converged ring. The
+// identifiers added and removed are returned sorted. Nodes that fail to join
+// are named in err while the rest of the change still applies; with
+// PreventEmptyRing set, the current nodes stay when none of target could
+// join, and err wraps ErrWouldEmptyRing.
+func (h *HashRing) Reconcile(target []ICacheNode) (added, removed []string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	wanted := make(map[string]struct{}, len(target))
+	var joining []ICacheNode
+	for _, node := range target {
+		id := node.GetIdentifier()
+		if _, dup := wanted[id]; dup {
+			continue
+		}
+		wanted[id] = struct{}{}
+		if _, exists := h.hostMap.Load(id); !exists {
+			joining = append(joining, node)
+		}
+	}
+
+	doomed := make(map[string]struct{})
+	h.hostMap.Range(func(key, _ any) bool {
+		if _, keep := wanted[key.(string)]; !keep {
+			doomed[key.(string)] = struct{}{}
+		}
+		return true
+	})
+
+	// add first: if every newcomer fails to join, removing the departing
+	// nodes could otherwise leave the ring empty
+	added, err = h.addMembers(joining)
+	slices.Sort(added)
+	var removeErr error
+	if removed, removeErr = h.removeMembers(doomed); removeErr != nil {
+		err = errors.Join(err, removeErr)
+	}
+
+	if h.config.EnableLogs && (len(added) > 0 || len(removed) > 0) {
+		log.Printf("[HashRing] Reconciled membership: added %v, removed %v", added, removed)
+	}
+
+	return added, removed, err
+}
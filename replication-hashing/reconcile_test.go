@@ -0,0 +1,92 @@
+package replicationhashing
+
+import (
+	"errors"
+	"hash"
+	"hash/fnv"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestReconcile(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c"}, SetVirtualNodes(20))
+
+	added, removed, err := ring.Reconcile(testNodes("b", "c", "d", "d", "e"))
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if !slices.Equal(added, []string{"d", "e"}) || !slices.Equal(removed, []string{"a"}) {
+		t.Fatalf("Reconcile added %v and removed %v, want [d e] and [a]", added, removed)
+	}
+	if got := ring.MemberIDs(); !slices.Equal(got, []string{"b", "c", "d", "e"}) {
+		t.Fatalf("members %v, want [b c d e]", got)
+	}
+	if got := ring.VirtualNodeCount(); got != 80 {
+		t.Fatalf("%d tokens, want 80", got)
+	}
+
+	// a second pass with the same target changes nothing
+	added, removed, err = ring.Reconcile(testNodes("e", "d", "c", "b"))
+	if err != nil || len(added) != 0 || len(removed) != 0 {
+		t.Fatalf("repeated Reconcile: added %v, removed %v, err %v", added, removed, err)
+	}
+}
+
+func TestReconcileNeverEmptiesProtectedRing(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b"}, SetPreventEmptyRing(true), SetHashFunction(func() hash.Hash64 {
+		return failingHash{Hash64: fnv.New64a(), poison: "c_"}
+	}))
+	keys := sampleKeys(100)
+	before := owners(t, ring, keys)
+
+	// the only newcomer fails to join, so dropping a and b would empty the ring
+	added, removed, err := ring.Reconcile(testNodes("c"))
+	if !errors.Is(err, ErrWouldEmptyRing) || !errors.Is(err, ErrInHashingKey) {
+		t.Fatalf("Reconcile: got %v, want ErrWouldEmptyRing and ErrInHashingKey", err)
+	}
+	if len(added) != 0 || len(removed) != 0 {
+		t.Fatalf("Reconcile added %v and removed %v, want nothing", added, removed)
+	}
+	if got := ring.MemberIDs(); !slices.Equal(got, []string{"a", "b"}) {
+		t.Fatalf("members %v, want [a b]", got)
+	}
+	for key, owner := range owners(t, ring, keys) {
+		if before[key] != owner {
+			t.Fatalf("key %q moved from %s to %s", key, before[key], owner)
+		}
+	}
+}
+
+func TestReconcileIsAtomicForReaders(t *testing.T) {
+	sets := [][]string{{"a", "b", "c"}, {"d", "e"}}
+	ring := newTestRing(t, sets[0])
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if got := ring.MemberIDs(); !slices.Equal(got, sets[0]) && !slices.Equal(got, sets[1]) {
+					t.Errorf("reader saw a half-converged ring: %v", got)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := range 200 {
+		if _, _, err := ring.Reconcile(testNodes(sets[(i+1)%2]...)); err != nil {
+			t.Fatalf("Reconcile: %v", err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
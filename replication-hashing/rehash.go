@@ -0,0 +1,165 @@
+package replicationhashing
+
+import (
+	"fmt"
+	"hash"
+	"log"
+	"slices"
+)
+
+// ChangeHashFunction swaps the ring's hash function and rebuilds every
+// virtual-node position with it, returning how many positions changed.
+// Because this remaps essentially every key, it is refused on a populated
+// ring unless force is set. Tokens placed with AddServerWithTokens are kept.
+func (h *HashRing) ChangeHashFunction(f func() hash.Hash64, force bool) (int, error) {
+	if f == nil {
+		return 0, ErrNilHashFunction
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.positions) > 0 && !force {
+		return 0, fmt.Errorf("%w: changing the hash function remaps all keys", ErrRingNotEmpty)
+	}
+
+	old := make(map[uint64]struct{}, len(h.positions))
+	for _, p := range h.positions {
+		old[p.hash] = struct{}{}
+	}
+
+	previous, previousName := h.config.HashFunction, h.config.HashName
+	h.config.HashFunction, h.config.HashName = f, ""
+
+	// compute the whole new layout before replacing the slice so a hashing
+	// failure leaves the ring as it was
+	positions, err := h.rebuildLayout(func(m *member) int { return len(m.tokens) })
+	if err != nil {
+		h.config.HashFunction, h.config.HashName = previous, previousName
+		return 0, err
+	}
+
+	changed := 0
+	for _, p := range positions {
+		if _, ok := old[p.hash]; !ok {
+			changed++
+		}
+	}
+	h.installLayout(positions)
+
+	if h.config.EnableLogs {
+		log.Printf("[HashRing] Hash function changed, %d of %d positions moved", changed, len(positions))
+	}
+
+	return changed, nil
+}
+
+// ResizeVirtualNodes regenerates every node's tokens for a new base virtual
+// node count, keeping each node's weight. The new layout is built before it
+// replaces the old one under a single write lock, so readers see either the
+// old or the fully new ring. Tokens placed with AddServerWithTokens are kept.
+func (h *HashRing) ResizeVirtualNodes(count int) error {
+	if count < 1 {
+		return fmt.Errorf("%w: virtual nodes must be at least 1, got %d", ErrInvalidConfig, count)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	previous := h.config.VirtualNodes
+	h.config.VirtualNodes = count
+	positions, err := h.rebuildLayout(func(m *member) int { return h.vnodesFor(m.weight) })
+	if err == nil {
+		if max := h.config.MaxTotalVNodes; max > 0 && len(positions) > max {
+			err = fmt.Errorf("%w: resizing would need %d positions, limit is %d", ErrTooManyVNodes, len(positions), max)
+		}
+	}
+	if err != nil {
+		h.config.VirtualNodes = previous
+		return err
+	}
+	h.installLayout(positions)
+	h.hostMap.Range(func(_, val any) bool {
+		val.(*member).target = 0 // joins in progress end at the new count
+		return true
+	})
+
+	if h.config.EnableLogs {
+		log.Printf("[HashRing] Virtual nodes resized %d -> %d, %d positions", previous, count, len(positions))
+	}
+
+	return nil
+}
+
+// rebuildLayout derives fresh tokens for every member with the current hash
+// function, count(m) virtual nodes each, and returns the sorted positions
+// without installing them. Members are visited in ID order so the same
+// membership always salts the same colliding tokens. Callers must hold h.mu.
+func (h *HashRing) rebuildLayout(count func(m *member) int) ([]position, error) {
+	var ids []string
+	h.hostMap.Range(func(key, _ any) bool {
+		ids = append(ids, key.(string))
+		return true
+	})
+	slices.Sort(ids)
+
+	positions := make([]position, 0, len(h.positions))
+	placed := make(map[uint64]struct{}, len(h.positions))
+	taken := func(hash uint64) bool {
+		_, ok := placed[hash]
+		return ok
+	}
+
+	// tokens supplied by the caller aren't derived from the hash function
+	// and stay where they are
+	for _, id := range ids {
+		val, _ := h.hostMap.Load(id)
+		if m := val.(*member); m.pinned {
+			for i, hash := range m.tokens {
+				placed[hash] = struct{}{}
+				positions = append(positions, position{hash: hash, node: m.node, vnode: i})
+			}
+		}
+	}
+	for _, id := range ids {
+		val, _ := h.hostMap.Load(id)
+		m := val.(*member)
+		if m.pinned {
+			continue
+		}
+		for i := 0; i < count(m); i++ {
+			hash, err := h.placeVNode(id, i, taken)
+			if err != nil {
+				return nil, err
+			}
+			placed[hash] = struct{}{}
+			positions = append(positions, position{hash: hash, node: m.node, vnode: i})
+		}
+	}
+	slices.SortFunc(positions, comparePositions)
+	return positions, nil
+}
+
+// installLayout replaces the ring's positions with a rebuilt layout and
+// refreshes each member's token list to match. Callers must hold h.mu.
+func (h *HashRing) installLayout(positions []position) {
+	tokens := make(map[string][]uint64)
+	for _, p := range positions {
+		id := p.node.GetIdentifier()
+		memberTokens := tokens[id]
+		for len(memberTokens) <= p.vnode {
+			memberTokens = append(memberTokens, 0)
+		}
+		memberTokens[p.vnode] = p.hash
+		tokens[id] = memberTokens
+	}
+	h.hostMap.Range(func(key, val any) bool {
+		m := val.(*member)
+		m.tokens = tokens[key.(string)]
+		if len(m.tokens) >= m.target {
+			m.target = 0
+		}
+		return true
+	})
+	h.positions = positions
+}
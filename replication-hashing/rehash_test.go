@@ -0,0 +1,123 @@
+package replicationhashing
+
+import (
+	"errors"
+	"hash/fnv"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestChangeHashFunctionEmptyRing(t *testing.T) {
+	ring := InitHashRing()
+	changed, err := ring.ChangeHashFunction(fnv.New64, false)
+	if err != nil || changed != 0 {
+		t.Fatalf("got %d, %v; want 0, nil", changed, err)
+	}
+
+	// nodes added afterwards are placed with the new function
+	if err := ring.AddServers(testNodes("a", "b")); err != nil {
+		t.Fatalf("AddServers: %v", err)
+	}
+	fresh := newTestRing(t, []string{"a", "b"}, SetHashFunction(fnv.New64))
+	if !slices.Equal(ring.Tokens(), fresh.Tokens()) {
+		t.Fatal("tokens differ from a ring built with the new hash function")
+	}
+}
+
+func TestChangeHashFunctionPopulatedRing(t *testing.T) {
+	ids := []string{"a", "b", "c"}
+	ring := newTestRing(t, ids, SetVirtualNodes(10))
+	before := ring.Tokens()
+
+	if _, err := ring.ChangeHashFunction(fnv.New64, false); !errors.Is(err, ErrRingNotEmpty) {
+		t.Fatalf("without force: got %v, want ErrRingNotEmpty", err)
+	}
+	if !slices.Equal(ring.Tokens(), before) {
+		t.Fatal("refused change still modified the ring")
+	}
+
+	changed, err := ring.ChangeHashFunction(fnv.New64, true)
+	if err != nil {
+		t.Fatalf("with force: %v", err)
+	}
+	if changed != 30 {
+		t.Fatalf("%d positions changed, want all 30", changed)
+	}
+	fresh := newTestRing(t, ids, SetVirtualNodes(10), SetHashFunction(fnv.New64))
+	if !slices.Equal(ring.Tokens(), fresh.Tokens()) {
+		t.Fatal("rebuilt tokens differ from a ring built with the new hash function")
+	}
+	keys := sampleKeys(300)
+	if !maps.Equal(owners(t, ring, keys), owners(t, fresh, keys)) {
+		t.Fatal("lookups don't use the new hash function")
+	}
+}
+
+func TestChangeHashFunctionNil(t *testing.T) {
+	if _, err := InitHashRing().ChangeHashFunction(nil, true); !errors.Is(err, ErrNilHashFunction) {
+		t.Fatalf("got %v, want ErrNilHashFunction", err)
+	}
+}
+
+func TestResizeVirtualNodes(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b"}, SetVirtualNodes(3))
+	if err := ring.AddServerWithWeight(testNode("heavy"), 2); err != nil {
+		t.Fatalf("AddServerWithWeight: %v", err)
+	}
+	if err := ring.AddServerWithTokens(testNode("fixed"), []uint64{7, 70}); err != nil {
+		t.Fatalf("AddServerWithTokens: %v", err)
+	}
+
+	if err := ring.ResizeVirtualNodes(20); err != nil {
+		t.Fatalf("ResizeVirtualNodes: %v", err)
+	}
+	if got := ring.VirtualNodes(); got != 20 {
+		t.Errorf("VirtualNodes() = %d, want 20", got)
+	}
+	want := map[string]int{"a": 20, "b": 20, "heavy": 40, "fixed": 2}
+	for id, n := range want {
+		if got := len(memberTokens(t, ring, id)); got != n {
+			t.Errorf("%s has %d tokens, want %d", id, got, n)
+		}
+	}
+	if got := len(ring.Tokens()); got != 82 {
+		t.Errorf("ring has %d tokens, want 82", got)
+	}
+	if got := memberTokens(t, ring, "fixed"); !slices.Equal(got, []uint64{7, 70}) {
+		t.Errorf("explicit tokens moved to %v", got)
+	}
+
+	// the layout matches a ring built with the new count from the start
+	fresh := newTestRing(t, []string{"a", "b"}, SetVirtualNodes(20))
+	for _, id := range []string{"a", "b"} {
+		if !slices.Equal(memberTokens(t, ring, id), memberTokens(t, fresh, id)) {
+			t.Errorf("%s tokens differ from a ring built with 20 virtual nodes", id)
+		}
+	}
+
+	before := ring.Tokens()
+	for _, count := range []int{0, -1} {
+		if err := ring.ResizeVirtualNodes(count); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("ResizeVirtualNodes(%d) = %v, want ErrInvalidConfig", count, err)
+		}
+	}
+	if !slices.Equal(ring.Tokens(), before) {
+		t.Error("a rejected resize modified the ring")
+	}
+}
+
+func TestResizeVirtualNodesRespectsLimit(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b"}, SetVirtualNodes(3), SetMaxTotalVirtualNodes(10))
+	before := ring.Tokens()
+
+	if err := ring.ResizeVirtualNodes(6); !errors.Is(err, ErrTooManyVNodes) {
+		t.Fatalf("ResizeVirtualNodes(6) = %v, want ErrTooManyVNodes", err)
+	}
+	if got := ring.VirtualNodes(); got != 3 {
+		t.Errorf("VirtualNodes() = %d after a failed resize, want 3", got)
+	}
+	if !slices.Equal(ring.Tokens(), before) {
+		t.Error("a failed resize modified the ring")
+	}
+}
@@ -0,0 +1,50 @@
+package replicationhashing
+
+import (
+	"errors"
+	"fmt"
+)
+
+// INodeRole can be implemented by nodes that are temporarily read-only, e.g.
+// during maintenance. Nodes that don't implement it accept writes.
+type INodeRole interface {
+	AcceptsWrites() bool
+}
+
+func acceptsWrites(node ICacheNode) bool {
+	if r, ok := node.(INodeRole); ok {
+		return r.AcceptsWrites()
+	}
+	return true
+}
+
+// GetWriteServer returns the node that should receive writes for key: the
+// first node clockwise from the key's position that accepts writes and isn't
+// marked down. Reads
+// should keep using GetServer, which still routes to read-only nodes.
+func (h *HashRing) GetWriteServer(key string) (ICacheNode, error) {
+	if err := h.lockForLookup(); err != nil {
+		return nil, err
+	}
+	defer h.mu.RUnlock()
+
+	hashValue, err := h.hashKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w : %s", ErrInHashingKey, key)
+	}
+
+	healthy := h.healthyFilter()
+	node, _, err := h.ownerOf(hashValue, func(node ICacheNode) bool {
+		if healthy != nil && !healthy(node) {
+			return false
+		}
+		return acceptsWrites(node)
+	})
+	if err != nil {
+		if errors.Is(err, ErrNodeNotFound) {
+			return nil, fmt.Errorf("%w: no writable node for key %s", ErrNodeNotFound, key)
+		}
+		return nil, err
+	}
+	return node, nil
+}
@@ -0,0 +1,87 @@
+package replicationhashing
+
+import (
+	"errors"
+	"testing"
+)
+
+// roleNode is a node that can be made read-only.
+type roleNode struct {
+	id       string
+	readOnly bool
+}
+
+func (n roleNode) GetIdentifier() string { return n.id }
+func (n roleNode) AcceptsWrites() bool   { return !n.readOnly }
+
+func TestGetWriteServerSkipsReadOnly(t *testing.T) {
+	ring := newTokenRing(t, map[string][]uint64{"b": {200}, "c": {300}})
+	if err := ring.AddServerWithTokens(roleNode{id: "a", readOnly: true}, []uint64{100}); err != nil {
+		t.Fatalf("AddServerWithTokens: %v", err)
+	}
+
+	tests := []struct {
+		key         string
+		read, write string
+	}{
+		{"50", "a", "b"},
+		{"100", "a", "b"},
+		{"150", "b", "b"},
+		{"350", "a", "b"},
+		{"250", "c", "c"},
+	}
+	for _, tt := range tests {
+		read, err := ring.GetServer(tt.key)
+		if err != nil {
+			t.Fatalf("GetServer(%q): %v", tt.key, err)
+		}
+		write, err := ring.GetWriteServer(tt.key)
+		if err != nil {
+			t.Fatalf("GetWriteServer(%q): %v", tt.key, err)
+		}
+		if read.GetIdentifier() != tt.read || write.GetIdentifier() != tt.write {
+			t.Errorf("key %s: read %s, write %s; want %s, %s", tt.key, read.GetIdentifier(), write.GetIdentifier(), tt.read, tt.write)
+		}
+	}
+}
+
+func TestGetWriteServerAllReadOnly(t *testing.T) {
+	ring := InitHashRing()
+	if err := ring.AddServer(roleNode{id: "a", readOnly: true}); err != nil {
+		t.Fatalf("AddServer: %v", err)
+	}
+	if _, err := ring.GetWriteServer("k"); !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("got %v, want ErrNodeNotFound", err)
+	}
+	if _, err := ring.GetServer("k"); err != nil {
+		t.Fatalf("reads should still land on a read-only node: %v", err)
+	}
+}
+
+func TestGetWriteServerSkipsDownNodes(t *testing.T) {
+	ring := newTokenRing(t, map[string][]uint64{"b": {200}, "c": {300}})
+	if err := ring.AddServerWithTokens(roleNode{id: "a", readOnly: true}, []uint64{100}); err != nil {
+		t.Fatalf("AddServerWithTokens: %v", err)
+	}
+	// b accepts writes but is down, so writes for its arc go on to c
+	if err := ring.MarkDown("b"); err != nil {
+		t.Fatalf("MarkDown: %v", err)
+	}
+
+	for _, key := range []string{"50", "150", "250", "350"} {
+		write, err := ring.GetWriteServer(key)
+		if err != nil {
+			t.Fatalf("GetWriteServer(%q): %v", key, err)
+		}
+		if write.GetIdentifier() != "c" {
+			t.Errorf("GetWriteServer(%q) = %s, want c", key, write.GetIdentifier())
+		}
+	}
+
+	if err := ring.MarkDown("c"); err != nil {
+		t.Fatalf("MarkDown: %v", err)
+	}
+	if _, err := ring.GetWriteServer("150"); !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("every writable node down: got %v, want ErrNodeNotFound", err)
+	}
+}
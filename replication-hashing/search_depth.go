@@ -0,0 +1,48 @@
+package replicationhashing
+
+import "sync"
+
+// searchDepthStats aggregates the number of comparisons sort.Search performs
+// per lookup. It has its own mutex so it can be updated from the read path.
+type searchDepthStats struct {
+	mu      sync.Mutex
+	samples int
+	total   int
+	min     int
+	max     int
+}
+
+func (s *searchDepthStats) record(depth int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.samples == 0 || depth < s.min {
+		s.min = depth
+	}
+	if depth > s.max {
+		s.max = depth
+	}
+	s.samples++
+	s.total += depth
+}
+
+// EnableSearchDepthStats records how many comparisons each lookup's binary
+// search performs. Intended as a diagnostic for very large rings.
+func EnableSearchDepthStats(enabled bool) HashRingConfigFn {
+	return func(config *hashRingConfig) {
+		config.TrackSearchDepth = enabled
+	}
+}
+
+// SearchDepthStats returns the minimum, maximum and average number of
+// comparisons per lookup observed so far. All values are zero when tracking
+// is disabled or no lookups have happened yet.
+func (h *HashRing) SearchDepthStats() (min, max, avg int) {
+	h.depth.mu.Lock()
+	defer h.depth.mu.Unlock()
+
+	if h.depth.samples == 0 {
+		return 0, 0, 0
+	}
+	return h.depth.min, h.depth.max, h.depth.total / h.depth.samples
+}
@@ -0,0 +1,40 @@
+package replicationhashing
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestSearchDepthStats(t *testing.T) {
+	ids := make([]string, 100)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("node-%03d", i)
+	}
+	ring := newTestRing(t, ids, SetVirtualNodes(100), EnableSearchDepthStats(true))
+
+	for _, key := range sampleKeys(2000) {
+		if _, err := ring.GetServer(key); err != nil {
+			t.Fatalf("GetServer: %v", err)
+		}
+	}
+
+	minDepth, maxDepth, avg := ring.SearchDepthStats()
+	want := math.Log2(float64(ring.VirtualNodeCount()))
+	if math.Abs(float64(avg)-want) > 1 {
+		t.Fatalf("average depth %d, want about log2(%d) = %.1f", avg, ring.VirtualNodeCount(), want)
+	}
+	if minDepth > avg || avg > maxDepth {
+		t.Fatalf("min %d, avg %d, max %d out of order", minDepth, avg, maxDepth)
+	}
+}
+
+func TestSearchDepthStatsDisabled(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b"})
+	if _, err := ring.GetServer("key"); err != nil {
+		t.Fatalf("GetServer: %v", err)
+	}
+	if minDepth, maxDepth, avg := ring.SearchDepthStats(); minDepth != 0 || maxDepth != 0 || avg != 0 {
+		t.Fatalf("got %d/%d/%d with tracking disabled, want zeros", minDepth, maxDepth, avg)
+	}
+}
@@ -0,0 +1,120 @@
+package replicationhashing
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// SimulateRemove reports how many of keys would change owner if nodeID were
+// removed, without touching the ring. Like a RingView it follows the token
+// layout only, so pins, MarkDown and DefaultNode are not applied.
+func (h *HashRing) SimulateRemove(nodeID string, keys []string) (moved, total int, err error) {
+	h.mu.RLock()
+	if _, exists := h.hostMap.Load(nodeID); !exists {
+		h.mu.RUnlock()
+		return 0, 0, fmt.Errorf("%w : %s", ErrNodeNotFound, nodeID)
+	}
+	before := h.snapshot()
+	h.mu.RUnlock()
+
+	after := before
+	after.positions = slices.DeleteFunc(slices.Clone(before.positions), func(p position) bool {
+		return p.node.GetIdentifier() == nodeID
+	})
+	return diffViews(before, after, keys)
+}
+
+// SimulateAdd reports how many of keys would change owner if node were added
+// with AddServer, without touching the ring. The node gets the tokens
+// AddServer would place right away, so a gradual join counts its first step
+// only. See SimulateRemove for what the comparison covers.
+func (h *HashRing) SimulateAdd(node ICacheNode, keys []string) (moved, total int, err error) {
+	h.mu.RLock()
+	m, err := h.placeMember(node, 1, 0, h.tokenTaken)
+	if err != nil {
+		h.mu.RUnlock()
+		return 0, 0, err
+	}
+	before := h.snapshot()
+	h.mu.RUnlock()
+
+	after := before
+	after.positions = slices.Clone(before.positions)
+	for i, token := range m.tokens {
+		after.positions = append(after.positions, position{hash: token, node: node, vnode: i})
+	}
+	slices.SortStableFunc(after.positions, func(a, b position) int {
+		return cmp.Compare(a.hash, b.hash)
+	})
+	return diffViews(before, after, keys)
+}
+
+// diffViews counts the keys whose owner differs between two views. A key
+// that has an owner in only one of them counts as moved.
+func diffViews(before, after RingView, keys []string) (moved, total int, err error) {
+	if before.Len() == 0 || after.Len() == 0 {
+		return len(keys), len(keys), nil
+	}
+	for _, key := range keys {
+		was, err := before.Get(key)
+		if err != nil {
+			return 0, 0, err
+		}
+		now, err := after.Get(key)
+		if err != nil {
+			return 0, 0, err
+		}
+		if was.GetIdentifier() != now.GetIdentifier() {
+			moved++
+		}
+	}
+	return moved, len(keys), nil
+}
+
+// OwnershipChange is an arc of the hash space that changes owner.
+type OwnershipChange struct {
+	Range Range
+	From  string
+	To    string
+}
+
+// WhatMoves returns the exact arcs node would take over if it were added
+// with AddServer, one per new token in ring order, each naming the node that
+// owns it today. Together they cover what GetOwnedRanges would report for
+// node after the add. On an empty ring From is empty. The ring is not
+// modified.
+func (h *HashRing) WhatMoves(node ICacheNode) ([]OwnershipChange, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	m, err := h.placeMember(node, 1, 0, h.tokenTaken)
+	if err != nil {
+		return nil, err
+	}
+	tokens := slices.Sorted(slices.Values(m.tokens))
+
+	changes := make([]OwnershipChange, 0, len(tokens))
+	for i, token := range tokens {
+		// the arc starts after whichever token precedes this one once node
+		// has joined: its own previous token or an existing one. Measuring
+		// token-x-1 makes a lone token its own predecessor, i.e. the full ring.
+		prev := tokens[(i-1+len(tokens))%len(tokens)]
+		from := ""
+		if index, err := h.search(h.positions, token); err == nil {
+			from = h.positions[index].node.GetIdentifier()
+			existing := h.positions[(index-1+len(h.positions))%len(h.positions)].hash
+			if token-existing-1 < token-prev-1 {
+				prev = existing
+			}
+		}
+
+		start := prev + 1
+		changes = append(changes, OwnershipChange{
+			Range: Range{Start: start, End: token, Wraps: start > token},
+			From:  from,
+			To:    node.GetIdentifier(),
+		})
+	}
+	return changes, nil
+}
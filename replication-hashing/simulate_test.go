@@ -0,0 +1,180 @@
+package replicationhashing
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// movedKeys counts the keys whose owner differs between two owner maps.
+func movedKeys(before, after map[string]string) int {
+	moved := 0
+	for key, owner := range before {
+		if after[key] != owner {
+			moved++
+		}
+	}
+	return moved
+}
+
+func TestSimulateRemove(t *testing.T) {
+	fleet := []string{"a", "b", "c", "d"}
+	ring := newTestRing(t, fleet, SetVirtualNodes(20))
+	keys := sampleKeys(2000)
+	tokens := ring.Tokens()
+	before := owners(t, ring, keys)
+
+	moved, total, err := ring.SimulateRemove("b", keys)
+	if err != nil {
+		t.Fatalf("SimulateRemove: %v", err)
+	}
+	if total != len(keys) {
+		t.Fatalf("total = %d, want %d", total, len(keys))
+	}
+	if !slices.Equal(ring.Tokens(), tokens) || !ring.HasNode("b") {
+		t.Fatal("SimulateRemove modified the ring")
+	}
+
+	if err := ring.RemoveServerByID("b"); err != nil {
+		t.Fatalf("RemoveServerByID: %v", err)
+	}
+	if actual := movedKeys(before, owners(t, ring, keys)); moved != actual || moved == 0 {
+		t.Fatalf("SimulateRemove predicted %d moves, removing b moved %d", moved, actual)
+	}
+}
+
+func TestSimulateAdd(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c"}, SetVirtualNodes(20))
+	keys := sampleKeys(2000)
+	tokens := ring.Tokens()
+	before := owners(t, ring, keys)
+
+	moved, total, err := ring.SimulateAdd(testNode("d"), keys)
+	if err != nil {
+		t.Fatalf("SimulateAdd: %v", err)
+	}
+	if total != len(keys) {
+		t.Fatalf("total = %d, want %d", total, len(keys))
+	}
+	if !slices.Equal(ring.Tokens(), tokens) || ring.HasNode("d") {
+		t.Fatal("SimulateAdd modified the ring")
+	}
+
+	if err := ring.AddServer(testNode("d")); err != nil {
+		t.Fatalf("AddServer: %v", err)
+	}
+	after := owners(t, ring, keys)
+	if actual := movedKeys(before, after); moved != actual || moved == 0 {
+		t.Fatalf("SimulateAdd predicted %d moves, adding d moved %d", moved, actual)
+	}
+	// only keys taken over by the new node move
+	for key, owner := range after {
+		if owner != before[key] && owner != "d" {
+			t.Fatalf("key %q moved from %s to %s, not to the new node", key, before[key], owner)
+		}
+	}
+}
+
+func TestSimulateErrors(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b"})
+	keys := sampleKeys(10)
+
+	if _, _, err := ring.SimulateRemove("ghost", keys); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("SimulateRemove(ghost) = %v, want ErrNodeNotFound", err)
+	}
+	if _, _, err := ring.SimulateAdd(testNode("a"), keys); !errors.Is(err, ErrNodeExists) {
+		t.Errorf("SimulateAdd(existing) = %v, want ErrNodeExists", err)
+	}
+
+	// every key moves when the ring gains its first node or loses its last
+	empty := InitHashRing()
+	if moved, total, err := empty.SimulateAdd(testNode("a"), keys); err != nil || moved != len(keys) || total != len(keys) {
+		t.Errorf("SimulateAdd on an empty ring = %d, %d, %v; want %d, %d, nil", moved, total, err, len(keys), len(keys))
+	}
+	single := newTestRing(t, []string{"a"})
+	if moved, total, err := single.SimulateRemove("a", keys); err != nil || moved != len(keys) || total != len(keys) {
+		t.Errorf("SimulateRemove of the last node = %d, %d, %v; want %d, %d, nil", moved, total, err, len(keys), len(keys))
+	}
+}
+
+func TestWhatMoves(t *testing.T) {
+	tests := []struct {
+		name string
+		ring func(t *testing.T) *HashRing
+	}{
+		{"hashed", func(t *testing.T) *HashRing {
+			return newTestRing(t, []string{"a", "b", "c"}, SetVirtualNodes(20))
+		}},
+		// most of the new tokens follow another new token rather than a's
+		{"one existing token", func(t *testing.T) *HashRing {
+			return newTokenRing(t, map[string][]uint64{"a": {1 << 63}}, SetVirtualNodes(8))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring := tt.ring(t)
+			tokens := ring.Tokens()
+
+			changes, err := ring.WhatMoves(testNode("n"))
+			if err != nil {
+				t.Fatalf("WhatMoves: %v", err)
+			}
+			if !slices.Equal(ring.Tokens(), tokens) || ring.HasNode("n") {
+				t.Fatal("WhatMoves modified the ring")
+			}
+
+			// each arc comes from the node that owns all of it today
+			var moving []Range
+			for _, c := range changes {
+				if c.To != "n" {
+					t.Fatalf("arc %+v moves to %s, want n", c.Range, c.To)
+				}
+				for _, hash := range []uint64{c.Range.Start, c.Range.End} {
+					owner, err := ring.GetServerByHash(hash)
+					if err != nil {
+						t.Fatalf("GetServerByHash(%d): %v", hash, err)
+					}
+					if owner.GetIdentifier() != c.From {
+						t.Fatalf("arc %+v reports owner %s, but %d belongs to %s", c.Range, c.From, hash, owner.GetIdentifier())
+					}
+				}
+				moving = append(moving, c.Range)
+			}
+
+			if err := ring.AddServer(testNode("n")); err != nil {
+				t.Fatalf("AddServer: %v", err)
+			}
+			owned, err := ring.GetOwnedRanges("n")
+			if err != nil {
+				t.Fatalf("GetOwnedRanges: %v", err)
+			}
+			byEnd := func(a, b Range) int { return cmp.Compare(a.End, b.End) }
+			slices.SortFunc(moving, byEnd)
+			slices.SortFunc(owned, byEnd)
+			if !slices.Equal(moving, owned) {
+				t.Fatalf("WhatMoves reported %v, n owns %v after the add", moving, owned)
+			}
+		})
+	}
+}
+
+func TestWhatMovesEmptyRing(t *testing.T) {
+	ring := InitHashRing(SetVirtualNodes(5))
+	changes, err := ring.WhatMoves(testNode("n"))
+	if err != nil {
+		t.Fatalf("WhatMoves: %v", err)
+	}
+	ranges := make([]Range, len(changes))
+	for i, c := range changes {
+		if c.From != "" || c.To != "n" {
+			t.Fatalf("change %+v on an empty ring, want From empty and To n", c)
+		}
+		ranges[i] = c.Range
+	}
+	checkCoverage(t, ranges)
+
+	if _, err := newTestRing(t, []string{"a"}).WhatMoves(testNode("a")); !errors.Is(err, ErrNodeExists) {
+		t.Fatalf("WhatMoves for a member = %v, want ErrNodeExists", err)
+	}
+}
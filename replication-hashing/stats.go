@@ -0,0 +1,60 @@
+package replicationhashing
+
+import "math"
+
+// RingStats reports runtime counters and the balance of the ring.
+type RingStats struct {
+	// EmptyRingLookups counts GetServer calls that failed with
+	// ErrNoConnectedNodes, typically requests racing node registration at
+	// startup.
+	EmptyRingLookups uint64
+
+	// Shares maps each physical node to the fraction of the hash space it
+	// owns, summed over its virtual-node arcs. The shares add up to 1.
+	Shares map[string]float64
+	// MinShare, MaxShare, MeanShare and StddevShare aggregate Shares across
+	// nodes; the standard deviation is the population one. All are 0 on an
+	// empty ring.
+	MinShare    float64
+	MaxShare    float64
+	MeanShare   float64
+	StddevShare float64
+}
+
+// Stats returns a snapshot of the ring's runtime counters and balance.
+func (h *HashRing) Stats() RingStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	stats := RingStats{
+		EmptyRingLookups: h.emptyLookups.Load(),
+		Shares:           h.ownershipShares(),
+	}
+	// members without a position yet still count towards the spread
+	h.hostMap.Range(func(key, _ any) bool {
+		if _, ok := stats.Shares[key.(string)]; !ok {
+			stats.Shares[key.(string)] = 0
+		}
+		return true
+	})
+	if len(stats.Shares) == 0 {
+		return stats
+	}
+
+	stats.MinShare = math.Inf(1)
+	sum := 0.0
+	for _, share := range stats.Shares {
+		stats.MinShare = math.Min(stats.MinShare, share)
+		stats.MaxShare = math.Max(stats.MaxShare, share)
+		sum += share
+	}
+	stats.MeanShare = sum / float64(len(stats.Shares))
+
+	variance := 0.0
+	for _, share := range stats.Shares {
+		variance += (share - stats.MeanShare) * (share - stats.MeanShare)
+	}
+	stats.StddevShare = math.Sqrt(variance / float64(len(stats.Shares)))
+
+	return stats
+}
@@ -0,0 +1,110 @@
+package replicationhashing
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestStatsCountsEmptyRingLookups(t *testing.T) {
+	ring := InitHashRing()
+	for i := 0; i < 5; i++ {
+		if _, err := ring.GetServer("key"); !errors.Is(err, ErrNoConnectedNodes) {
+			t.Fatalf("GetServer on an empty ring: got %v, want ErrNoConnectedNodes", err)
+		}
+	}
+	if got := ring.Stats().EmptyRingLookups; got != 5 {
+		t.Fatalf("EmptyRingLookups = %d, want 5", got)
+	}
+
+	if err := ring.AddServer(testNode("a")); err != nil {
+		t.Fatalf("AddServer: %v", err)
+	}
+	if _, err := ring.GetServer("key"); err != nil {
+		t.Fatalf("GetServer: %v", err)
+	}
+	if got := ring.Stats().EmptyRingLookups; got != 5 {
+		t.Fatalf("successful lookup changed EmptyRingLookups to %d", got)
+	}
+}
+
+func TestStatsShares(t *testing.T) {
+	const q = 1 << 62 // a quarter of the hash space
+	tests := []struct {
+		name   string
+		tokens map[string][]uint64
+		want   map[string]float64
+	}{
+		{
+			// a owns the wrap-around arc past the last token up to its own
+			name:   "wrap-around arc",
+			tokens: map[string][]uint64{"a": {q}, "b": {2 * q}, "c": {3 * q}},
+			want:   map[string]float64{"a": 0.5, "b": 0.25, "c": 0.25},
+		},
+		{
+			name:   "arcs summed per node",
+			tokens: map[string][]uint64{"a": {0, 2 * q}, "b": {q, 3 * q}},
+			want:   map[string]float64{"a": 0.5, "b": 0.5},
+		},
+		{
+			name:   "uneven arcs",
+			tokens: map[string][]uint64{"a": {q / 2}, "b": {q}, "c": {3 * q}},
+			want:   map[string]float64{"a": 0.375, "b": 0.125, "c": 0.5},
+		},
+		{
+			name:   "single token",
+			tokens: map[string][]uint64{"solo": {12345}},
+			want:   map[string]float64{"solo": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := newTokenRing(t, tt.tokens).Stats()
+			if len(stats.Shares) != len(tt.want) {
+				t.Fatalf("Shares = %v, want %v", stats.Shares, tt.want)
+			}
+			minShare, maxShare, sum := math.Inf(1), 0.0, 0.0
+			for id, want := range tt.want {
+				if got := stats.Shares[id]; math.Abs(got-want) > 1e-12 {
+					t.Errorf("%s owns %v of the hash space, want %v", id, got, want)
+				}
+				minShare, maxShare, sum = math.Min(minShare, want), math.Max(maxShare, want), sum+want
+			}
+			mean := sum / float64(len(tt.want))
+			variance := 0.0
+			for _, want := range tt.want {
+				variance += (want - mean) * (want - mean)
+			}
+			stddev := math.Sqrt(variance / float64(len(tt.want)))
+
+			for name, c := range map[string][2]float64{
+				"MinShare":    {stats.MinShare, minShare},
+				"MaxShare":    {stats.MaxShare, maxShare},
+				"MeanShare":   {stats.MeanShare, mean},
+				"StddevShare": {stats.StddevShare, stddev},
+			} {
+				if math.Abs(c[0]-c[1]) > 1e-12 {
+					t.Errorf("%s = %v, want %v", name, c[0], c[1])
+				}
+			}
+		})
+	}
+}
+
+func TestStatsSharesKnownSpread(t *testing.T) {
+	stats := newTokenRing(t, map[string][]uint64{"a": {1 << 62}, "b": {2 << 62}, "c": {3 << 62}}).Stats()
+	// shares of 1/2, 1/4 and 1/4 give a population stddev of sqrt(1/72)
+	if want := math.Sqrt(1.0 / 72); math.Abs(stats.StddevShare-want) > 1e-12 {
+		t.Errorf("StddevShare = %v, want %v", stats.StddevShare, want)
+	}
+	if stats.MinShare != 0.25 || stats.MaxShare != 0.5 || math.Abs(stats.MeanShare-1.0/3) > 1e-12 {
+		t.Errorf("min/max/mean = %v/%v/%v, want 0.25/0.5/0.333", stats.MinShare, stats.MaxShare, stats.MeanShare)
+	}
+}
+
+func TestStatsSharesEmptyRing(t *testing.T) {
+	stats := InitHashRing().Stats()
+	if len(stats.Shares) != 0 || stats.MinShare != 0 || stats.MaxShare != 0 || stats.MeanShare != 0 || stats.StddevShare != 0 {
+		t.Fatalf("Stats on an empty ring = %+v, want zero shares", stats)
+	}
+}
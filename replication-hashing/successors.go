@@ -0,0 +1,88 @@
+package replicationhashing
+
+import "fmt"
+
+// GetN returns up to n distinct physical nodes for key, starting with its
+// owner and walking clockwise. If the ring has fewer than n usable nodes,
+// the ones found are returned with ErrInsufficientNodes.
+func (h *HashRing) GetN(key string, n int) ([]ICacheNode, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidCount, n)
+	}
+
+	if err := h.lockForLookup(); err != nil {
+		return nil, err
+	}
+	defer h.mu.RUnlock()
+
+	hashValue, err := h.hashKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w : %s", ErrInHashingKey, key)
+	}
+
+	positions := h.lookupPositions()
+	index, err := h.search(positions, hashValue)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := successors(positions, index, n)
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("%w: no node found for key %s", ErrNodeNotFound, key)
+	}
+	if len(nodes) < n {
+		return nodes, fmt.Errorf("%w: found %d of %d", ErrInsufficientNodes, len(nodes), n)
+	}
+	return nodes, nil
+}
+
+// successors walks positions clockwise from index, collecting up to n
+// distinct usable nodes.
+func successors(positions []position, index, n int) []ICacheNode {
+	nodes := make([]ICacheNode, 0, n)
+	seen := make(map[string]struct{}, n)
+	for i := 0; i < len(positions) && len(nodes) < n; i++ {
+		p := positions[(index+i)%len(positions)]
+		if p.node == nil || p.node.GetIdentifier() == "" {
+			continue
+		}
+		id := p.node.GetIdentifier()
+		if _, dup := seen[id]; dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		nodes = append(nodes, p.node)
+	}
+	return nodes
+}
+
+// NextNode returns the node at the token immediately after the one owning
+// key, wrapping around the end of the ring.
+func (h *HashRing) NextNode(key string) (ICacheNode, error) {
+	return h.stepFromOwner(key, 1)
+}
+
+// PrevNode returns the node at the token immediately before the one owning
+// key, wrapping around the start of the ring.
+func (h *HashRing) PrevNode(key string) (ICacheNode, error) {
+	return h.stepFromOwner(key, -1)
+}
+
+func (h *HashRing) stepFromOwner(key string, step int) (ICacheNode, error) {
+	if err := h.lockForLookup(); err != nil {
+		return nil, err
+	}
+	defer h.mu.RUnlock()
+
+	hashValue, err := h.hashKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w : %s", ErrInHashingKey, key)
+	}
+
+	positions := h.lookupPositions()
+	index, err := h.search(positions, hashValue)
+	if err != nil {
+		return nil, err
+	}
+	return positions[(index+step+len(positions))%len(positions)].node, nil
+}
@@ -0,0 +1,46 @@
+package replicationhashing
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestGetN(t *testing.T) {
+	ring := newTokenRing(t, map[string][]uint64{"a": {100, 120}, "b": {200}, "c": {300}})
+	tests := []struct {
+		name string
+		key  string
+		n    int
+		want []string
+		err  error
+	}{
+		{"owner only", "150", 1, []string{"b"}, nil},
+		{"skips the owner's other vnode", "90", 2, []string{"a", "b"}, nil},
+		{"wraps past the last token", "250", 3, []string{"c", "a", "b"}, nil},
+		{"key beyond the last token", "350", 2, []string{"a", "b"}, nil},
+		{"more than the cluster", "110", 4, []string{"a", "b", "c"}, ErrInsufficientNodes},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, err := ring.GetN(tt.key, tt.n)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("GetN(%s, %d): got %v, want %v", tt.key, tt.n, err, tt.err)
+			}
+			if got := ids(nodes); !slices.Equal(got, tt.want) {
+				t.Fatalf("GetN(%s, %d) = %v, want %v", tt.key, tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetNErrors(t *testing.T) {
+	ring := newTestRing(t, []string{"a"})
+	if _, err := ring.GetN("k", 0); !errors.Is(err, ErrInvalidCount) {
+		t.Fatalf("GetN with n=0: got %v, want ErrInvalidCount", err)
+	}
+	empty := newTestRing(t, nil)
+	if _, err := empty.GetN("k", 1); !errors.Is(err, ErrNoConnectedNodes) {
+		t.Fatalf("GetN on an empty ring: got %v, want ErrNoConnectedNodes", err)
+	}
+}
@@ -0,0 +1,112 @@
+package replicationhashing
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// TokenInfo describes one token on the ring and the virtual node that
+// produced it.
+type TokenInfo struct {
+	Hash       uint64
+	NodeID     string
+	VNodeIndex int
+}
+
+// Tokens returns the full token table sorted by hash.
+func (h *HashRing) Tokens() []TokenInfo {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	tokens := make([]TokenInfo, 0, len(h.positions))
+	h.hostMap.Range(func(key, val any) bool {
+		for i, hash := range val.(*member).tokens {
+			tokens = append(tokens, TokenInfo{Hash: hash, NodeID: key.(string), VNodeIndex: i})
+		}
+		return true
+	})
+	slices.SortFunc(tokens, func(a, b TokenInfo) int {
+		if c := cmp.Compare(a.Hash, b.Hash); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.NodeID, b.NodeID)
+	})
+	return tokens
+}
+
+// Range is an inclusive span of hash values. When Wraps is set the span runs
+// from Start through the top of the hash space and on from 0 to End.
+type Range struct {
+	Start, End uint64
+	Wraps      bool
+}
+
+// GetOwnedRanges returns the hash ranges nodeID is responsible for, in ring
+// order. Each of its tokens owns the arc after the preceding token up to and
+// including itself.
+func (h *HashRing) GetOwnedRanges(nodeID string) ([]Range, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if _, ok := h.hostMap.Load(nodeID); !ok {
+		return nil, fmt.Errorf("%w : %s", ErrNodeNotFound, nodeID)
+	}
+
+	var ranges []Range
+	for i, p := range h.positions {
+		if p.node.GetIdentifier() != nodeID {
+			continue
+		}
+		if i > 0 && h.positions[i-1].hash == p.hash {
+			// an equal token sorts first and owns the arc
+			continue
+		}
+		prev := h.positions[(i-1+len(h.positions))%len(h.positions)].hash
+		start := prev + 1
+		ranges = append(ranges, Range{Start: start, End: p.hash, Wraps: start > p.hash})
+	}
+	return ranges, nil
+}
+
+// LookupResult explains how a key was placed: its hash, the token that
+// matched and the virtual node and physical node behind that token. Pinned
+// and Default report a key settled by PinKey or by the default node instead
+// of a token; Token is then zero and VNodeIndex -1.
+type LookupResult struct {
+	KeyHash    uint64
+	Token      uint64
+	VNodeIndex int
+	Node       ICacheNode
+	Pinned     bool
+	Default    bool
+}
+
+// GetServerDetailed is GetServer but also reports the matched token and
+// virtual node, for debugging skew. It resolves keys exactly as GetServer
+// does, pins and the default node included.
+func (h *HashRing) GetServerDetailed(key string) (LookupResult, error) {
+	if err := h.lockForLookup(); err != nil {
+		return LookupResult{}, err
+	}
+	defer h.mu.RUnlock()
+
+	hashValue, err := h.hashKey(key)
+	if err != nil {
+		return LookupResult{}, fmt.Errorf("%w : %s", ErrInHashingKey, key)
+	}
+
+	if node, ok := h.pinnedServer(key); ok {
+		return LookupResult{KeyHash: hashValue, VNodeIndex: -1, Node: node, Pinned: true}, nil
+	}
+
+	p, fallback, err := h.resolvePosition(hashValue)
+	if err != nil {
+		if errors.Is(err, ErrNodeNotFound) {
+			return LookupResult{}, fmt.Errorf("%w: no node found for key %s", ErrNodeNotFound, key)
+		}
+		return LookupResult{}, err
+	}
+	return LookupResult{KeyHash: hashValue, Token: p.hash, VNodeIndex: p.vnode, Node: p.node, Default: fallback}, nil
+}
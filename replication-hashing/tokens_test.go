@@ -0,0 +1,239 @@
+package replicationhashing
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTokens(t *testing.T) {
+	const vnodes = 8
+	ring := newTestRing(t, []string{"a", "b", "c"}, SetVirtualNodes(vnodes))
+
+	tokens := ring.Tokens()
+	if len(tokens) != 3*vnodes {
+		t.Fatalf("%d tokens, want %d", len(tokens), 3*vnodes)
+	}
+	if !slices.IsSortedFunc(tokens, func(a, b TokenInfo) int { return cmp.Compare(a.Hash, b.Hash) }) {
+		t.Fatal("tokens are not sorted by hash")
+	}
+	indices := make(map[string][]int)
+	for _, tok := range tokens {
+		want, err := ring.vNodeHash(tok.NodeID, tok.VNodeIndex)
+		if err != nil || want != tok.Hash {
+			t.Fatalf("token %d is not virtual node %s_%d", tok.Hash, tok.NodeID, tok.VNodeIndex)
+		}
+		indices[tok.NodeID] = append(indices[tok.NodeID], tok.VNodeIndex)
+	}
+	for id, got := range indices {
+		if slices.Sort(got); !slices.Equal(got, []int{0, 1, 2, 3, 4, 5, 6, 7}) {
+			t.Fatalf("%s has virtual node indices %v, want 0 through 7", id, got)
+		}
+	}
+
+	if err := ring.RemoveServer(testNode("b")); err != nil {
+		t.Fatalf("RemoveServer: %v", err)
+	}
+	want := slices.DeleteFunc(tokens, func(tok TokenInfo) bool { return tok.NodeID == "b" })
+	if got := ring.Tokens(); !slices.Equal(got, want) {
+		t.Fatalf("tokens after removing b: %v, want %v", got, want)
+	}
+}
+
+// checkCoverage fails unless ranges cover the whole hash space exactly once.
+func checkCoverage(t *testing.T, ranges []Range) {
+	t.Helper()
+	var spans []Range
+	for _, r := range ranges {
+		if r.Wraps {
+			spans = append(spans, Range{Start: r.Start, End: math.MaxUint64}, Range{Start: 0, End: r.End})
+			continue
+		}
+		spans = append(spans, r)
+	}
+	slices.SortFunc(spans, func(a, b Range) int { return cmp.Compare(a.Start, b.Start) })
+
+	next := uint64(0)
+	for i, r := range spans {
+		if r.Start != next || r.End < r.Start {
+			t.Fatalf("span %d is [%d, %d], want it to start at %d", i, r.Start, r.End, next)
+		}
+		if r.End == math.MaxUint64 {
+			if i != len(spans)-1 {
+				t.Fatalf("%d spans left over past the top of the hash space", len(spans)-1-i)
+			}
+			return
+		}
+		next = r.End + 1
+	}
+	t.Fatalf("ranges stop at %d, short of the top of the hash space", next-1)
+}
+
+func TestGetOwnedRanges(t *testing.T) {
+	tests := []struct {
+		name string
+		ring func(t *testing.T) *HashRing
+	}{
+		{"hashed", func(t *testing.T) *HashRing {
+			return newTestRing(t, []string{"a", "b", "c"}, SetVirtualNodes(20))
+		}},
+		{"wrapping arc", func(t *testing.T) *HashRing {
+			return newTokenRing(t, map[string][]uint64{"a": {100, 500}, "b": {300}})
+		}},
+		{"tokens at both ends", func(t *testing.T) *HashRing {
+			return newTokenRing(t, map[string][]uint64{"a": {0, 500}, "b": {math.MaxUint64}})
+		}},
+		{"single token", func(t *testing.T) *HashRing {
+			return newTokenRing(t, map[string][]uint64{"a": {42}})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring := tt.ring(t)
+			var all []Range
+			for _, id := range ring.MemberIDs() {
+				ranges, err := ring.GetOwnedRanges(id)
+				if err != nil {
+					t.Fatalf("GetOwnedRanges(%s): %v", id, err)
+				}
+				for _, r := range ranges {
+					// a range's end is its owner's token
+					if node, err := ring.GetServerByHash(r.End); err != nil || node.GetIdentifier() != id {
+						t.Fatalf("range end %d of %s routes to %v (%v)", r.End, id, node, err)
+					}
+				}
+				all = append(all, ranges...)
+			}
+			checkCoverage(t, all)
+		})
+	}
+
+	ring := newTokenRing(t, map[string][]uint64{"a": {100, 500}, "b": {300}})
+	ranges, err := ring.GetOwnedRanges("a")
+	if err != nil {
+		t.Fatalf("GetOwnedRanges(a): %v", err)
+	}
+	want := []Range{{Start: 501, End: 100, Wraps: true}, {Start: 301, End: 500}}
+	if !slices.Equal(ranges, want) {
+		t.Fatalf("a owns %v, want %v", ranges, want)
+	}
+	if _, err := ring.GetOwnedRanges("x"); !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("unknown node: got %v, want ErrNodeNotFound", err)
+	}
+}
+
+func TestString(t *testing.T) {
+	tokens := map[string][]uint64{"a": {100, 300}, "b": {200}}
+	ring := newTokenRing(t, tokens, SetHashFunctionNamed("numeric", newNumericHash))
+
+	want := `HashRing{nodes: 2, tokens: 3, vnodes: 3, hash: numeric}
+  members: a, b
+                   100 a
+                   200 b
+                   300 a
+`
+	if got := fmt.Sprint(ring); got != want {
+		t.Fatalf("String() =\n%s\nwant\n%s", got, want)
+	}
+
+	// the same layout built in another order prints the same
+	other := newTokenRing(t, nil, SetHashFunctionNamed("numeric", newNumericHash))
+	for _, id := range []string{"b", "a"} {
+		if err := other.AddServerWithTokens(testNode(id), tokens[id]); err != nil {
+			t.Fatalf("AddServerWithTokens(%s): %v", id, err)
+		}
+	}
+	if got := other.String(); got != want {
+		t.Fatalf("insertion order changed String():\n%s", got)
+	}
+
+	big := newTestRing(t, []string{"a", "b", "c"}, SetVirtualNodes(10))
+
+	// large rings end with a count of the tokens left out
+	lines := strings.Split(strings.TrimSuffix(big.String(), "\n"), "\n")
+	if got := lines[len(lines)-1]; got != "  … and 10 more tokens" {
+		t.Fatalf("last line %q, want the truncation notice", got)
+	}
+	if got := len(lines); got != 23 {
+		t.Fatalf("%d lines, want 23", got)
+	}
+}
+
+func TestGetServerDetailed(t *testing.T) {
+	ring := newTokenRing(t, map[string][]uint64{"a": {100, 300}, "b": {200}})
+	tests := []struct {
+		key   string
+		token uint64
+		vnode int
+		node  string
+	}{
+		{"150", 200, 0, "b"},
+		{"250", 300, 1, "a"},
+		{"300", 300, 1, "a"},
+		{"350", 100, 0, "a"}, // wraps to the first token
+	}
+	for _, tt := range tests {
+		res, err := ring.GetServerDetailed(tt.key)
+		if err != nil {
+			t.Fatalf("GetServerDetailed(%s): %v", tt.key, err)
+		}
+		want := LookupResult{KeyHash: res.KeyHash, Token: tt.token, VNodeIndex: tt.vnode, Node: testNode(tt.node)}
+		if res != want || strconv.FormatUint(res.KeyHash, 10) != tt.key {
+			t.Errorf("GetServerDetailed(%s) = %+v, want %+v", tt.key, res, want)
+		}
+	}
+
+	// pinned keys report the pin, as GetServer follows it
+	if err := ring.PinKey("150", "a"); err != nil {
+		t.Fatalf("PinKey: %v", err)
+	}
+	res, err := ring.GetServerDetailed("150")
+	if err != nil {
+		t.Fatalf("GetServerDetailed(150): %v", err)
+	}
+	if want := (LookupResult{KeyHash: 150, VNodeIndex: -1, Node: testNode("a"), Pinned: true}); res != want {
+		t.Fatalf("pinned key: got %+v, want %+v", res, want)
+	}
+	if node, err := ring.GetServer("150"); err != nil || node != res.Node {
+		t.Fatalf("GetServer(150) = %v, %v; GetServerDetailed says %v", node, err, res.Node)
+	}
+}
+
+func TestGetServerDetailedDefaultNode(t *testing.T) {
+	ring := newTestRing(t, nil, SetHashFunction(newNumericHash), SetDefaultNode(testNode("fallback")))
+	id := "x"
+	if err := ring.AddServerWithTokens(vanishingNode{&id}, []uint64{100, 200}); err != nil {
+		t.Fatalf("AddServerWithTokens: %v", err)
+	}
+	id = ""
+
+	res, err := ring.GetServerDetailed("150")
+	if err != nil {
+		t.Fatalf("GetServerDetailed: %v", err)
+	}
+	if want := (LookupResult{KeyHash: 150, VNodeIndex: -1, Node: testNode("fallback"), Default: true}); res != want {
+		t.Fatalf("got %+v, want %+v", res, want)
+	}
+}
+
+func TestGetServerDetailedAgreesWithGetServer(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c"}, SetVirtualNodes(20))
+	for _, key := range sampleKeys(300) {
+		res, err := ring.GetServerDetailed(key)
+		if err != nil {
+			t.Fatalf("GetServerDetailed(%q): %v", key, err)
+		}
+		node, err := ring.GetServer(key)
+		if err != nil || node.GetIdentifier() != res.Node.GetIdentifier() {
+			t.Fatalf("key %q: GetServer %v (%v), GetServerDetailed %s", key, node, err, res.Node.GetIdentifier())
+		}
+		if want, err := ring.vNodeHash(res.Node.GetIdentifier(), res.VNodeIndex); err != nil || want != res.Token {
+			t.Fatalf("key %q: token %d is not virtual node %d of %s", key, res.Token, res.VNodeIndex, res.Node.GetIdentifier())
+		}
+	}
+}
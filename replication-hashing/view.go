@@ -0,0 +1,123 @@
+package replicationhashing
+
+import (
+	"fmt"
+	"hash"
+	"slices"
+	"sort"
+)
+
+// RingView is an immutable copy of the ring's layout. Lookups on a view never
+// touch the ring's mutex, which makes it suitable for hot read loops. A view
+// does not observe later topology changes; callers refresh it by capturing a
+// new one when membership changes.
+type RingView struct {
+	positions    []position
+	hashFunction func() hash.Hash64
+	hashTags     bool
+	normalize    func(string) string
+}
+
+// FrozenView captures the current layout as a RingView.
+func (h *HashRing) FrozenView() RingView {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.snapshot()
+}
+
+// Walk calls fn for every token in ascending order, stopping early when fn
+// returns false. It walks a snapshot, so fn may safely call back into the
+// ring.
+func (h *HashRing) Walk(fn func(token uint64, node ICacheNode) bool) {
+	for _, p := range h.FrozenView().positions {
+		if !fn(p.hash, p.node) {
+			return
+		}
+	}
+}
+
+// Distribution resolves every key on a FrozenView and counts how many land
+// on each node, keyed by node identifier. Running on a snapshot keeps a large
+// sample from holding up writers; like any view it follows the token layout
+// only, so pins, MarkDown and DefaultNode are not applied.
+func (h *HashRing) Distribution(keys []string) (map[string]int, error) {
+	view := h.FrozenView()
+	counts := make(map[string]int)
+	for _, key := range keys {
+		node, err := view.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		counts[node.GetIdentifier()]++
+	}
+	return counts, nil
+}
+
+// snapshot copies the layout into a RingView. Callers must hold h.mu.
+func (h *HashRing) snapshot() RingView {
+	return RingView{
+		positions:    slices.Clone(h.positions),
+		hashFunction: h.config.HashFunction,
+		hashTags:     h.config.HashTags,
+		normalize:    h.config.KeyNormalizer,
+	}
+}
+
+// Len returns the number of ring positions in the view.
+func (v RingView) Len() int {
+	return len(v.positions)
+}
+
+// Get returns the node owning key as of the moment the view was captured.
+func (v RingView) Get(key string) (ICacheNode, error) {
+	hashValue, err := v.hashKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w : %s", ErrInHashingKey, key)
+	}
+	return v.owner(hashValue)
+}
+
+// hashKey hashes a lookup key with the settings captured in the view.
+func (v RingView) hashKey(key string) (uint64, error) {
+	if v.normalize != nil {
+		key = v.normalize(key)
+	}
+	if v.hashTags {
+		key = hashTag(key)
+	}
+	hash := v.hashFunction()
+	if _, err := hash.Write([]byte(key)); err != nil {
+		return 0, err
+	}
+	return hash.Sum64(), nil
+}
+
+// owner mirrors HashRing.ownerOf on the copied layout.
+func (v RingView) owner(hashValue uint64) (ICacheNode, error) {
+	if len(v.positions) == 0 {
+		return nil, ErrNoConnectedNodes
+	}
+
+	index := v.index(hashValue)
+	for i := 0; i < len(v.positions); i++ {
+		p := v.positions[(index+i)%len(v.positions)]
+		if p.node == nil || p.node.GetIdentifier() == "" {
+			continue
+		}
+		return p.node, nil
+	}
+
+	return nil, fmt.Errorf("%w: no node owns hash %d", ErrNodeNotFound, hashValue)
+}
+
+// index returns the first position >= hashValue, wrapping to 0.
+func (v RingView) index(hashValue uint64) int {
+	index := sort.Search(len(v.positions), func(i int) bool {
+		return v.positions[i].hash >= hashValue
+	})
+	if index == len(v.positions) {
+		index = 0
+	}
+	return index
+}
@@ -0,0 +1,101 @@
+package replicationhashing
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFrozenViewIsStale(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c"}, SetVirtualNodes(20))
+	view := ring.FrozenView()
+	keys := sampleKeys(500)
+	before := owners(t, ring, keys)
+
+	if err := ring.RemoveServerByID("a"); err != nil {
+		t.Fatalf("RemoveServerByID: %v", err)
+	}
+	if err := ring.AddServer(testNode("d")); err != nil {
+		t.Fatalf("AddServer: %v", err)
+	}
+
+	// the view keeps answering from the layout it captured
+	moved := 0
+	for _, key := range keys {
+		node, err := view.Get(key)
+		if err != nil {
+			t.Fatalf("view.Get(%q): %v", key, err)
+		}
+		if node.GetIdentifier() != before[key] {
+			t.Fatalf("key %q: view says %s, it owned %s when captured", key, node.GetIdentifier(), before[key])
+		}
+		live, err := ring.GetServer(key)
+		if err != nil {
+			t.Fatalf("GetServer(%q): %v", key, err)
+		}
+		if live.GetIdentifier() != before[key] {
+			moved++
+		}
+	}
+	if moved == 0 {
+		t.Fatal("no key moved on the live ring, so the test proves nothing")
+	}
+	if view.Len() != 60 {
+		t.Fatalf("view has %d positions, want the 60 it captured", view.Len())
+	}
+
+	fresh := ring.FrozenView()
+	for _, key := range keys {
+		node, err := fresh.Get(key)
+		if err != nil {
+			t.Fatalf("fresh.Get(%q): %v", key, err)
+		}
+		if node.GetIdentifier() == "a" {
+			t.Fatalf("key %q: a refreshed view still routes to the removed node", key)
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c"}, SetVirtualNodes(10))
+
+	var tokens []uint64
+	ring.Walk(func(token uint64, node ICacheNode) bool {
+		owner, err := ring.GetServerByHash(token)
+		if err != nil || owner.GetIdentifier() != node.GetIdentifier() {
+			t.Fatalf("token %d reported for %s, ring routes it to %v (%v)", token, node.GetIdentifier(), owner, err)
+		}
+		tokens = append(tokens, token)
+		return true
+	})
+	want := make([]uint64, len(ring.positions))
+	for i, p := range ring.positions {
+		want[i] = p.hash
+	}
+	if !slices.IsSorted(tokens) || !slices.Equal(tokens, want) {
+		t.Fatal("Walk order differs from the sorted token slice")
+	}
+
+	visited := 0
+	ring.Walk(func(uint64, ICacheNode) bool {
+		visited++
+		return visited < 5
+	})
+	if visited != 5 {
+		t.Fatalf("Walk visited %d tokens after fn returned false at 5", visited)
+	}
+}
+
+func BenchmarkFrozenViewGet(b *testing.B) {
+	ring := newTestRing(b, []string{"a", "b", "c", "d", "e"})
+	view := ring.FrozenView()
+	keys := sampleKeys(1024)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			if _, err := view.Get(keys[i%len(keys)]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
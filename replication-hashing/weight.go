@@ -0,0 +1,112 @@
+package replicationhashing
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"math"
+	"slices"
+)
+
+// AddServerWithWeight adds node with round(VirtualNodes*weight) virtual
+// nodes (at least one), so larger hosts own a proportionally larger share
+// of the ring.
+func (h *HashRing) AddServerWithWeight(node ICacheNode, weight float64) error {
+	if err := checkWeight(weight, node.GetIdentifier()); err != nil {
+		return err
+	}
+	return h.addServer(node, weight, nil)
+}
+
+// addServer adds node with an already validated weight and optional labels.
+func (h *HashRing) addServer(node ICacheNode, weight float64, meta map[string]string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	m, err := h.placeMember(node, weight, 0, h.tokenTaken)
+	if err != nil {
+		return err
+	}
+	m.meta = maps.Clone(meta)
+	h.commitMembers([]*member{m})
+	return nil
+}
+
+// checkWeight rejects weights that aren't positive and finite.
+func checkWeight(weight float64, nodeID string) error {
+	if !(weight > 0) || math.IsInf(weight, 1) {
+		return fmt.Errorf("%w: %g for %s", ErrInvalidWeight, weight, nodeID)
+	}
+	return nil
+}
+
+// SetWeight changes nodeID's weight at runtime, adding or removing only the
+// difference in virtual nodes. Removal takes the highest-index virtual nodes
+// first, so the node's remaining tokens stay where they are, and at least one
+// virtual node is always kept.
+func (h *HashRing) SetWeight(nodeID string, weight float64) error {
+	if err := checkWeight(weight, nodeID); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	val, ok := h.hostMap.Load(nodeID)
+	if !ok {
+		return fmt.Errorf("%w : %s", ErrNodeNotFound, nodeID)
+	}
+	m := val.(*member)
+	if m.pinned {
+		return fmt.Errorf("%w : %s", ErrPinnedTokens, nodeID)
+	}
+
+	target, current := h.vnodesFor(weight), len(m.tokens)
+	switch {
+	case target > current:
+		if max := h.config.MaxTotalVNodes; max > 0 && len(h.positions)+target-current > max {
+			return fmt.Errorf("%w: reweighting %s would need %d positions, limit is %d",
+				ErrTooManyVNodes, nodeID, len(h.positions)+target-current, max)
+		}
+
+		// place every new token before touching the ring so a failure
+		// leaves it as it was
+		added := make([]position, 0, target-current)
+		own := make(map[uint64]struct{}, target-current)
+		for i := current; i < target; i++ {
+			hash, err := h.placeVNode(nodeID, i, func(hash uint64) bool {
+				_, ok := own[hash]
+				return ok || h.tokenTaken(hash)
+			})
+			if err != nil {
+				return err
+			}
+			own[hash] = struct{}{}
+			added = append(added, position{hash: hash, node: m.node, vnode: i})
+		}
+		for _, p := range added {
+			m.tokens = append(m.tokens, p.hash)
+		}
+		h.positions = insertPositions(h.positions, added)
+	case target < current:
+		h.positions = slices.DeleteFunc(h.positions, func(p position) bool {
+			return p.vnode >= target && p.node.GetIdentifier() == nodeID
+		})
+		m.tokens = m.tokens[:target]
+	}
+	m.weight = weight
+	m.target = 0 // a gradual join in progress ends at the new count
+	h.recordOp(Operation{Kind: OpSetWeight, NodeID: nodeID, Weight: weight})
+
+	if h.config.EnableLogs {
+		log.Printf("[HashRing] Node %s reweighted to %g, %d -> %d virtual nodes", nodeID, weight, current, target)
+	}
+
+	return nil
+}
+
+// vnodesFor scales the configured virtual node count by weight, keeping at
+// least one.
+func (h *HashRing) vnodesFor(weight float64) int {
+	return max(1, int(math.Round(float64(h.config.VirtualNodes)*weight)))
+}
@@ -0,0 +1,123 @@
+package replicationhashing
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// memberTokens returns the tokens recorded for nodeID, in virtual node order.
+func memberTokens(t *testing.T, ring *HashRing, nodeID string) []uint64 {
+	t.Helper()
+	val, ok := ring.hostMap.Load(nodeID)
+	if !ok {
+		t.Fatalf("%s is not a member", nodeID)
+	}
+	return slices.Clone(val.(*member).tokens)
+}
+
+func TestAddServerWithWeight(t *testing.T) {
+	ring := newTestRing(t, nil, SetVirtualNodes(500), SetHashFunction(newSHAHash))
+	if err := ring.AddServerWithWeight(testNode("small"), 1); err != nil {
+		t.Fatalf("AddServerWithWeight(small): %v", err)
+	}
+	if err := ring.AddServerWithWeight(testNode("big"), 3); err != nil {
+		t.Fatalf("AddServerWithWeight(big): %v", err)
+	}
+	if got := len(memberTokens(t, ring, "big")); got != 1500 {
+		t.Fatalf("big has %d tokens, want 1500", got)
+	}
+
+	keys := make([]string, 100000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+	}
+	counts, err := ring.Distribution(keys)
+	if err != nil {
+		t.Fatalf("Distribution: %v", err)
+	}
+	if ratio := float64(counts["big"]) / float64(counts["small"]); ratio < 2.7 || ratio > 3.3 {
+		t.Fatalf("big holds %.2fx the keys of small, want about 3x (%v)", ratio, counts)
+	}
+
+	// a tiny weight still places one virtual node
+	if err := ring.AddServerWithWeight(testNode("tiny"), 0.001); err != nil {
+		t.Fatalf("AddServerWithWeight(tiny): %v", err)
+	}
+	if got := len(memberTokens(t, ring, "tiny")); got != 1 {
+		t.Fatalf("tiny has %d tokens, want 1", got)
+	}
+	for _, w := range []float64{0, -1} {
+		if err := ring.AddServerWithWeight(testNode("bad"), w); !errors.Is(err, ErrInvalidWeight) {
+			t.Fatalf("weight %g: got %v, want ErrInvalidWeight", w, err)
+		}
+	}
+
+	// removal drops every token whatever the weight
+	if err := ring.RemoveServerByID("big"); err != nil {
+		t.Fatalf("RemoveServerByID: %v", err)
+	}
+	if got := ring.VirtualNodeCount(); got != 501 {
+		t.Fatalf("%d tokens after removing big, want 501", got)
+	}
+}
+
+func TestSetWeight(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b"}, SetVirtualNodes(10))
+	original := memberTokens(t, ring, "a")
+	others := memberTokens(t, ring, "b")
+
+	steps := []struct {
+		weight float64
+		tokens int
+	}{
+		{2, 20},
+		{3, 30},
+		{0.5, 5},
+		{0.01, 1}, // never below one virtual node
+		{1, 10},
+	}
+	prev := original
+	for _, step := range steps {
+		if err := ring.SetWeight("a", step.weight); err != nil {
+			t.Fatalf("SetWeight(a, %g): %v", step.weight, err)
+		}
+		got := memberTokens(t, ring, "a")
+		if len(got) != step.tokens {
+			t.Fatalf("weight %g: %d tokens, want %d", step.weight, len(got), step.tokens)
+		}
+		// only the delta moves: the shared prefix keeps its hashes
+		shared := min(len(got), len(prev))
+		if !slices.Equal(got[:shared], prev[:shared]) {
+			t.Fatalf("weight %g moved tokens that should have stayed", step.weight)
+		}
+		if ring.VirtualNodeCount() != step.tokens+len(others) {
+			t.Fatalf("weight %g: ring holds %d positions, want %d", step.weight, ring.VirtualNodeCount(), step.tokens+len(others))
+		}
+		if !slices.Equal(memberTokens(t, ring, "b"), others) {
+			t.Fatalf("weight %g changed another node's tokens", step.weight)
+		}
+		prev = got
+	}
+	if !slices.Equal(prev, original) {
+		t.Fatal("returning to weight 1 did not restore the original tokens")
+	}
+	if !slices.IsSortedFunc(ring.positions, func(a, b position) int { return cmp.Compare(a.hash, b.hash) }) {
+		t.Fatal("positions are not sorted after reweighting")
+	}
+
+	if err := ring.SetWeight("x", 2); !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("unknown node: got %v, want ErrNodeNotFound", err)
+	}
+	if err := ring.SetWeight("a", 0); !errors.Is(err, ErrInvalidWeight) {
+		t.Fatalf("zero weight: got %v, want ErrInvalidWeight", err)
+	}
+	if err := ring.AddServerWithTokens(testNode("pinned"), []uint64{42}); err != nil {
+		t.Fatalf("AddServerWithTokens: %v", err)
+	}
+	if err := ring.SetWeight("pinned", 2); !errors.Is(err, ErrPinnedTokens) {
+		t.Fatalf("pinned node: got %v, want ErrPinnedTokens", err)
+	}
+}
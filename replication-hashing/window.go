@@ -0,0 +1,26 @@
+package replicationhashing
+
+import (
+	"fmt"
+	"time"
+)
+
+// GetServerForWindow routes baseKey for the time window containing
+// windowStart. Windows are aligned on multiples of windowSize since the Unix
+// epoch, so any instant within a window routes identically while the same
+// key in another window may land elsewhere, spreading a long-lived entity's
+// load over time.
+func (h *HashRing) GetServerForWindow(baseKey string, windowStart time.Time, windowSize time.Duration) (ICacheNode, error) {
+	if windowSize <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidWindow, windowSize)
+	}
+
+	bucket := windowStart.UnixNano() / int64(windowSize)
+	if windowStart.UnixNano()%int64(windowSize) < 0 {
+		bucket-- // floor for instants before the epoch
+	}
+
+	// the bucket leads the hashed key so that FNV-style hashes, which barely
+	// mix their last input bytes into the high bits, still move the key
+	return h.GetServer(fmt.Sprintf("%d@%s", bucket, baseKey))
+}
@@ -0,0 +1,51 @@
+package replicationhashing
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGetServerForWindow(t *testing.T) {
+	ring := newTestRing(t, []string{"a", "b", "c", "d"}, SetVirtualNodes(20))
+	const size = time.Hour
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	route := func(key string, at time.Time) string {
+		t.Helper()
+		node, err := ring.GetServerForWindow(key, at, size)
+		if err != nil {
+			t.Fatalf("GetServerForWindow(%q, %v): %v", key, at, err)
+		}
+		return node.GetIdentifier()
+	}
+
+	differs := 0
+	for _, key := range sampleKeys(100) {
+		first := route(key, base)
+		for _, at := range []time.Time{base.Add(time.Minute), base.Add(size - time.Nanosecond)} {
+			if got := route(key, at); got != first {
+				t.Fatalf("key %q: %s at the window start, %s at %v in the same window", key, first, got, at)
+			}
+		}
+		if route(key, base.Add(size)) != first {
+			differs++
+		}
+	}
+	// with four nodes about three in four keys should move
+	if differs < 50 {
+		t.Fatalf("only %d of 100 keys changed node in the next window", differs)
+	}
+
+	// windows before the epoch are floored, not truncated toward zero
+	epoch := time.Unix(0, 0)
+	for _, key := range sampleKeys(50) {
+		if route(key, epoch.Add(-time.Minute)) != route(key, epoch.Add(-size)) {
+			t.Fatalf("key %q: the hour before the epoch is split in two", key)
+		}
+	}
+
+	if _, err := ring.GetServerForWindow("k", base, 0); !errors.Is(err, ErrInvalidWindow) {
+		t.Fatalf("zero window: got %v, want ErrInvalidWindow", err)
+	}
+}
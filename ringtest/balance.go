@@ -0,0 +1,67 @@
+// Package ringtest provides assertions for validating hash ring
+// configurations from tests.
+package ringtest
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	replicationhashing "github.com/Tanishq4501/go-hash/replication-hashing"
+)
+
+// AssertBalanced routes every key through ring and fails t if any node's
+// share of the keys deviates from its expected share by more than tolerance,
+// expressed relative to that share (0.1 allows ±10%). A node's expected share
+// is its fraction of the ring's positions, so weighted nodes are held to
+// their weight. Nodes that receive no keys at all count as a full deviation.
+func AssertBalanced(t testing.TB, ring *replicationhashing.HashRing, keys []string, tolerance float64) {
+	t.Helper()
+
+	if len(keys) == 0 {
+		t.Fatalf("ringtest: no keys to check balance with")
+	}
+
+	positions := make(map[string]int)
+	total := 0
+	ring.Walk(func(_ uint64, node replicationhashing.ICacheNode) bool {
+		positions[node.GetIdentifier()]++
+		total++
+		return true
+	})
+	if total == 0 {
+		t.Fatalf("ringtest: ring has no nodes")
+	}
+
+	counts := make(map[string]int)
+	for _, key := range keys {
+		node, err := ring.GetServer(key)
+		if err != nil {
+			t.Fatalf("ringtest: routing %q: %v", key, err)
+		}
+		counts[node.GetIdentifier()]++
+	}
+
+	ids := make([]string, 0, len(positions))
+	for id := range positions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	missing := 0
+	for _, id := range ids {
+		if counts[id] == 0 {
+			missing++
+			continue
+		}
+		expected := float64(positions[id]) / float64(total)
+		share := float64(counts[id]) / float64(len(keys))
+		if deviation := math.Abs(share/expected - 1); deviation > tolerance {
+			t.Errorf("ringtest: node %s got %.2f%% of keys, expected %.2f%% ±%.0f%% (off by %.0f%%)",
+				id, share*100, expected*100, tolerance*100, deviation*100)
+		}
+	}
+	if missing > 0 {
+		t.Errorf("ringtest: %d of %d nodes received no keys", missing, len(ids))
+	}
+}
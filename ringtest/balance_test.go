@@ -0,0 +1,131 @@
+package ringtest
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"runtime"
+	"testing"
+
+	replicationhashing "github.com/Tanishq4501/go-hash/replication-hashing"
+)
+
+type node string
+
+func (n node) GetIdentifier() string { return string(n) }
+
+// fakeTB records failures instead of failing the real test. Fatalf stops the
+// calling goroutine like the real one does, so it must run through check.
+type fakeTB struct {
+	testing.TB
+	failed bool
+	logs   []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.failed = true
+	f.logs = append(f.logs, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Fatalf(format string, args ...any) {
+	f.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+// shaHash is a well-mixed 64-bit hash built on SHA-256. FNV-1a spreads the
+// short, similar keys used here too unevenly for a tight tolerance.
+type shaHash struct{ buf []byte }
+
+func newSHAHash() hash.Hash64 { return &shaHash{} }
+
+func (s *shaHash) Write(p []byte) (int, error) {
+	s.buf = append(s.buf, p...)
+	return len(p), nil
+}
+
+func (s *shaHash) Sum64() uint64 {
+	sum := sha256.Sum256(s.buf)
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+func (s *shaHash) Sum(b []byte) []byte {
+	return binary.BigEndian.AppendUint64(b, s.Sum64())
+}
+func (s *shaHash) Reset()         { s.buf = s.buf[:0] }
+func (s *shaHash) Size() int      { return 8 }
+func (s *shaHash) BlockSize() int { return 1 }
+
+// check runs AssertBalanced against a fake TB and reports what it recorded.
+func check(ring *replicationhashing.HashRing, keys []string, tolerance float64) *fakeTB {
+	tb := &fakeTB{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		AssertBalanced(tb, ring, keys, tolerance)
+	}()
+	<-done
+	return tb
+}
+
+func sampleKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("user:%d:session", i*7919)
+	}
+	return keys
+}
+
+func TestAssertBalancedPasses(t *testing.T) {
+	ring := replicationhashing.InitHashRing(replicationhashing.SetVirtualNodes(200), replicationhashing.SetHashFunction(newSHAHash))
+	for _, id := range []string{"a", "b", "c", "d"} {
+		if err := ring.AddServer(node(id)); err != nil {
+			t.Fatalf("AddServer(%s): %v", id, err)
+		}
+	}
+	if tb := check(ring, sampleKeys(20000), 0.15); tb.failed {
+		t.Fatalf("balanced ring failed: %v", tb.logs)
+	}
+}
+
+func TestAssertBalancedUsesWeights(t *testing.T) {
+	ring := replicationhashing.InitHashRing(replicationhashing.SetVirtualNodes(200), replicationhashing.SetHashFunction(newSHAHash))
+	if err := ring.AddServer(node("small")); err != nil {
+		t.Fatalf("AddServer: %v", err)
+	}
+	if err := ring.AddServerWithWeight(node("big"), 3); err != nil {
+		t.Fatalf("AddServerWithWeight: %v", err)
+	}
+	if tb := check(ring, sampleKeys(20000), 0.15); tb.failed {
+		t.Fatalf("ring balanced by weight failed: %v", tb.logs)
+	}
+}
+
+func TestAssertBalancedFailsSkewed(t *testing.T) {
+	// one token each, but a owns all but a sliver of the hash space
+	ring := replicationhashing.InitHashRing()
+	if err := ring.AddServerWithTokens(node("a"), []uint64{1 << 10}); err != nil {
+		t.Fatalf("AddServerWithTokens: %v", err)
+	}
+	if err := ring.AddServerWithTokens(node("b"), []uint64{1 << 62}); err != nil {
+		t.Fatalf("AddServerWithTokens: %v", err)
+	}
+	if tb := check(ring, sampleKeys(2000), 0.15); !tb.failed {
+		t.Fatal("skewed ring passed")
+	}
+}
+
+func TestAssertBalancedFailsEmpty(t *testing.T) {
+	if tb := check(replicationhashing.InitHashRing(), sampleKeys(10), 0.1); !tb.failed {
+		t.Fatal("empty ring passed")
+	}
+	ring := replicationhashing.InitHashRing()
+	if err := ring.AddServer(node("a")); err != nil {
+		t.Fatalf("AddServer: %v", err)
+	}
+	if tb := check(ring, nil, 0.1); !tb.failed {
+		t.Fatal("no keys passed")
+	}
+}